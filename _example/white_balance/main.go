@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	divoom "github.com/suapapa/go_divoom"
+)
+
+var (
+	flagWidth int
+	flagPath  string
+)
+
+func main() {
+	flag.IntVar(&flagWidth, "w", 64, "panel width; 16, 32 or 64")
+	flag.StringVar(&flagPath, "f", "", "white balance file (default in user config dir)")
+	flag.Parse()
+
+	if flagPath == "" {
+		var err error
+		flagPath, err = divoom.DefaultWhiteBalancePath()
+		chk(err)
+	}
+
+	ds, err := divoom.FindDevice()
+	chk(err)
+	if len(ds) < 1 {
+		log.Fatal("no divoom device is found")
+	}
+	d := ds[0]
+	c := divoom.NewClient(d)
+
+	wb, ok, err := divoom.LoadWhiteBalance(flagPath, d.DeviceID)
+	chk(err)
+	if !ok {
+		wb = divoom.DefaultWhiteBalance
+	}
+
+	wb, err = c.CalibrateWhiteBalance(os.Stdin, os.Stdout, flagWidth, wb)
+	chk(err)
+
+	err = divoom.SaveWhiteBalance(flagPath, d.DeviceID, wb)
+	chk(err)
+	log.Printf("white balance of %s saved to %s: %+v\n", d.DeviceName, flagPath, wb)
+}
+
+func chk(err error) {
+	if err != nil {
+		log.Fatal(err)
+	}
+}
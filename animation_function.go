@@ -1,12 +1,14 @@
 package divoom
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"image"
+	"image/draw"
 	"image/gif"
-	"net/http"
+	"io"
 
 	"github.com/nfnt/resize"
 	"github.com/oliamb/cutter"
@@ -100,12 +102,138 @@ func (c *Client) ResetSendingAnimationPicID() error {
 }
 
 func (c *Client) SendAnimationGif(id int, gifImg *gif.GIF) error {
-	frameCnt := len(gifImg.Image)
-	imgs := make([]image.Image, frameCnt)
-	delayMSecs := make([]int, frameCnt)
+	frames, delayMSecs := gifFrames(gifImg)
+	imgs, err := fitImgs(frames)
+	if err != nil {
+		return errors.Wrap(err, "fail to set gif")
+	}
+
+	return c.SendAnimationImgs(id, delayMSecs, imgs)
+}
+
+// gifFrames composites frames of gifImg on its canvas, following disposal
+// of each frame, as optimized GIF keeps only changed rect in a frame.
+func gifFrames(gifImg *gif.GIF) ([]image.Image, []int) {
+	rect := image.Rect(0, 0, gifImg.Config.Width, gifImg.Config.Height)
+	if rect.Empty() {
+		for _, p := range gifImg.Image {
+			rect = rect.Union(p.Bounds())
+		}
+	}
+
+	canvas := image.NewRGBA(rect)
+	frames := make([]image.Image, len(gifImg.Image))
+	delayMSecs := make([]int, len(gifImg.Image))
+	for i, p := range gifImg.Image {
+		var disposal byte
+		if i < len(gifImg.Disposal) {
+			disposal = gifImg.Disposal[i]
+		}
+		var prev *image.RGBA
+		if disposal == gif.DisposalPrevious {
+			prev = image.NewRGBA(rect)
+			copy(prev.Pix, canvas.Pix)
+		}
+
+		draw.Draw(canvas, p.Bounds(), p, p.Bounds().Min, draw.Over)
+		frame := image.NewRGBA(rect)
+		copy(frame.Pix, canvas.Pix)
+		frames[i] = frame
+		if i < len(gifImg.Delay) {
+			delayMSecs[i] = gifImg.Delay[i] * 10
+		}
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, p.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = prev
+		}
+	}
+	return frames, delayMSecs
+}
+
+// SendAnimationAPNG sends animated PNG of r, cropped and resized like SendAnimationGif.
+func (c *Client) SendAnimationAPNG(id int, r io.Reader) error {
+	anim, err := DecodeAPNG(r)
+	if err != nil {
+		return err
+	}
+	return c.SendAnimationFit(id, anim)
+}
+
+// SendAnimationWebP sends animated WebP of r, cropped and resized like SendAnimationGif.
+func (c *Client) SendAnimationWebP(id int, r io.Reader) error {
+	anim, err := DecodeWebP(r)
+	if err != nil {
+		return err
+	}
+	return c.SendAnimationFit(id, anim)
+}
+
+// SendAnimationFit sends frames of anim cropped to square and resized to 64, 32 or 16
+// by the size of the first frame, like SendImage.
+func (c *Client) SendAnimationFit(id int, anim Animation) error {
+	imgs, err := fitImgs(anim.Imgs)
+	if err != nil {
+		return errors.Wrap(err, "fail to send animation")
+	}
+	return c.SendAnimationImgs(id, anim.SpeedMSecs, imgs)
+}
+
+// SendImage sends gif, APNG or WebP animation or still image of r. Still image is shown for speedMSec.
+// Like SendAnimationGif, the image is cropped to square and resized to 64, 32 or 16.
+// Decoders of other still image formats, like image/jpeg, should be imported by the caller.
+func (c *Client) SendImage(id int, r io.Reader, speedMSec int) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return errors.Wrap(err, "fail to send image")
+	}
+
+	if g, err := gif.DecodeAll(bytes.NewReader(b)); err == nil {
+		return c.SendAnimationGif(id, g)
+	}
+
+	var anim Animation
+	switch {
+	case IsAPNG(b):
+		anim, err = DecodeAPNG(bytes.NewReader(b))
+	case IsWebP(b):
+		anim, err = DecodeWebP(bytes.NewReader(b))
+	}
+	if err != nil {
+		return errors.Wrap(err, "fail to send image")
+	}
+	if len(anim.Imgs) > 1 {
+		return c.SendAnimationFit(id, anim)
+	}
+	if len(anim.Imgs) == 1 {
+		anim.SpeedMSecs[0] = speedMSec
+		return c.SendAnimationFit(id, anim)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(b))
+	if err != nil {
+		return errors.Wrap(err, "fail to send image")
+	}
+	imgs, err := fitImgs([]image.Image{img})
+	if err != nil {
+		return errors.Wrap(err, "fail to send image")
+	}
+
+	return c.SendAnimationImgs(id, []int{speedMSec}, imgs)
+}
+
+// fitImgs crops center square of imgs and resizes them to 64, 32 or 16,
+// by the size of the first image.
+func fitImgs(frames []image.Image) ([]image.Image, error) {
+	if len(frames) < 1 {
+		return nil, fmt.Errorf("want more than one image")
+	}
+	imgs := make([]image.Image, len(frames))
 
 	// find short length in w and h
-	bound := gifImg.Image[0].Bounds()
+	bound := frames[0].Bounds()
 	w, h := bound.Dx(), bound.Dy()
 	var length int
 	if w < h {
@@ -130,10 +258,10 @@ func (c *Client) SendAnimationGif(id int, gifImg *gif.GIF) error {
 		Mode:   cutter.Centered,
 	}
 
-	for i := 0; i < frameCnt; i++ {
-		cImg, err := cutter.Crop(gifImg.Image[i], cutterCfg)
+	for i := range frames {
+		cImg, err := cutter.Crop(frames[i], cutterCfg)
 		if err != nil {
-			return errors.Wrap(err, "fail to set gif")
+			return nil, err
 		}
 
 		// resize
@@ -142,11 +270,9 @@ func (c *Client) SendAnimationGif(id int, gifImg *gif.GIF) error {
 		} else {
 			imgs[i] = cImg
 		}
-
-		delayMSecs[i] = gifImg.Delay[i] * 10
 	}
 
-	return c.SendAnimationImgs(id, delayMSecs, imgs)
+	return imgs, nil
 }
 
 func (c *Client) SendAnimationImgs(id int, speedMSecs []int, imgs []image.Image) error {
@@ -179,45 +305,58 @@ func (c *Client) SendAnimation(width, id int, speedMSecs []int, picDatas [][]byt
 		return ErrInvalidPicWidth
 	}
 
-	cmd := "Draw/SendHttpGif"
 	for offset := 0; offset < picNum; offset++ {
-		picData := picDatas[offset]
-
-		data := map[string]interface{}{
-			"Command":   cmd,
-			"PicNum":    picNum,
-			"PicWidth":  width,
-			"PicOffset": offset,
-			"PicID":     id,
-			"PicSpeed":  speedMSecs[offset],
-			"PicData":   base64.StdEncoding.EncodeToString(picData),
-		}
-		resp, err := c.do(data)
+		err := c.sendAnimationFrame(width, id, picNum, offset, speedMSecs[offset], picDatas[offset])
 		if err != nil {
-			return errors.Wrap(err, "fail to send animation")
+			return err
 		}
-		defer resp.Body.Close()
+	}
 
-		var ret errorCode
-		err = json.NewDecoder(resp.Body).Decode(&ret)
-		if err != nil {
-			return errors.Wrap(err, "fail to send animation")
-		}
+	return nil
+}
 
-		if ret.ErrorCode != 0 {
-			return fmt.Errorf("fail to send animation: %d", ret.ErrorCode)
-		}
+// sendAnimationFrame sends offset-th frame of an animation of picNum frames.
+// The device starts to play the animation when it gets all the frames.
+func (c *Client) sendAnimationFrame(width, id, picNum, offset, speedMSec int, picData []byte) error {
+	cmd := "Draw/SendHttpGif"
+	data := map[string]interface{}{
+		"Command":   cmd,
+		"PicNum":    picNum,
+		"PicWidth":  width,
+		"PicOffset": offset,
+		"PicID":     id,
+		"PicSpeed":  speedMSec,
+		"PicData":   base64.StdEncoding.EncodeToString(picData),
+	}
+	resp, err := c.do(data)
+	if err != nil {
+		return errors.Wrap(err, "fail to send animation")
+	}
+	defer resp.Body.Close()
+
+	var ret errorCode
+	err = json.NewDecoder(resp.Body).Decode(&ret)
+	if err != nil {
+		return errors.Wrap(err, "fail to send animation")
+	}
+
+	if ret.ErrorCode != 0 {
+		return fmt.Errorf("fail to send animation: %d", ret.ErrorCode)
+	}
+
+	if c.metrics != nil {
+		c.metrics.ObserveAnimationFrames(c.dev, 1)
 	}
 
 	return nil
 }
 
 func imgToRGB24Bytes(img image.Image) []byte {
-	w, h := img.Bounds().Dx(), img.Bounds().Dy()
-	imgData := make([]byte, w*h*3)
+	b0 := img.Bounds()
+	imgData := make([]byte, b0.Dx()*b0.Dy()*3)
 	var i int
-	for y := 0; y < h; y++ {
-		for x := 0; x < w; x++ {
+	for y := b0.Min.Y; y < b0.Max.Y; y++ {
+		for x := b0.Min.X; x < b0.Max.X; x++ {
 			r, g, b, _ := img.At(x, y).RGBA()
 			r8, g8, b8 := r>>8, g>>8, b>>8
 			imgData[i] = byte(r8)
@@ -315,40 +454,6 @@ func (c *Client) ClearAllTextArea() error {
 	return nil
 }
 
-type getFontListResult struct {
-	ReturnCode    int     `json:"ReturnCode"`
-	ReturnMessage string  `json:"ReturnMessage"`
-	FontList      []*Font `json:"FontList"`
-}
-type Font struct {
-	ID      int    `json:"id"`
-	Name    string `json:"name"`
-	Width   string `json:"width"`
-	High    string `json:"high"`
-	Charset string `json:"charset"`
-	Type    int    `json:"type"`
-}
-
-func GetFontList() ([]*Font, error) {
-	resp, err := http.Post("https://app.divoom-gz.com/Device/ReturnSameLANDevice", "", nil)
-	if err != nil {
-		return nil, errors.Wrap(err, "fail to get font list")
-	}
-	defer resp.Body.Close()
-
-	var ret getFontListResult
-	err = json.NewDecoder(resp.Body).Decode(&ret)
-	if err != nil {
-		return nil, errors.Wrap(err, "fail to get font list")
-	}
-
-	if ret.ReturnCode != 0 {
-		return nil, fmt.Errorf("fail to get font list: %s", ret.ReturnMessage)
-	}
-
-	return ret.FontList, nil
-}
-
 // TODO: TBD
 func (c *Client) SendDisplayList() error {
 	return ErrNotImplemented
@@ -0,0 +1,58 @@
+package divoom
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+)
+
+func TestGifFrames(t *testing.T) {
+	none := color.RGBA{}
+	red, blue, green := testPalette[1].(color.RGBA), testPalette[2].(color.RGBA), testPalette[3].(color.RGBA)
+
+	for _, tc := range []struct {
+		name     string
+		disposal byte
+		want     color.RGBA // left half of the last frame
+	}{
+		{"none", gif.DisposalNone, red},
+		{"background", gif.DisposalBackground, none},
+		{"previous", gif.DisposalPrevious, blue},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// second frame is disposed before the third one, on the right half only
+			g := &gif.GIF{
+				Image: []*image.Paletted{
+					paletted(image.Rect(0, 0, 4, 2), 2, 2),
+					paletted(image.Rect(0, 0, 2, 2), 1),
+					paletted(image.Rect(2, 0, 4, 2), 3),
+				},
+				Delay:    []int{1, 2, 3},
+				Disposal: []byte{gif.DisposalNone, tc.disposal, gif.DisposalNone},
+				Config:   image.Config{Width: 4, Height: 2},
+			}
+			frames, delays := gifFrames(g)
+			if len(frames) != 3 {
+				t.Fatalf("got %d frames", len(frames))
+			}
+			if delays[2] != 30 {
+				t.Errorf("delay = %d, want 30", delays[2])
+			}
+			for i, f := range frames {
+				if f.Bounds() != image.Rect(0, 0, 4, 2) {
+					t.Errorf("frame %d of %v, want canvas of the gif", i, f.Bounds())
+				}
+			}
+			if c := color.RGBAModel.Convert(frames[1].At(3, 0)); c != blue {
+				t.Errorf("right of frame 1 = %v, want blue of frame 0", c)
+			}
+			if c := color.RGBAModel.Convert(frames[2].At(0, 0)); c != tc.want {
+				t.Errorf("left of frame 2 = %v, want %v", c, tc.want)
+			}
+			if c := color.RGBAModel.Convert(frames[2].At(3, 0)); c != green {
+				t.Errorf("right of frame 2 = %v, want green", c)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package divoom
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/draw"
+	"image/png"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+type pngChunk struct {
+	typ  string
+	data []byte
+}
+
+// apngFrame is fcTL and image data of a frame of APNG.
+type apngFrame struct {
+	w, h, x, y int
+	delayMSec  int
+	dispose    byte
+	blend      byte
+	data       [][]byte
+}
+
+const (
+	apngDisposeNone       = 0
+	apngDisposeBackground = 1
+	apngDisposePrevious   = 2
+
+	apngBlendSource = 0
+)
+
+// IsAPNG returns true if b is animated PNG.
+func IsAPNG(b []byte) bool {
+	if !bytes.HasPrefix(b, pngSignature) {
+		return false
+	}
+	chunks, err := readPNGChunks(b)
+	if err != nil {
+		return false
+	}
+	for _, c := range chunks {
+		switch c.typ {
+		case "acTL":
+			return true
+		case "IDAT":
+			return false
+		}
+	}
+	return false
+}
+
+// DecodeAPNG decodes frames of animated PNG, composed to the full size, with their delays.
+// PNG which isn't animated is decoded as a frame with 0 delay.
+func DecodeAPNG(r io.Reader) (Animation, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return Animation{}, errors.Wrap(err, "fail to decode apng")
+	}
+	if !bytes.HasPrefix(b, pngSignature) {
+		return Animation{}, fmt.Errorf("fail to decode apng: not png")
+	}
+	chunks, err := readPNGChunks(b)
+	if err != nil {
+		return Animation{}, errors.Wrap(err, "fail to decode apng")
+	}
+
+	var ihdr []byte
+	var shared []pngChunk // chunks like PLTE and tRNS which each frame needs
+	var frames []*apngFrame
+	var cur *apngFrame
+	animated := false
+	for _, c := range chunks {
+		switch c.typ {
+		case "IHDR":
+			ihdr = c.data
+		case "acTL":
+			animated = true
+		case "fcTL":
+			if len(c.data) < 26 {
+				return Animation{}, fmt.Errorf("fail to decode apng: short fcTL")
+			}
+			cur = parseFCTL(c.data)
+			frames = append(frames, cur)
+		case "IDAT":
+			// IDAT is the first frame only if fcTL comes before it
+			if cur != nil {
+				cur.data = append(cur.data, c.data)
+			}
+		case "fdAT":
+			if cur == nil || len(c.data) < 4 {
+				return Animation{}, fmt.Errorf("fail to decode apng: unexpected fdAT")
+			}
+			cur.data = append(cur.data, c.data[4:])
+		case "IEND":
+		default:
+			if len(frames) == 0 {
+				shared = append(shared, c)
+			}
+		}
+	}
+	if len(ihdr) < 13 {
+		return Animation{}, fmt.Errorf("fail to decode apng: no IHDR")
+	}
+
+	if !animated || len(frames) == 0 {
+		img, err := png.Decode(bytes.NewReader(b))
+		if err != nil {
+			return Animation{}, errors.Wrap(err, "fail to decode apng")
+		}
+		return Animation{Imgs: []image.Image{img}, SpeedMSecs: []int{0}}, nil
+	}
+
+	w := int(binary.BigEndian.Uint32(ihdr[0:]))
+	h := int(binary.BigEndian.Uint32(ihdr[4:]))
+	canvas := image.NewRGBA(image.Rect(0, 0, w, h))
+
+	var anim Animation
+	for i, f := range frames {
+		img, err := decodeAPNGFrame(ihdr, shared, f)
+		if err != nil {
+			return Animation{}, errors.Wrapf(err, "fail to decode apng frame %d", i)
+		}
+
+		r := image.Rect(f.x, f.y, f.x+f.w, f.y+f.h)
+		var prev *image.RGBA
+		if f.dispose == apngDisposePrevious && i > 0 {
+			prev = image.NewRGBA(canvas.Rect)
+			copy(prev.Pix, canvas.Pix)
+		}
+
+		op := draw.Over
+		if f.blend == apngBlendSource {
+			op = draw.Src
+		}
+		draw.Draw(canvas, r, img, img.Bounds().Min, op)
+
+		out := image.NewRGBA(canvas.Rect)
+		copy(out.Pix, canvas.Pix)
+		anim.Imgs = append(anim.Imgs, out)
+		anim.SpeedMSecs = append(anim.SpeedMSecs, f.delayMSec)
+
+		switch {
+		case prev != nil:
+			canvas = prev
+		case f.dispose == apngDisposeBackground, f.dispose == apngDisposePrevious:
+			// previous of the first frame is the background
+			draw.Draw(canvas, r, image.Transparent, image.Point{}, draw.Src)
+		}
+	}
+	return anim, nil
+}
+
+func parseFCTL(d []byte) *apngFrame {
+	num := int(binary.BigEndian.Uint16(d[20:]))
+	den := int(binary.BigEndian.Uint16(d[22:]))
+	if den == 0 {
+		den = 100
+	}
+	return &apngFrame{
+		w:         int(binary.BigEndian.Uint32(d[4:])),
+		h:         int(binary.BigEndian.Uint32(d[8:])),
+		x:         int(binary.BigEndian.Uint32(d[12:])),
+		y:         int(binary.BigEndian.Uint32(d[16:])),
+		delayMSec: num * 1000 / den,
+		dispose:   d[24],
+		blend:     d[25],
+	}
+}
+
+// decodeAPNGFrame decodes f as standalone PNG of the frame size.
+func decodeAPNGFrame(ihdr []byte, shared []pngChunk, f *apngFrame) (image.Image, error) {
+	if len(f.data) == 0 {
+		return nil, fmt.Errorf("no image data")
+	}
+
+	var buf bytes.Buffer
+	buf.Write(pngSignature)
+
+	hdr := append([]byte(nil), ihdr...)
+	binary.BigEndian.PutUint32(hdr[0:], uint32(f.w))
+	binary.BigEndian.PutUint32(hdr[4:], uint32(f.h))
+	writePNGChunk(&buf, "IHDR", hdr)
+	for _, c := range shared {
+		writePNGChunk(&buf, c.typ, c.data)
+	}
+	for _, d := range f.data {
+		writePNGChunk(&buf, "IDAT", d)
+	}
+	writePNGChunk(&buf, "IEND", nil)
+
+	return png.Decode(&buf)
+}
+
+func readPNGChunks(b []byte) ([]pngChunk, error) {
+	var chunks []pngChunk
+	b = b[len(pngSignature):]
+	for len(b) >= 12 {
+		n := binary.BigEndian.Uint32(b)
+		if uint64(n)+12 > uint64(len(b)) {
+			return nil, fmt.Errorf("truncated chunk")
+		}
+		chunks = append(chunks, pngChunk{typ: string(b[4:8]), data: b[8 : 8+n]})
+		b = b[12+n:]
+	}
+	return chunks, nil
+}
+
+func writePNGChunk(w io.Writer, typ string, data []byte) {
+	var hdr [8]byte
+	binary.BigEndian.PutUint32(hdr[:4], uint32(len(data)))
+	copy(hdr[4:], typ)
+	w.Write(hdr[:])
+	w.Write(data)
+
+	crc := crc32.NewIEEE()
+	crc.Write(hdr[4:])
+	crc.Write(data)
+	binary.Write(w, binary.BigEndian, crc.Sum32())
+}
@@ -0,0 +1,147 @@
+package divoom
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// pixelColors are colors of the letters in rows of frames of fixtures.
+var pixelColors = map[byte]color.NRGBA{
+	'R': {255, 0, 0, 255},
+	'G': {0, 255, 0, 255},
+	'B': {0, 0, 255, 255},
+	'W': {255, 255, 255, 255},
+	'.': {},
+}
+
+type animTest struct {
+	file       string
+	wantDelays []int
+	wantFrames [][]string // rows of pixels of each frame
+}
+
+func checkAnimation(t *testing.T, anim Animation, tc animTest) {
+	t.Helper()
+	if !reflect.DeepEqual(anim.SpeedMSecs, tc.wantDelays) {
+		t.Errorf("delays = %v, want %v", anim.SpeedMSecs, tc.wantDelays)
+	}
+	if len(anim.Imgs) != len(tc.wantFrames) {
+		t.Fatalf("%d frames, want %d", len(anim.Imgs), len(tc.wantFrames))
+	}
+	for i, rows := range tc.wantFrames {
+		checkPixels(t, i, anim.Imgs[i], rows)
+	}
+}
+
+func checkPixels(t *testing.T, frame int, img image.Image, rows []string) {
+	t.Helper()
+	b := img.Bounds()
+	if b.Dx() != len(rows[0]) || b.Dy() != len(rows) {
+		t.Fatalf("frame %d is %dx%d, want %dx%d", frame, b.Dx(), b.Dy(), len(rows[0]), len(rows))
+	}
+	for y, row := range rows {
+		for x := range row {
+			got := color.NRGBAModel.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.NRGBA)
+			want := pixelColors[row[x]]
+			if got.A == 0 && want.A == 0 {
+				continue
+			}
+			if got != want {
+				t.Errorf("frame %d: pixel (%d,%d) = %v, want %v (%c)", frame, x, y, got, want, row[x])
+			}
+		}
+	}
+}
+
+func TestDecodeAPNG(t *testing.T) {
+	tcs := []animTest{
+		{
+			// dispose to background and previous, blend source and over,
+			// the first frame in IDAT and delay of 0 denominator
+			file:       "testdata/anim.png",
+			wantDelays: []int{100, 200, 30, 10},
+			wantFrames: [][]string{
+				{"RRRR", "RRRR", "RRRR", "RRRR"},
+				{"RRRR", "RRRR", "RRRB", "RRBB"},
+				{"GGRR", "GGRR", "RR..", "RR.."},
+				{"RRRB", "RRRR", "RR..", "RR.."},
+			},
+		},
+		{
+			// paletted frames sharing PLTE and tRNS, in fdAT only,
+			// with white default image which isn't a frame
+			file:       "testdata/anim-default-image.png",
+			wantDelays: []int{50, 50},
+			wantFrames: [][]string{
+				{"GGGG", "GGGG", "GGGG", "GGGG"},
+				{"GGGG", "G..G", "G..G", "GGGG"},
+			},
+		},
+		{
+			file:       "testdata/still.png",
+			wantDelays: []int{0},
+			wantFrames: [][]string{
+				{"RRRR", "RRRR", "RRRR", "RRRR"},
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.file, func(t *testing.T) {
+			b, err := os.ReadFile(tc.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			anim, err := DecodeAPNG(bytes.NewReader(b))
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkAnimation(t, anim, tc)
+		})
+	}
+}
+
+func TestIsAPNG(t *testing.T) {
+	tcs := []struct {
+		file string
+		want bool
+	}{
+		{"testdata/anim.png", true},
+		{"testdata/anim-default-image.png", true},
+		{"testdata/still.png", false},
+		{"testdata/anim.webp", false},
+	}
+	for _, tc := range tcs {
+		b, err := os.ReadFile(tc.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := IsAPNG(b); got != tc.want {
+			t.Errorf("IsAPNG(%s) = %v, want %v", tc.file, got, tc.want)
+		}
+	}
+}
+
+func TestDecodeAPNGError(t *testing.T) {
+	b, err := os.ReadFile("testdata/anim.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tcs := []struct {
+		name string
+		data []byte
+	}{
+		{"not png", []byte("GIF89a")},
+		{"truncated", b[:len(b)/2]},
+	}
+	for _, tc := range tcs {
+		if _, err := DecodeAPNG(bytes.NewReader(tc.data)); err == nil {
+			t.Errorf("%s: no error", tc.name)
+		}
+	}
+}
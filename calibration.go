@@ -0,0 +1,301 @@
+package divoom
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type CalibrationPattern int
+
+const (
+	CalibrationPatternGrayRamp CalibrationPattern = iota
+	CalibrationPatternWhite
+	CalibrationPatternRed
+	CalibrationPatternGreen
+	CalibrationPatternBlue
+	CalibrationPatternColorBars
+	calibrationPatternCnt
+)
+
+func (p CalibrationPattern) String() string {
+	switch p {
+	case CalibrationPatternGrayRamp:
+		return "gray ramp"
+	case CalibrationPatternWhite:
+		return "white"
+	case CalibrationPatternRed:
+		return "red"
+	case CalibrationPatternGreen:
+		return "green"
+	case CalibrationPatternBlue:
+		return "blue"
+	case CalibrationPatternColorBars:
+		return "color bars"
+	}
+	return fmt.Sprintf("CalibrationPattern(%d)", int(p))
+}
+
+// CalibrationImage draws test pattern p in size x size.
+func CalibrationImage(p CalibrationPattern, size int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+
+	bars := []color.RGBA{
+		{255, 255, 255, 255}, {255, 255, 0, 255}, {0, 255, 255, 255}, {0, 255, 0, 255},
+		{255, 0, 255, 255}, {255, 0, 0, 255}, {0, 0, 255, 255}, {0, 0, 0, 255},
+	}
+
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			var c color.RGBA
+			switch p {
+			case CalibrationPatternGrayRamp:
+				// 8 steps from black to white, from top to bottom
+				v := uint8(y * 8 / size * 255 / 7)
+				c = color.RGBA{v, v, v, 255}
+			case CalibrationPatternWhite:
+				c = color.RGBA{255, 255, 255, 255}
+			case CalibrationPatternRed:
+				c = color.RGBA{255, 0, 0, 255}
+			case CalibrationPatternGreen:
+				c = color.RGBA{0, 255, 0, 255}
+			case CalibrationPatternBlue:
+				c = color.RGBA{0, 0, 255, 255}
+			case CalibrationPatternColorBars:
+				c = bars[x*len(bars)/size]
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+
+	return img
+}
+
+// ShowCalibrationPattern shows test pattern p on the device of width, which should be 16, 32 or 64.
+func (c *Client) ShowCalibrationPattern(p CalibrationPattern, width int) error {
+	err := c.ResetSendingAnimationPicID()
+	if err != nil {
+		return errors.Wrap(err, "fail to show calibration pattern")
+	}
+
+	img := CalibrationImage(p, width)
+	err = c.SendAnimationImgs(1, []int{1000}, []image.Image{img})
+	if err != nil {
+		return errors.Wrap(err, "fail to show calibration pattern")
+	}
+
+	return nil
+}
+
+type WhiteBalance struct {
+	R int `json:"r"`
+	G int `json:"g"`
+	B int `json:"b"`
+}
+
+var DefaultWhiteBalance = WhiteBalance{R: 100, G: 100, B: 100}
+
+func (c *Client) ApplyWhiteBalance(wb WhiteBalance) error {
+	return c.SetWhiteBalance(wb.R, wb.G, wb.B)
+}
+
+// DefaultWhiteBalancePath returns path of white balance file, ~/.config/divoom/white_balance.json,
+// next to the registry of DefaultRegistryPath.
+func DefaultWhiteBalancePath() (string, error) {
+	path, err := configPath("white_balance.json")
+	if err != nil {
+		return "", errors.Wrap(err, "fail to get white balance path")
+	}
+	return path, nil
+}
+
+// LoadWhiteBalance loads white balance of the device from the file of path,
+// which keeps white balance per DeviceID. ok is false if not saved.
+func LoadWhiteBalance(path string, deviceID int) (wb WhiteBalance, ok bool, err error) {
+	wbs, err := loadWhiteBalances(path)
+	if err != nil {
+		return wb, false, err
+	}
+	wb, ok = wbs[strconv.Itoa(deviceID)]
+	return wb, ok, nil
+}
+
+// SaveWhiteBalance saves white balance of the device to the file of path.
+// White balances of other devices in the file are kept.
+func SaveWhiteBalance(path string, deviceID int, wb WhiteBalance) error {
+	wbs, err := loadWhiteBalances(path)
+	if err != nil {
+		return err
+	}
+	wbs[strconv.Itoa(deviceID)] = wb
+
+	b, err := json.MarshalIndent(wbs, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "fail to save white balance")
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return errors.Wrap(err, "fail to save white balance")
+	}
+	err = os.WriteFile(path, b, 0644)
+	if err != nil {
+		return errors.Wrap(err, "fail to save white balance")
+	}
+
+	return nil
+}
+
+func loadWhiteBalances(path string) (map[string]WhiteBalance, error) {
+	wbs := make(map[string]WhiteBalance)
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return wbs, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to load white balance")
+	}
+
+	err = json.Unmarshal(b, &wbs)
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to load white balance")
+	}
+	return wbs, nil
+}
+
+const calibrateHelp = `commands:
+  r+ [n], r- [n]  step red up or down by n (default 5)
+  g+ [n], g- [n]  step green
+  b+ [n], b- [n]  step blue
+  set R G B       set all values
+  p               show next test pattern
+  reset           back to 100 100 100
+  done            finish calibration
+  quit            abort calibration
+`
+
+// CalibrateWhiteBalance runs interactive white balance calibration of the device of width.
+// It shows test patterns and reads commands line by line from in, writing prompts to out.
+// It returns the white balance when operator types done.
+// On quit or end of in, start is applied back and returned with error.
+func (c *Client) CalibrateWhiteBalance(in io.Reader, out io.Writer, width int, start WhiteBalance) (WhiteBalance, error) {
+	wb := start
+	pattern := CalibrationPatternGrayRamp
+
+	err := c.ShowCalibrationPattern(pattern, width)
+	if err != nil {
+		return wb, errors.Wrap(err, "fail to calibrate white balance")
+	}
+	err = c.ApplyWhiteBalance(wb)
+	if err != nil {
+		return wb, errors.Wrap(err, "fail to calibrate white balance")
+	}
+
+	abort := func(cause error) (WhiteBalance, error) {
+		if err := c.ApplyWhiteBalance(start); err != nil {
+			return start, errors.Wrap(err, "fail to calibrate white balance: fail to restore")
+		}
+		return start, cause
+	}
+
+	fmt.Fprint(out, calibrateHelp)
+	sc := bufio.NewScanner(in)
+	for {
+		fmt.Fprintf(out, "[%s] r=%d g=%d b=%d > ", pattern, wb.R, wb.G, wb.B)
+		if !sc.Scan() {
+			if err := sc.Err(); err != nil {
+				return abort(errors.Wrap(err, "fail to calibrate white balance"))
+			}
+			return abort(errors.New("fail to calibrate white balance: aborted"))
+		}
+
+		args := strings.Fields(sc.Text())
+		if len(args) == 0 {
+			continue
+		}
+
+		next := wb
+		step := 5
+		if len(args) > 1 && args[0] != "set" {
+			step, err = strconv.Atoi(args[1])
+			if err != nil {
+				fmt.Fprintln(out, "invalid step:", args[1])
+				continue
+			}
+		}
+
+		switch args[0] {
+		case "r+":
+			next.R += step
+		case "r-":
+			next.R -= step
+		case "g+":
+			next.G += step
+		case "g-":
+			next.G -= step
+		case "b+":
+			next.B += step
+		case "b-":
+			next.B -= step
+		case "set":
+			if len(args) != 4 {
+				fmt.Fprintln(out, "usage: set R G B")
+				continue
+			}
+			var vs [3]int
+			for i := range vs {
+				vs[i], err = strconv.Atoi(args[i+1])
+				if err != nil {
+					break
+				}
+			}
+			if err != nil {
+				fmt.Fprintln(out, "usage: set R G B")
+				continue
+			}
+			next = WhiteBalance{R: vs[0], G: vs[1], B: vs[2]}
+		case "reset":
+			next = DefaultWhiteBalance
+		case "p":
+			pattern = (pattern + 1) % calibrationPatternCnt
+			err = c.ShowCalibrationPattern(pattern, width)
+			if err != nil {
+				fmt.Fprintln(out, err)
+			}
+			continue
+		case "done":
+			return wb, nil
+		case "quit":
+			return abort(errors.New("fail to calibrate white balance: aborted"))
+		default:
+			fmt.Fprint(out, calibrateHelp)
+			continue
+		}
+
+		next.R, next.G, next.B = clampPercent(next.R), clampPercent(next.G), clampPercent(next.B)
+		err = c.ApplyWhiteBalance(next)
+		if err != nil {
+			fmt.Fprintln(out, err)
+			continue
+		}
+		wb = next
+	}
+}
+
+func clampPercent(v int) int {
+	if v < 0 {
+		return 0
+	}
+	if v > 100 {
+		return 100
+	}
+	return v
+}
@@ -0,0 +1,86 @@
+package divoom
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestSetWhiteBalance(t *testing.T) {
+	c, d := newFakeClient()
+	if err := c.SetWhiteBalance(90, 80, 70); err != nil {
+		t.Fatal(err)
+	}
+	cmd := d.last()
+	if cmd["Command"] != "Device/SetWhiteBalance" ||
+		cmd["RValue"] != float64(90) || cmd["GValue"] != float64(80) || cmd["BValue"] != float64(70) {
+		t.Errorf("sent %v", cmd)
+	}
+
+	if err := c.SetWhiteBalance(101, 0, 0); err != ErrInvalidWhiteBalance {
+		t.Errorf("error = %v, want ErrInvalidWhiteBalance", err)
+	}
+}
+
+func TestCalibrateWhiteBalanceAbort(t *testing.T) {
+	start := WhiteBalance{R: 100, G: 90, B: 80}
+	for _, tc := range []struct {
+		name string
+		in   io.Reader
+	}{
+		{"quit", strings.NewReader("r- 10\nquit\n")},
+		{"eof", strings.NewReader("r- 10\n")},
+		{"read error", io.MultiReader(strings.NewReader("r- 10\n"), iotest.ErrReader(errors.New("broken")))},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c, d := newFakeClient()
+			wb, err := c.CalibrateWhiteBalance(tc.in, io.Discard, 16, start)
+			if err == nil {
+				t.Error("no error for aborted calibration")
+			}
+			if wb != start {
+				t.Errorf("returned %+v, want %+v", wb, start)
+			}
+			// r- 10 is applied and then start is restored
+			cmd := d.last()
+			if cmd["Command"] != "Device/SetWhiteBalance" || cmd["RValue"] != float64(100) {
+				t.Errorf("last sent %v, want start to be restored", cmd)
+			}
+		})
+	}
+}
+
+func TestCalibrateWhiteBalanceDone(t *testing.T) {
+	c, d := newFakeClient()
+	wb, err := c.CalibrateWhiteBalance(strings.NewReader("r- 10\ng+\nset 1 2 x\nb- 200\ndone\n"), io.Discard, 16, DefaultWhiteBalance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := WhiteBalance{R: 90, G: 100, B: 0}
+	if wb != want {
+		t.Errorf("returned %+v, want %+v", wb, want)
+	}
+	if cmd := d.last(); cmd["BValue"] != float64(0) {
+		t.Errorf("last sent %v", cmd)
+	}
+}
+
+func TestDefaultWhiteBalancePath(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	wbPath, err := DefaultWhiteBalancePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	regPath, err := DefaultRegistryPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(wbPath) != filepath.Join(dir, "divoom") || filepath.Dir(wbPath) != filepath.Dir(regPath) {
+		t.Errorf("white balance in %s, registry in %s", wbPath, regPath)
+	}
+}
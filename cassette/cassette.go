@@ -0,0 +1,249 @@
+// Package cassette records traffic between Client and a real device to a file,
+// and replays it to Client in tests without the device.
+//
+// Record a session:
+//
+//	rec := cassette.NewRecorder(http.DefaultTransport)
+//	c := divoom.NewClient(dev, divoom.WithHTTPClient(&http.Client{Transport: rec}))
+//	// ... drive the device ...
+//	err := rec.Save("testdata/brightness.json")
+//
+// Replay it:
+//
+//	rp, err := cassette.Load("testdata/brightness.json")
+//	c := divoom.NewClient(&divoom.Device{DevicePrivateIP: "pixoo"},
+//		divoom.WithHTTPClient(&http.Client{Transport: rp.Replayer("Brightness")}))
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Interaction is a pair of command and reply.
+type Interaction struct {
+	Command  string                 `json:"command"`
+	Request  map[string]interface{} `json:"request"`
+	Status   int                    `json:"status"`
+	Response json.RawMessage        `json:"response"`
+}
+
+// Cassette is recorded interactions, in order.
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Load loads cassette from path.
+func Load(path string) (*Cassette, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to load cassette")
+	}
+
+	var c Cassette
+	err = json.Unmarshal(b, &c)
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to load cassette")
+	}
+	return &c, nil
+}
+
+// Save writes the cassette to path.
+func (c *Cassette) Save(path string) error {
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "fail to save cassette")
+	}
+	err = os.WriteFile(path, b, 0644)
+	if err != nil {
+		return errors.Wrap(err, "fail to save cassette")
+	}
+	return nil
+}
+
+// Recorder is http.RoundTripper which records commands and replies passing through it.
+type Recorder struct {
+	next http.RoundTripper
+
+	mu sync.Mutex
+	c  Cassette
+}
+
+// NewRecorder returns Recorder sending requests with next.
+func NewRecorder(next http.RoundTripper) *Recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Recorder{next: next}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, cmd, err := readCommand(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	b, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to record")
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(b))
+
+	raw := json.RawMessage(b)
+	if !json.Valid(b) {
+		// keep invalid reply as JSON string
+		raw, _ = json.Marshal(string(b))
+	}
+
+	r.mu.Lock()
+	r.c.Interactions = append(r.c.Interactions, &Interaction{
+		Command:  cmd,
+		Request:  body,
+		Status:   resp.StatusCode,
+		Response: raw,
+	})
+	r.mu.Unlock()
+
+	return resp, nil
+}
+
+// Cassette returns copy of the recorded interactions.
+func (r *Recorder) Cassette() *Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return &Cassette{
+		Interactions: append([]*Interaction(nil), r.c.Interactions...),
+	}
+}
+
+// Save writes the recorded interactions to path.
+func (r *Recorder) Save(path string) error {
+	return r.Cassette().Save(path)
+}
+
+// Replayer is http.RoundTripper which replies with the interactions of a cassette.
+// A request matches an interaction if Command and the selected params are equal.
+// Interactions are replayed in order and each is used once, so repeated
+// commands get their replies in the recorded order.
+type Replayer struct {
+	c      *Cassette
+	params []string
+
+	mu   sync.Mutex
+	used []bool
+}
+
+// Replayer returns Replayer of the cassette matching requests on Command and params.
+func (c *Cassette) Replayer(params ...string) *Replayer {
+	return &Replayer{
+		c:      c,
+		params: params,
+		used:   make([]bool, len(c.Interactions)),
+	}
+}
+
+func (rp *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, cmd, err := readCommand(req)
+	if err != nil {
+		return nil, err
+	}
+
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	for i, it := range rp.c.Interactions {
+		if rp.used[i] || it.Command != cmd || !rp.match(it.Request, body) {
+			continue
+		}
+		rp.used[i] = true
+
+		resp := []byte(it.Response)
+		var s string
+		if json.Unmarshal(resp, &s) == nil {
+			resp = []byte(s)
+		}
+		status := it.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		return &http.Response{
+			StatusCode: status,
+			Status:     fmt.Sprintf("%d %s", status, http.StatusText(status)),
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(bytes.NewReader(resp)),
+			Request:    req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("no recorded interaction for %s %v", cmd, rp.selected(body))
+}
+
+// Unused returns interactions which are not replayed yet.
+// Tests can check it's empty to make sure all recorded commands are sent.
+func (rp *Replayer) Unused() []*Interaction {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	var ret []*Interaction
+	for i, it := range rp.c.Interactions {
+		if !rp.used[i] {
+			ret = append(ret, it)
+		}
+	}
+	return ret
+}
+
+func (rp *Replayer) match(recorded, got map[string]interface{}) bool {
+	for _, p := range rp.params {
+		if !reflect.DeepEqual(recorded[p], got[p]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (rp *Replayer) selected(body map[string]interface{}) map[string]interface{} {
+	ret := make(map[string]interface{})
+	for _, p := range rp.params {
+		ret[p] = body[p]
+	}
+	return ret
+}
+
+// readCommand reads JSON body of req and gives the body back.
+func readCommand(req *http.Request) (map[string]interface{}, string, error) {
+	body := make(map[string]interface{})
+	if req.Body == nil {
+		return body, "", nil
+	}
+
+	b, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, "", errors.Wrap(err, "fail to read command")
+	}
+	req.Body = io.NopCloser(bytes.NewReader(b))
+
+	if len(bytes.TrimSpace(b)) > 0 {
+		err = json.Unmarshal(b, &body)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "fail to read command")
+		}
+	}
+	cmd, _ := body["Command"].(string)
+	return body, cmd, nil
+}
@@ -0,0 +1,133 @@
+package cassette_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	divoom "github.com/suapapa/go_divoom"
+	"github.com/suapapa/go_divoom/cassette"
+)
+
+func replayClient(t *testing.T, c *cassette.Cassette, params ...string) (*divoom.Client, *cassette.Replayer) {
+	t.Helper()
+	rp := c.Replayer(params...)
+	cl := divoom.NewClient(&divoom.Device{DevicePrivateIP: "pixoo"},
+		divoom.WithHTTPClient(&http.Client{Transport: rp}))
+	return cl, rp
+}
+
+func TestReplay(t *testing.T) {
+	c, err := cassette.Load("testdata/brightness.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cl, rp := replayClient(t, c, "Brightness", "OnOff")
+
+	set, err := cl.GetAllSetting()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if set["Brightness"] != float64(100) {
+		t.Errorf("brightness = %v, want 100", set["Brightness"])
+	}
+
+	if err := cl.SetBrightness(30); err != nil {
+		t.Fatal(err)
+	}
+
+	// the same command gets the next recorded reply
+	set, err = cl.GetAllSetting()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if set["Brightness"] != float64(30) {
+		t.Errorf("brightness = %v, want 30", set["Brightness"])
+	}
+
+	// error_code of the reply is returned as error
+	if err := cl.ScreenSwitch(false); err == nil {
+		t.Error("no error for error_code 1")
+	}
+
+	if u := rp.Unused(); len(u) != 0 {
+		t.Errorf("%d interactions not replayed", len(u))
+	}
+}
+
+func TestReplayUnmatched(t *testing.T) {
+	c, err := cassette.Load("testdata/brightness.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cl, rp := replayClient(t, c, "Brightness")
+
+	if err := cl.SetBrightness(50); err == nil {
+		t.Error("no error for brightness not recorded")
+	}
+	if err := cl.SetBrightness(30); err != nil {
+		t.Fatal(err)
+	}
+	if err := cl.SetBrightness(30); err == nil {
+		t.Error("no error for interaction replayed twice")
+	}
+	if u := rp.Unused(); len(u) != 3 {
+		t.Errorf("%d interactions not replayed, want 3", len(u))
+	}
+}
+
+func TestRecord(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"error_code": 0}`))
+	}))
+	defer srv.Close()
+
+	rec := cassette.NewRecorder(http.DefaultTransport)
+	cl := divoom.NewClient(&divoom.Device{DevicePrivateIP: "pixoo"},
+		divoom.WithHTTPClient(&http.Client{Transport: rewrite{srv.URL, rec}}))
+	if err := cl.SetBrightness(70); err != nil {
+		t.Fatal(err)
+	}
+	if err := cl.ScreenSwitch(true); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "record.json")
+	if err := rec.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	c, err := cassette.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Interactions) != 2 || c.Interactions[0].Command != "Channel/SetBrightness" {
+		t.Fatalf("recorded %+v", c.Interactions)
+	}
+
+	cl, rp := replayClient(t, c, "Brightness", "OnOff")
+	if err := cl.SetBrightness(70); err != nil {
+		t.Fatal(err)
+	}
+	if err := cl.ScreenSwitch(true); err != nil {
+		t.Fatal(err)
+	}
+	if u := rp.Unused(); len(u) != 0 {
+		t.Errorf("%d interactions not replayed", len(u))
+	}
+}
+
+// rewrite sends requests to the device to the test server.
+type rewrite struct {
+	url  string
+	next http.RoundTripper
+}
+
+func (rw rewrite) RoundTrip(req *http.Request) (*http.Response, error) {
+	r, err := http.NewRequest(req.Method, rw.url+req.URL.Path, req.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.Header = req.Header
+	return rw.next.RoundTrip(r)
+}
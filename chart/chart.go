@@ -0,0 +1,259 @@
+// Package chart renders charts for tiny panels, like sparklines, bars,
+// gauges and big numbers. Frames are ready for Client.SendAnimationImgs:
+//
+//	img := chart.Gauge(cpu, image.Pt(64, 64), chart.Options{
+//		Thresholds: []chart.Threshold{{Value: 80, Color: color.RGBA{255, 0, 0, 255}}},
+//	})
+//	err := c.SendAnimationImgs(1, []int{1000}, []image.Image{img})
+package chart
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/suapapa/go_divoom/internal/pixfont"
+)
+
+// Threshold colors values at or above Value in Color.
+type Threshold struct {
+	Value float64
+	Color color.Color
+}
+
+// Range is scale of values.
+type Range struct {
+	Min, Max float64
+}
+
+// Options is style of a chart.
+type Options struct {
+	Color      color.Color // white by default
+	Background color.Color // black by default
+	Track      color.Color // unfilled part of gauge; dark gray by default
+	// Thresholds overrides Color by value. The highest one which
+	// the value reaches is used.
+	Thresholds []Threshold
+	// Range is fixed scale. Sparkline and Bars scale to min and max of values
+	// if nil, and Gauge uses 0~100.
+	Range *Range
+}
+
+func (o Options) color(v float64) color.Color {
+	c := o.Color
+	if c == nil {
+		c = color.White
+	}
+	best := math.Inf(-1)
+	for _, t := range o.Thresholds {
+		if v >= t.Value && t.Value >= best {
+			c, best = t.Color, t.Value
+		}
+	}
+	return c
+}
+
+func (o Options) background() color.Color {
+	if o.Background == nil {
+		return color.Black
+	}
+	return o.Background
+}
+
+func (o Options) track() color.Color {
+	if o.Track == nil {
+		return color.Gray{Y: 48}
+	}
+	return o.Track
+}
+
+func (o Options) scale(values []float64) Range {
+	if o.Range != nil {
+		return *o.Range
+	}
+	if len(values) == 0 {
+		return Range{0, 1}
+	}
+	r := Range{values[0], values[0]}
+	for _, v := range values {
+		r.Min = math.Min(r.Min, v)
+		r.Max = math.Max(r.Max, v)
+	}
+	return r
+}
+
+// frac returns position of v in r, clamped to 0~1.
+func (r Range) frac(v float64) float64 {
+	if r.Max == r.Min {
+		return 0.5
+	}
+	f := (v - r.Min) / (r.Max - r.Min)
+	return math.Max(0, math.Min(1, f))
+}
+
+func newFrame(size image.Point, o Options) *image.RGBA {
+	img := image.NewRGBA(image.Rectangle{Max: size})
+	draw.Draw(img, img.Rect, image.NewUniform(o.background()), image.Point{}, draw.Src)
+	return img
+}
+
+// Sparkline returns line chart of values with the newest on the right.
+// It shows last size.X values.
+func Sparkline(values []float64, size image.Point, o Options) image.Image {
+	img := newFrame(size, o)
+	DrawSparkline(img, img.Rect, values, o)
+	return img
+}
+
+// DrawSparkline draws sparkline of values in r of dst, over what's there.
+func DrawSparkline(dst draw.Image, r image.Rectangle, values []float64, o Options) {
+	if n := r.Dx(); len(values) > n {
+		values = values[len(values)-n:]
+	}
+	if len(values) == 0 {
+		return
+	}
+
+	sc := o.scale(values)
+	h := float64(r.Dy() - 1)
+	y := func(v float64) int {
+		return r.Max.Y - 1 - int(sc.frac(v)*h+0.5)
+	}
+
+	x0 := r.Max.X - len(values)
+	prev := y(values[0])
+	for i, v := range values {
+		cur := y(v)
+		// fill vertical gap to previous point to keep the line connected
+		from, to := prev, cur
+		if from > to {
+			from, to = to, from
+		}
+		c := o.color(v)
+		for yy := from; yy <= to; yy++ {
+			dst.Set(x0+i, yy, c)
+		}
+		prev = cur
+	}
+}
+
+// Bars returns bar chart of values, from left to right.
+func Bars(values []float64, size image.Point, o Options) image.Image {
+	img := newFrame(size, o)
+	DrawBars(img, img.Rect, values, o)
+	return img
+}
+
+// DrawBars draws bar chart of values in r of dst.
+// Bars are 1 pixel apart if they are wider than 2 pixels.
+func DrawBars(dst draw.Image, r image.Rectangle, values []float64, o Options) {
+	if len(values) == 0 {
+		return
+	}
+
+	sc := o.scale(values)
+	if o.Range == nil && sc.Min > 0 {
+		// bars of positive values grow from zero
+		sc.Min = 0
+	}
+	if sc.Min == sc.Max {
+		// all zeros have no bar, rather than bars of half height
+		sc.Max = sc.Min + 1
+	}
+
+	n := len(values)
+	for i, v := range values {
+		x0 := r.Min.X + r.Dx()*i/n
+		x1 := r.Min.X + r.Dx()*(i+1)/n
+		if x1-x0 > 2 {
+			x1--
+		}
+		top := r.Max.Y - int(sc.frac(v)*float64(r.Dy())+0.5)
+		draw.Draw(dst, image.Rect(x0, top, x1, r.Max.Y), image.NewUniform(o.color(v)), image.Point{}, draw.Src)
+	}
+}
+
+// Gauge returns radial gauge of v with the value in the center.
+func Gauge(v float64, size image.Point, o Options) image.Image {
+	img := newFrame(size, o)
+	DrawGauge(img, img.Rect, v, o)
+	return img
+}
+
+// DrawGauge draws radial gauge of v in r of dst. The arc sweeps 270 degrees
+// clockwise from bottom left.
+func DrawGauge(dst draw.Image, r image.Rectangle, v float64, o Options) {
+	sc := Range{0, 100}
+	if o.Range != nil {
+		sc = *o.Range
+	}
+	c := o.color(v)
+	track := o.track()
+
+	d := math.Min(float64(r.Dx()), float64(r.Dy()))
+	outer := d / 2
+	inner := outer - math.Max(2, d/8)
+	cx := float64(r.Min.X) + float64(r.Dx())/2
+	cy := float64(r.Min.Y) + float64(r.Dy())/2
+	fill := -135 + 270*sc.frac(v)
+
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			dx, dy := float64(x)+0.5-cx, float64(y)+0.5-cy
+			dist := math.Hypot(dx, dy)
+			if dist > outer || dist < inner {
+				continue
+			}
+			// degrees from top, clockwise
+			a := math.Atan2(dx, -dy) * 180 / math.Pi
+			if a < -135 || a > 135 {
+				continue
+			}
+			if a <= fill {
+				dst.Set(x, y, c)
+			} else {
+				dst.Set(x, y, track)
+			}
+		}
+	}
+
+	// value in the center, as big as fits in the inner circle
+	text := fmt.Sprintf("%.0f", v)
+	box := int(inner * 1.4)
+	tr := image.Rect(0, 0, box, box).Add(image.Pt(int(cx)-box/2, int(cy)-box/2))
+	drawBigText(dst, tr, text, c)
+}
+
+// BigNumber returns v formatted with format, like "%.1f",
+// in the biggest digits fitting to size.
+func BigNumber(v float64, format string, size image.Point, o Options) image.Image {
+	img := newFrame(size, o)
+	DrawBigNumber(img, img.Rect, v, format, o)
+	return img
+}
+
+// DrawBigNumber draws v formatted with format in the biggest digits fitting to r of dst.
+func DrawBigNumber(dst draw.Image, r image.Rectangle, v float64, format string, o Options) {
+	drawBigText(dst, r, fmt.Sprintf(format, v), o.color(v))
+}
+
+// drawBigText draws s centered in r in the biggest integer scale of the font.
+func drawBigText(dst draw.Image, r image.Rectangle, s string, c color.Color) {
+	m := pixfont.Measure(s, 1)
+	if m.X == 0 {
+		return
+	}
+	scale := r.Dx() / m.X
+	if s := r.Dy() / m.Y; s < scale {
+		scale = s
+	}
+	if scale < 1 {
+		scale = 1
+	}
+
+	m = pixfont.Measure(s, scale)
+	p := r.Min.Add(image.Pt((r.Dx()-m.X)/2, (r.Dy()-m.Y)/2))
+	pixfont.Draw(dst, p, s, c, scale)
+}
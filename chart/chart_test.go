@@ -0,0 +1,209 @@
+package chart
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+var (
+	black = color.RGBA{0, 0, 0, 0xff}
+	white = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	red   = color.RGBA{0xff, 0, 0, 0xff}
+)
+
+// lit returns pixels of img other than black background.
+func lit(img image.Image) map[image.Point]bool {
+	ps := make(map[image.Point]bool)
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if color.RGBAModel.Convert(img.At(x, y)) != black {
+				ps[image.Pt(x, y)] = true
+			}
+		}
+	}
+	return ps
+}
+
+// bounds returns bounding box of lit pixels of img.
+func bounds(img image.Image) image.Rectangle {
+	var r image.Rectangle
+	for p := range lit(img) {
+		r = r.Union(image.Rectangle{p, p.Add(image.Pt(1, 1))})
+	}
+	return r
+}
+
+func pts(ps ...image.Point) map[image.Point]bool {
+	m := make(map[image.Point]bool)
+	for _, p := range ps {
+		m[p] = true
+	}
+	return m
+}
+
+func samePoints(a, b map[image.Point]bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for p := range a {
+		if !b[p] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSparkline(t *testing.T) {
+	size := image.Pt(8, 9)
+	tcs := []struct {
+		name   string
+		values []float64
+		o      Options
+		want   map[image.Point]bool
+	}{
+		{"empty", nil, Options{}, pts()},
+		// a point or constant values are in the middle
+		{"single", []float64{5}, Options{}, pts(image.Pt(7, 4))},
+		{"constant", []float64{3, 3, 3}, Options{}, pts(image.Pt(5, 4), image.Pt(6, 4), image.Pt(7, 4))},
+		// gap to the previous point is filled
+		{"step", []float64{0, 1}, Options{}, pts(image.Pt(6, 8), image.Pt(7, 0), image.Pt(7, 1),
+			image.Pt(7, 2), image.Pt(7, 3), image.Pt(7, 4), image.Pt(7, 5), image.Pt(7, 6), image.Pt(7, 7), image.Pt(7, 8))},
+		// out of fixed range is clamped
+		{"range", []float64{-5, 50}, Options{Range: &Range{0, 8}}, pts(image.Pt(6, 8), image.Pt(7, 0),
+			image.Pt(7, 1), image.Pt(7, 2), image.Pt(7, 3), image.Pt(7, 4), image.Pt(7, 5), image.Pt(7, 6), image.Pt(7, 7), image.Pt(7, 8))},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			img := Sparkline(tc.values, size, tc.o)
+			if img.Bounds() != (image.Rectangle{Max: size}) {
+				t.Fatalf("image of %v", img.Bounds())
+			}
+			if got := lit(img); !samePoints(got, tc.want) {
+				t.Errorf("drawn %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSparklineLastValues(t *testing.T) {
+	// only the last 4 values fit; 100 would flatten them if it's scaled
+	values := []float64{100, 0, 1, 2, 3}
+	img := Sparkline(values, image.Pt(4, 4), Options{})
+	want := pts(image.Pt(0, 3), image.Pt(1, 3), image.Pt(1, 2), image.Pt(2, 2), image.Pt(2, 1), image.Pt(3, 1), image.Pt(3, 0))
+	if got := lit(img); !samePoints(got, want) {
+		t.Errorf("drawn %v, want %v", got, want)
+	}
+}
+
+func TestBars(t *testing.T) {
+	size := image.Pt(8, 8)
+	tcs := []struct {
+		name   string
+		values []float64
+		want   image.Rectangle // bounds of the bars
+	}{
+		{"empty", nil, image.Rectangle{}},
+		{"zeros", []float64{0, 0}, image.Rectangle{}},
+		// positive values grow from zero
+		{"single", []float64{5}, image.Rect(0, 0, 7, 8)},
+		{"constant", []float64{3, 3}, image.Rect(0, 0, 7, 8)},
+		{"half", []float64{1, 2}, image.Rect(0, 0, 7, 8)},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			img := Bars(tc.values, size, Options{})
+			if got := bounds(img); got != tc.want {
+				t.Errorf("bars in %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	// 1 pixel apart, half and full height
+	img := Bars([]float64{1, 2}, size, Options{})
+	want := make(map[image.Point]bool)
+	for x := 0; x < 3; x++ {
+		for y := 4; y < 8; y++ {
+			want[image.Pt(x, y)] = true
+		}
+	}
+	for x := 4; x < 7; x++ {
+		for y := 0; y < 8; y++ {
+			want[image.Pt(x, y)] = true
+		}
+	}
+	if got := lit(img); !samePoints(got, want) {
+		t.Errorf("drawn %v, want %v", got, want)
+	}
+}
+
+func TestGauge(t *testing.T) {
+	o := Options{Thresholds: []Threshold{{Value: 50, Color: red}, {Value: 10, Color: white}}}
+	tcs := []struct {
+		v       float64
+		o       Options
+		wantTop color.RGBA // top of the arc
+	}{
+		{0, o, color.RGBA{48, 48, 48, 0xff}},
+		{100, o, red},
+		{30, o, color.RGBA{48, 48, 48, 0xff}},
+		// degenerate range is at the middle
+		{7, Options{Range: &Range{7, 7}}, white},
+	}
+	for _, tc := range tcs {
+		img := Gauge(tc.v, image.Pt(32, 32), tc.o)
+		// left of the center, which is filled at the middle of range
+		if got := color.RGBAModel.Convert(img.At(15, 1)); got != tc.wantTop {
+			t.Errorf("top of gauge of %v = %v, want %v", tc.v, got, tc.wantTop)
+		}
+		// the gap at the bottom isn't drawn
+		if got := color.RGBAModel.Convert(img.At(16, 31)); got != black {
+			t.Errorf("bottom of gauge of %v = %v, want background", tc.v, got)
+		}
+	}
+
+	// tiny gauge doesn't panic
+	Gauge(50, image.Pt(1, 1), Options{})
+	Gauge(50, image.Point{}, Options{})
+}
+
+func TestBigNumber(t *testing.T) {
+	tcs := []struct {
+		v      float64
+		format string
+		size   image.Point
+		want   image.Rectangle
+	}{
+		// "8" of 3x5 in scale 3, centered
+		{8, "%.0f", image.Pt(16, 16), image.Rect(3, 0, 12, 15)},
+		// "42" of 7x5 in scale 2
+		{42, "%.0f", image.Pt(16, 16), image.Rect(1, 3, 15, 13)},
+		// too small for the text is in scale 1, clipped
+		{12345, "%.0f", image.Pt(8, 8), image.Rect(0, 1, 8, 6)},
+	}
+	for _, tc := range tcs {
+		img := BigNumber(tc.v, tc.format, tc.size, Options{})
+		if got := bounds(img); got != tc.want {
+			t.Errorf("%v in %v is drawn in %v, want %v", tc.v, tc.size, got, tc.want)
+		}
+	}
+}
+
+func TestOptionsColor(t *testing.T) {
+	o := Options{Thresholds: []Threshold{{Value: 80, Color: red}, {Value: 50, Color: black}}}
+	tcs := []struct {
+		v    float64
+		want color.Color
+	}{
+		{10, color.White},
+		{50, black},
+		{79, black},
+		{80, red},
+	}
+	for _, tc := range tcs {
+		if got := o.color(tc.v); got != tc.want {
+			t.Errorf("color of %v = %v, want %v", tc.v, got, tc.want)
+		}
+	}
+}
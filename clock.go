@@ -0,0 +1,71 @@
+package divoom
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// MinClockSyncThreshold is the smallest threshold for SyncClock.
+// Device time has resolution of a second, so smaller drift can't be measured.
+const MinClockSyncThreshold = 2 * time.Second
+
+// ClockDrift measures how far device time is ahead of host time.
+// Negative drift means the device is behind.
+func (c *Client) ClockDrift() (time.Duration, error) {
+	sent := time.Now()
+	dt, err := c.DeviceTime()
+	if err != nil {
+		return 0, errors.Wrap(err, "fail to measure clock drift")
+	}
+	rtt := time.Since(sent)
+
+	// compare with host time at the middle of the round trip
+	host := sent.Add(rtt / 2).Truncate(time.Second)
+	return dt.Sub(host), nil
+}
+
+// SyncClock measures clock drift and sets system time of the device to host time
+// if the drift is beyond threshold. It returns the drift measured before correction.
+func (c *Client) SyncClock(threshold time.Duration) (drift time.Duration, corrected bool, err error) {
+	if threshold < MinClockSyncThreshold {
+		threshold = MinClockSyncThreshold
+	}
+
+	drift, err = c.ClockDrift()
+	if err != nil {
+		return 0, false, errors.Wrap(err, "fail to sync clock")
+	}
+
+	if drift < threshold && drift > -threshold {
+		return drift, false, nil
+	}
+
+	err = c.SetSystemTime(time.Now())
+	if err != nil {
+		return drift, false, errors.Wrap(err, "fail to sync clock")
+	}
+
+	return drift, true, nil
+}
+
+// RunClockSync calls SyncClock every interval until ctx is done.
+// Result of every sync is reported to onSync, if not nil.
+func (c *Client) RunClockSync(ctx context.Context, interval, threshold time.Duration, onSync func(drift time.Duration, corrected bool, err error)) error {
+	tk := time.NewTicker(interval)
+	defer tk.Stop()
+
+	for {
+		drift, corrected, err := c.SyncClock(threshold)
+		if onSync != nil {
+			onSync(drift, corrected, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tk.C:
+		}
+	}
+}
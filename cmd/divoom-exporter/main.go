@@ -0,0 +1,71 @@
+// divoom-exporter exports health of the devices in the registry to Prometheus.
+//
+// Usage:
+//
+//	divoom-exporter [-addr :9110] [-interval 30s] [-registry path]
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	divoom "github.com/suapapa/go_divoom"
+	"github.com/suapapa/go_divoom/promexporter"
+)
+
+var (
+	flagAddr     string
+	flagInterval time.Duration
+	flagRegistry string
+)
+
+func main() {
+	flag.StringVar(&flagAddr, "addr", ":9110", "address to serve /metrics")
+	flag.DurationVar(&flagInterval, "interval", 30*time.Second, "poll interval")
+	flag.StringVar(&flagRegistry, "registry", "", "device registry (default ~/.config/divoom/devices.yaml)")
+	flag.Parse()
+
+	if flagRegistry == "" {
+		var err error
+		flagRegistry, err = divoom.DefaultRegistryPath()
+		chk(err)
+	}
+	reg, err := divoom.LoadRegistry(flagRegistry)
+	chk(err)
+	if len(reg.Names()) == 0 {
+		_, _, err = reg.Refresh()
+		chk(err)
+		chk(reg.Save())
+	}
+
+	pr := prometheus.NewRegistry()
+	pr.MustRegister(collectors.NewGoCollector())
+	m, err := promexporter.NewMetrics(pr)
+	chk(err)
+	e, err := promexporter.NewExporter(pr)
+	chk(err)
+
+	for _, n := range reg.Names() {
+		rd, err := reg.Get(n)
+		chk(err)
+		e.AddClient(divoom.NewClient(rd.Device(n), divoom.WithMetrics(m)))
+	}
+
+	go e.Run(context.Background(), flagInterval)
+
+	http.Handle("/metrics", promhttp.HandlerFor(pr, promhttp.HandlerOpts{}))
+	log.Printf("serving metrics of %v on %s\n", reg.Names(), flagAddr)
+	log.Fatal(http.ListenAndServe(flagAddr, nil))
+}
+
+func chk(err error) {
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,102 @@
+// divoom-mqtt bridges the devices in the registry to MQTT broker, with Home Assistant discovery.
+//
+// Try it with a local broker:
+//
+//	mosquitto -p 1883 &
+//	divoom-mqtt -broker tcp://localhost:1883 &
+//	mosquitto_sub -t 'divoom/#' -v
+//	mosquitto_pub -t divoom/office/brightness/set -m 30
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	divoom "github.com/suapapa/go_divoom"
+	"github.com/suapapa/go_divoom/mqttbridge"
+)
+
+var (
+	flagBroker    string
+	flagUser      string
+	flagPass      string
+	flagPrefix    string
+	flagDiscovery string
+	flagPoll      time.Duration
+	flagRegistry  string
+)
+
+func main() {
+	flag.StringVar(&flagBroker, "broker", "tcp://localhost:1883", "mqtt broker")
+	flag.StringVar(&flagUser, "user", "", "mqtt user")
+	flag.StringVar(&flagPass, "pass", os.Getenv("MQTT_PASSWORD"), "mqtt password")
+	flag.StringVar(&flagPrefix, "prefix", "divoom", "topic prefix")
+	flag.StringVar(&flagDiscovery, "discovery", "homeassistant", "home assistant discovery prefix")
+	flag.DurationVar(&flagPoll, "poll", 30*time.Second, "state poll interval")
+	flag.StringVar(&flagRegistry, "registry", "", "device registry (default ~/.config/divoom/devices.yaml)")
+	flag.Parse()
+
+	if flagRegistry == "" {
+		var err error
+		flagRegistry, err = divoom.DefaultRegistryPath()
+		chk(err)
+	}
+	reg, err := divoom.LoadRegistry(flagRegistry)
+	chk(err)
+	if len(reg.Names()) == 0 {
+		_, _, err = reg.Refresh()
+		chk(err)
+		chk(reg.Save())
+	}
+
+	var b *mqttbridge.Bridge
+	opts := mqtt.NewClientOptions().
+		AddBroker(flagBroker).
+		SetClientID("divoom-mqtt").
+		SetUsername(flagUser).
+		SetPassword(flagPass).
+		SetAutoReconnect(true).
+		SetWill(mqttbridge.AvailabilityTopic(flagPrefix), "offline", 1, true).
+		SetOnConnectHandler(func(mc mqtt.Client) {
+			b.OnConnect(mc)
+		})
+	mc := mqtt.NewClient(opts)
+
+	b = mqttbridge.New(mc, mqttbridge.Config{
+		Prefix:          flagPrefix,
+		DiscoveryPrefix: flagDiscovery,
+		PollInterval:    flagPoll,
+		OnError: func(err error) {
+			log.Println(err)
+		},
+	})
+	for _, n := range reg.Names() {
+		rd, err := reg.Get(n)
+		chk(err)
+		b.AddDevice(n, rd)
+		log.Printf("bridging %s (%s)\n", n, rd.IP)
+	}
+
+	t := mc.Connect()
+	t.Wait()
+	chk(t.Error())
+	defer mc.Disconnect(1000)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	err = b.Run(ctx)
+	if err != context.Canceled {
+		chk(err)
+	}
+}
+
+func chk(err error) {
+	if err != nil {
+		log.Fatal(err)
+	}
+}
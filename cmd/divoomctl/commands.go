@@ -0,0 +1,559 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "image/jpeg"
+	_ "image/png"
+
+	"github.com/pkg/errors"
+	divoom "github.com/suapapa/go_divoom"
+)
+
+type command struct {
+	usage    string
+	help     string
+	noDevice bool
+	run      func(c *divoom.Client, args []string) (interface{}, error)
+}
+
+var commands = map[string]*command{
+	"discover": {
+		help:     "list devices in the LAN",
+		noDevice: true,
+		run:      runDiscover,
+	},
+	"brightness": {
+		usage: "0~100",
+		help:  "set brightness",
+		run:   runBrightness,
+	},
+	"screen": {
+		usage: "on|off",
+		help:  "turn screen on or off",
+		run:   runScreen,
+	},
+	"channel": {
+		usage: "[faces|cloud|visualizer|custom [0~2]]",
+		help:  "get or select channel",
+		run:   runChannel,
+	},
+	"face": {
+		usage: "[CLOCK_ID]",
+		help:  "get or select face of faces channel",
+		run:   runFace,
+	},
+	"visualizer": {
+		usage: "POSITION",
+		help:  "select visualizer",
+		run:   runVisualizer,
+	},
+	"cloud": {
+		usage: "recommend|favorite|artist",
+		help:  "select cloud channel",
+		run:   runCloud,
+	},
+	"gif": {
+		usage: "FILE|URL",
+		help:  "send gif, APNG or WebP animation",
+		run:   runGif,
+	},
+	"image": {
+		usage: "[-speed 1000] FILE...",
+		help:  "send image, or images as animation frames, cropped to square",
+		run:   runImage,
+	},
+	"text": {
+		usage: "[-id 1] [-x 0] [-y 0] [-font 0] [-color #FFFFFF] [-speed 10] [-align left|middle|right] [-dir left|right] [-width 64] TEXT",
+		help:  "draw text over the animation",
+		run:   runText,
+	},
+	"qr": {
+		usage: "[-w 64] TEXT | [-w 64] -wifi SSID [-pass PASSWORD] [-auth WPA|WEP|nopass]",
+		help:  "show QR code of text or Wi-Fi network",
+		run:   runQR,
+	},
+	"countdown": {
+		usage: "DURATION [start|stop]",
+		help:  "set countdown tool",
+		run:   runCountdown,
+	},
+	"stopwatch": {
+		usage: "start|stop|reset",
+		help:  "control stopwatch tool",
+		run:   runStopwatch,
+	},
+	"scoreboard": {
+		usage: "RED BLUE",
+		help:  "set scoreboard tool",
+		run:   runScoreboard,
+	},
+	"noise": {
+		usage: "on|off",
+		help:  "turn noise meter tool on or off",
+		run:   runNoise,
+	},
+	"settings": {
+		usage: "get | set KEY VALUE",
+		help:  "get all settings or set one; run 'settings set' for keys",
+		run:   runSettings,
+	},
+}
+
+func runDiscover(_ *divoom.Client, args []string) (interface{}, error) {
+	ds, err := divoom.FindDevice()
+	if err != nil {
+		return nil, &noDeviceError{msg: err.Error()}
+	}
+	if flagJSON {
+		return ds, nil
+	}
+
+	var sb strings.Builder
+	for _, d := range ds {
+		fmt.Fprintf(&sb, "%s\t%d\t%s\n", d.DeviceName, d.DeviceID, d.DevicePrivateIP)
+	}
+	return strings.TrimSuffix(sb.String(), "\n"), nil
+}
+
+func runBrightness(c *divoom.Client, args []string) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, usagef("want brightness")
+	}
+	v, err := strconv.Atoi(args[0])
+	if err != nil || v < 0 || v > 100 {
+		return nil, usagef("invalid brightness %q", args[0])
+	}
+	return nil, c.SetBrightness(v)
+}
+
+func runScreen(c *divoom.Client, args []string) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, usagef("want on or off")
+	}
+	on, err := parseOnOff(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return nil, c.ScreenSwitch(on)
+}
+
+func runChannel(c *divoom.Client, args []string) (interface{}, error) {
+	if len(args) == 0 {
+		ch, err := c.GetCurrentChannel()
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"channel": channelName(ch), "index": int(ch)}, nil
+	}
+
+	switch args[0] {
+	case "faces":
+		return nil, c.SelectChannel(divoom.ChannelFaces)
+	case "cloud":
+		return nil, c.SelectChannel(divoom.ChannelCloud)
+	case "visualizer":
+		return nil, c.SelectChannel(divoom.ChannelVisualizer)
+	case "custom":
+		err := c.SelectChannel(divoom.ChannelCustom)
+		if err != nil || len(args) < 2 {
+			return nil, err
+		}
+		idx, err := strconv.Atoi(args[1])
+		if err != nil || idx < 0 || idx > 2 {
+			return nil, usagef("invalid custom page %q", args[1])
+		}
+		return nil, c.CustomChannel(divoom.CustomIdx(idx))
+	}
+	return nil, usagef("unknown channel %q", args[0])
+}
+
+func channelName(ch divoom.Channel) string {
+	switch ch {
+	case divoom.ChannelFaces:
+		return "faces"
+	case divoom.ChannelCloud:
+		return "cloud"
+	case divoom.ChannelVisualizer:
+		return "visualizer"
+	case divoom.ChannelCustom:
+		return "custom"
+	}
+	return "unknown"
+}
+
+func runFace(c *divoom.Client, args []string) (interface{}, error) {
+	if len(args) == 0 {
+		return c.GetSelectFaceID()
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return nil, usagef("invalid clock id %q", args[0])
+	}
+	return nil, c.SelectFacesChannel(id)
+}
+
+func runVisualizer(c *divoom.Client, args []string) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, usagef("want position")
+	}
+	pos, err := strconv.Atoi(args[0])
+	if err != nil || pos < 0 {
+		return nil, usagef("invalid position %q", args[0])
+	}
+	return nil, c.VisualizerChannel(pos)
+}
+
+func runCloud(c *divoom.Client, args []string) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, usagef("want cloud channel")
+	}
+	switch args[0] {
+	case "recommend":
+		return nil, c.CloudChannel(divoom.CloudChannelRecommendGallery)
+	case "favorite":
+		return nil, c.CloudChannel(divoom.CloudChannelFavorite)
+	case "artist":
+		return nil, c.CloudChannel(divoom.CloudChannelSubscribeArtist)
+	}
+	return nil, usagef("unknown cloud channel %q", args[0])
+}
+
+func runGif(c *divoom.Client, args []string) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, usagef("want animation file or url")
+	}
+
+	b, err := readFileOrURL(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.ResetSendingAnimationPicID()
+	if err != nil {
+		return nil, err
+	}
+	return nil, c.SendImage(1, bytes.NewReader(b), 1000)
+}
+
+func runImage(c *divoom.Client, args []string) (interface{}, error) {
+	fs := flag.NewFlagSet("image", flag.ContinueOnError)
+	speed := fs.Int("speed", 1000, "frame duration in msec")
+	if err := fs.Parse(args); err != nil {
+		return nil, usagef("%v", err)
+	}
+	if fs.NArg() < 1 {
+		return nil, usagef("want image files")
+	}
+
+	if fs.NArg() == 1 {
+		b, err := readFileOrURL(fs.Arg(0))
+		if err != nil {
+			return nil, err
+		}
+		err = c.ResetSendingAnimationPicID()
+		if err != nil {
+			return nil, err
+		}
+		return nil, c.SendImage(1, bytes.NewReader(b), *speed)
+	}
+
+	var anim divoom.Animation
+	for _, p := range fs.Args() {
+		b, err := readFileOrURL(p)
+		if err != nil {
+			return nil, err
+		}
+		img, _, err := image.Decode(bytes.NewReader(b))
+		if err != nil {
+			return nil, errors.Wrapf(err, "fail to decode %s", p)
+		}
+		anim.Imgs = append(anim.Imgs, img)
+		anim.SpeedMSecs = append(anim.SpeedMSecs, *speed)
+	}
+
+	err := c.ResetSendingAnimationPicID()
+	if err != nil {
+		return nil, err
+	}
+	return nil, c.SendAnimationFit(1, anim)
+}
+
+func runText(c *divoom.Client, args []string) (interface{}, error) {
+	fs := flag.NewFlagSet("text", flag.ContinueOnError)
+	id := fs.Int("id", 1, "text id, 0~19")
+	x := fs.Int("x", 0, "x position")
+	y := fs.Int("y", 0, "y position")
+	font := fs.Int("font", 0, "font, 0~7")
+	color := fs.String("color", "#FFFFFF", "text color")
+	speed := fs.Int("speed", 10, "scroll speed in msec per step")
+	align := fs.String("align", "left", "left, middle or right")
+	dir := fs.String("dir", "left", "scroll direction, left or right")
+	width := fs.Int("width", 64, "text area width, 16~64")
+	if err := fs.Parse(args); err != nil {
+		return nil, usagef("%v", err)
+	}
+	if fs.NArg() < 1 {
+		return nil, usagef("want text")
+	}
+	if *font < 0 || *font > 7 {
+		return nil, usagef("invalid font %d", *font)
+	}
+
+	var ta divoom.TextAlign
+	switch *align {
+	case "left":
+		ta = divoom.TextAlignLeft
+	case "middle":
+		ta = divoom.TextAlignMiddle
+	case "right":
+		ta = divoom.TextAlighRight
+	default:
+		return nil, usagef("invalid align %q", *align)
+	}
+
+	var td divoom.TextDir
+	switch *dir {
+	case "left":
+		td = divoom.TextDirLeft
+	case "right":
+		td = divoom.TextDirRight
+	default:
+		return nil, usagef("invalid dir %q", *dir)
+	}
+
+	str := strings.Join(fs.Args(), " ")
+	return nil, c.SendText(*id, *x, *y, td, divoom.TextFont(*font), *width, str, *speed, *color, ta)
+}
+
+func runQR(c *divoom.Client, args []string) (interface{}, error) {
+	fs := flag.NewFlagSet("qr", flag.ContinueOnError)
+	width := fs.Int("w", 64, "panel width; 16, 32 or 64")
+	ssid := fs.String("wifi", "", "ssid of Wi-Fi network")
+	pass := fs.String("pass", "", "password of Wi-Fi network")
+	auth := fs.String("auth", "WPA", "auth of Wi-Fi network; WPA, WEP or nopass")
+	if err := fs.Parse(args); err != nil {
+		return nil, usagef("%v", err)
+	}
+
+	var text string
+	switch {
+	case *ssid != "":
+		text = divoom.WiFiQRText(*ssid, *pass, *auth)
+	case fs.NArg() > 0:
+		text = strings.Join(fs.Args(), " ")
+	default:
+		return nil, usagef("want text or -wifi")
+	}
+	return nil, c.SendQR(text, *width)
+}
+
+func runCountdown(c *divoom.Client, args []string) (interface{}, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return nil, usagef("want duration")
+	}
+	dur, err := time.ParseDuration(args[0])
+	if err != nil {
+		return nil, usagef("invalid duration %q", args[0])
+	}
+	start := true
+	if len(args) == 2 {
+		switch args[1] {
+		case "start":
+		case "stop":
+			start = false
+		default:
+			return nil, usagef("want start or stop")
+		}
+	}
+	return nil, c.SetCountdownTool(dur, start)
+}
+
+func runStopwatch(c *divoom.Client, args []string) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, usagef("want start, stop or reset")
+	}
+	switch args[0] {
+	case "start":
+		return nil, c.SetStopwatchTool(divoom.StopwatchStatusStart)
+	case "stop":
+		return nil, c.SetStopwatchTool(divoom.StopwatchStatusStop)
+	case "reset":
+		return nil, c.SetStopwatchTool(divoom.StopwatchStatusReset)
+	}
+	return nil, usagef("want start, stop or reset")
+}
+
+func runScoreboard(c *divoom.Client, args []string) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, usagef("want red and blue scores")
+	}
+	red, err1 := strconv.Atoi(args[0])
+	blue, err2 := strconv.Atoi(args[1])
+	if err1 != nil || err2 != nil {
+		return nil, usagef("invalid scores")
+	}
+	return nil, c.SetScoreboardTool(red, blue)
+}
+
+func runNoise(c *divoom.Client, args []string) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, usagef("want on or off")
+	}
+	on, err := parseOnOff(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return nil, c.SetNoiseTool(on)
+}
+
+const settingKeys = `keys:
+  brightness 0~100
+  timezone GMT+9
+  temp-mode c|f
+  rotation 0|90|180|270
+  mirror on|off
+  hour-mode 12|24
+  highlight on|off
+  white-balance R,G,B
+  weather-area LONG,LAT
+  time now|RFC3339`
+
+func runSettings(c *divoom.Client, args []string) (interface{}, error) {
+	if len(args) == 1 && args[0] == "get" {
+		return c.GetAllSetting()
+	}
+	if len(args) != 3 || args[0] != "set" {
+		return nil, usagef("want get or set KEY VALUE\n%s", settingKeys)
+	}
+
+	key, val := args[1], args[2]
+	switch key {
+	case "brightness":
+		v, err := strconv.Atoi(val)
+		if err != nil {
+			return nil, usagef("invalid brightness %q", val)
+		}
+		return nil, c.SetBrightness(v)
+	case "timezone":
+		return nil, c.SetTimeZone(val)
+	case "temp-mode":
+		switch val {
+		case "c":
+			return nil, c.SetTemperatureMode(divoom.TempModeCelsius)
+		case "f":
+			return nil, c.SetTemperatureMode(divoom.TempModeFahrenheit)
+		}
+	case "rotation":
+		switch val {
+		case "0":
+			return nil, c.SetRotationAngle(divoom.RotationAngle0)
+		case "90":
+			return nil, c.SetRotationAngle(divoom.RotationAngle90)
+		case "180":
+			return nil, c.SetRotationAngle(divoom.RotationAngle180)
+		case "270":
+			return nil, c.SetRotationAngle(divoom.RotationAngle270)
+		}
+	case "mirror":
+		on, err := parseOnOff(val)
+		if err != nil {
+			return nil, err
+		}
+		if on {
+			return nil, c.SetMirrorMode(divoom.MirrorModeEnable)
+		}
+		return nil, c.SetMirrorMode(divoom.MirrorModeDisable)
+	case "hour-mode":
+		switch val {
+		case "12":
+			return nil, c.SetHourMode(divoom.HourMode12)
+		case "24":
+			return nil, c.SetHourMode(divoom.HourMode24)
+		}
+	case "highlight":
+		on, err := parseOnOff(val)
+		if err != nil {
+			return nil, err
+		}
+		return nil, c.SetHighLightMode(on)
+	case "white-balance":
+		var r, g, b int
+		_, err := fmt.Sscanf(val, "%d,%d,%d", &r, &g, &b)
+		if err != nil {
+			return nil, usagef("invalid white balance %q", val)
+		}
+		return nil, c.SetWhiteBalance(r, g, b)
+	case "weather-area":
+		ll := strings.Split(val, ",")
+		if len(ll) != 2 {
+			return nil, usagef("invalid weather area %q", val)
+		}
+		return nil, c.WeatherAreaSetting(ll[0], ll[1])
+	case "time":
+		t := time.Now()
+		if val != "now" {
+			var err error
+			t, err = time.Parse(time.RFC3339, val)
+			if err != nil {
+				return nil, usagef("invalid time %q", val)
+			}
+		}
+		return nil, c.SetSystemTime(t)
+	default:
+		return nil, usagef("unknown key %q\n%s", key, settingKeys)
+	}
+
+	return nil, usagef("invalid %s %q", key, val)
+}
+
+func parseOnOff(s string) (bool, error) {
+	switch s {
+	case "on":
+		return true, nil
+	case "off":
+		return false, nil
+	}
+	return false, usagef("want on or off, not %q", s)
+}
+
+func openFileOrURL(p string) (io.ReadCloser, error) {
+	if !strings.HasPrefix(p, "http://") && !strings.HasPrefix(p, "https://") {
+		return os.Open(p)
+	}
+
+	resp, err := http.Get(p)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("fail to get %s: %s", p, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// readFileOrURL reads all of file or url of p.
+func readFileOrURL(p string) ([]byte, error) {
+	r, err := openFileOrURL(p)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fail to read %s", p)
+	}
+	return b, nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	divoom "github.com/suapapa/go_divoom"
+)
+
+// fakeDevice replies error_code 0 to every command and keeps them.
+type fakeDevice struct {
+	cmds []map[string]interface{}
+}
+
+func (d *fakeDevice) RoundTrip(req *http.Request) (*http.Response, error) {
+	cmd := make(map[string]interface{})
+	if err := json.NewDecoder(req.Body).Decode(&cmd); err != nil {
+		return nil, err
+	}
+	d.cmds = append(d.cmds, cmd)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`{"error_code": 0}`)),
+		Request:    req,
+	}, nil
+}
+
+// frames returns frames sent to the device.
+func (d *fakeDevice) frames() []map[string]interface{} {
+	var fs []map[string]interface{}
+	for _, cmd := range d.cmds {
+		if cmd["Command"] == "Draw/SendHttpGif" {
+			fs = append(fs, cmd)
+		}
+	}
+	return fs
+}
+
+// writePNG writes w×h png, red on the left half and blue on the right.
+func writePNG(t *testing.T, w, h int) string {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, image.Rect(0, 0, w/2, h), image.NewUniform(color.RGBA{0xff, 0, 0, 0xff}), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(w/2, 0, w, h), image.NewUniform(color.RGBA{0, 0, 0xff, 0xff}), image.Point{}, draw.Src)
+
+	p := filepath.Join(t.TempDir(), "img.png")
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestRunImage(t *testing.T) {
+	dev := &fakeDevice{}
+	c := divoom.NewClient(&divoom.Device{DevicePrivateIP: "pixoo"}, divoom.WithHTTPClient(&http.Client{Transport: dev}))
+
+	// 64x32 is cropped to the center 32x32, not stretched
+	p := writePNG(t, 64, 32)
+	if _, err := runImage(c, []string{"-speed", "500", p}); err != nil {
+		t.Fatal(err)
+	}
+	fs := dev.frames()
+	if len(fs) != 1 || fs[0]["PicWidth"] != float64(32) || fs[0]["PicSpeed"] != float64(500) {
+		t.Fatalf("sent frames %v", fs)
+	}
+	pix, err := base64.StdEncoding.DecodeString(fs[0]["PicData"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if left, right := pix[0:3], pix[31*3:32*3]; string(left) != "\xff\x00\x00" || string(right) != "\x00\x00\xff" {
+		t.Errorf("first row from %v to %v, want red to blue", left, right)
+	}
+
+	dev.cmds = nil
+	if _, err := runImage(c, []string{p, p}); err != nil {
+		t.Fatal(err)
+	}
+	if fs := dev.frames(); len(fs) != 2 || fs[0]["PicNum"] != float64(2) || fs[1]["PicWidth"] != float64(32) {
+		t.Errorf("sent frames %v", fs)
+	}
+}
@@ -0,0 +1,226 @@
+// divoomctl controls Divoom devices, such as Pixoo64, in the LAN.
+//
+// Usage:
+//
+//	divoomctl [-d name|ip] [-json] command [args]
+//
+// Run divoomctl without command to list the commands.
+//
+// Exit codes are 0 on success, 1 when the device fails the command,
+// 2 on invalid usage and 3 when the device can't be found.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"sort"
+	"strings"
+
+	divoom "github.com/suapapa/go_divoom"
+)
+
+const (
+	exitOK = iota
+	exitFail
+	exitUsage
+	exitNoDevice
+)
+
+var (
+	flagDevice string
+	flagJSON   bool
+	flagV      bool
+)
+
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+func usagef(format string, a ...interface{}) error {
+	return &usageError{msg: fmt.Sprintf(format, a...)}
+}
+
+type noDeviceError struct {
+	msg string
+}
+
+func (e *noDeviceError) Error() string {
+	return e.msg
+}
+
+func main() {
+	flag.StringVar(&flagDevice, "d", "", "device name in registry or ip (default: the only device)")
+	flag.BoolVar(&flagJSON, "json", false, "print result as JSON")
+	flag.BoolVar(&flagV, "v", false, "log requests to the device")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		usage()
+		os.Exit(exitUsage)
+	}
+
+	os.Exit(run(flag.Arg(0), flag.Args()[1:]))
+}
+
+func run(name string, args []string) int {
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command: %s\n", name)
+		usage()
+		return exitUsage
+	}
+
+	var c *divoom.Client
+	if !cmd.noDevice {
+		d, err := selectDevice(flagDevice)
+		if err != nil {
+			return report(err)
+		}
+		var opts []divoom.ClientOption
+		if flagV {
+			l := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+			opts = append(opts, divoom.WithLogger(l))
+		}
+		c = divoom.NewClient(d, opts...)
+	}
+
+	ret, err := cmd.run(c, translateEnums(name, args))
+	if err != nil {
+		if _, ok := err.(*usageError); ok {
+			fmt.Fprintf(os.Stderr, "usage: divoomctl %s %s\n", name, cmd.usage)
+		}
+		return report(err)
+	}
+
+	printResult(ret)
+	return exitOK
+}
+
+func report(err error) int {
+	code := exitFail
+	switch err.(type) {
+	case *usageError:
+		code = exitUsage
+	case *noDeviceError:
+		code = exitNoDevice
+	}
+
+	if flagJSON {
+		json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+			"ok":    false,
+			"error": err.Error(),
+		})
+	} else {
+		fmt.Fprintln(os.Stderr, "error:", err)
+	}
+	return code
+}
+
+func printResult(ret interface{}) {
+	if flagJSON {
+		if ret == nil {
+			ret = map[string]interface{}{"ok": true}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(ret)
+		return
+	}
+
+	switch v := ret.(type) {
+	case nil:
+	case fmt.Stringer:
+		fmt.Println(v)
+	case string:
+		fmt.Println(v)
+	default:
+		b, _ := json.MarshalIndent(v, "", "  ")
+		fmt.Println(string(b))
+	}
+}
+
+// selectDevice finds device by ip or name in the registry. If sel is ip,
+// device isn't looked up. Devices in the LAN are added to the registry,
+// and ip of registered device is refreshed if it doesn't answer.
+func selectDevice(sel string) (*divoom.Device, error) {
+	if net.ParseIP(sel) != nil {
+		return &divoom.Device{DevicePrivateIP: sel}, nil
+	}
+
+	path, err := divoom.DefaultRegistryPath()
+	if err != nil {
+		return nil, err
+	}
+	r, err := divoom.LoadRegistry(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if sel != "" {
+		if _, err := r.Get(sel); err == nil {
+			// refresh the ip if the device is moved
+			rd, refreshed, err := r.Resolve(sel)
+			if refreshed {
+				if err := r.Save(); err != nil {
+					fmt.Fprintln(os.Stderr, "warning:", err)
+				}
+			}
+			if err != nil {
+				return nil, &noDeviceError{msg: err.Error()}
+			}
+			return rd.Device(sel), nil
+		}
+	}
+
+	if _, _, err := r.Refresh(); err != nil {
+		return nil, &noDeviceError{msg: err.Error()}
+	}
+	if err := r.Save(); err != nil {
+		fmt.Fprintln(os.Stderr, "warning:", err)
+	}
+
+	names := r.Names()
+	if sel == "" {
+		switch len(names) {
+		case 0:
+			return nil, &noDeviceError{msg: "no divoom device is found"}
+		case 1:
+			rd, _ := r.Get(names[0])
+			return rd.Device(names[0]), nil
+		default:
+			return nil, &noDeviceError{msg: fmt.Sprintf("%d devices are registered; select one with -d", len(names))}
+		}
+	}
+
+	for _, n := range names {
+		if strings.EqualFold(n, sel) {
+			rd, _ := r.Get(n)
+			return rd.Device(n), nil
+		}
+	}
+	return nil, &noDeviceError{msg: fmt.Sprintf("device %q is not found", sel)}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: divoomctl [flags] command [args]\n\nflags:\n")
+	flag.PrintDefaults()
+	fmt.Fprintf(os.Stderr, "\ncommands:\n")
+
+	names := make([]string, 0, len(commands))
+	for n := range commands {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		fmt.Fprintf(os.Stderr, "  %-11s %s\n", n, commands[n].help)
+	}
+}
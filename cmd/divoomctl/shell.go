@@ -0,0 +1,308 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/peterh/liner"
+	divoom "github.com/suapapa/go_divoom"
+)
+
+func init() {
+	commands["shell"] = &command{
+		help: "interactive shell connected to the device",
+		run:  runShell,
+	}
+}
+
+// enumNames maps names of the enum values in divoom package to the words of commands,
+// so both can be used as args.
+var enumNames = map[string]string{
+	"ChannelFaces":                 "faces",
+	"ChannelCloud":                 "cloud",
+	"ChannelVisualizer":            "visualizer",
+	"ChannelCustom":                "custom",
+	"CustomIdx0":                   "0",
+	"CustomIdx1":                   "1",
+	"CustomIdx2":                   "2",
+	"CloudChannelRecommendGallery": "recommend",
+	"CloudChannelFavorite":         "favorite",
+	"CloudChannelSubscribeArtist":  "artist",
+	"StopwatchStatusStart":         "start",
+	"StopwatchStatusStop":          "stop",
+	"StopwatchStatusReset":         "reset",
+	"TempModeCelsius":              "c",
+	"TempModeFahrenheit":           "f",
+	"RotationAngle0":               "0",
+	"RotationAngle90":              "90",
+	"RotationAngle180":             "180",
+	"RotationAngle270":             "270",
+	"MirrorModeEnable":             "on",
+	"MirrorModeDisable":            "off",
+	"HourMode12":                   "12",
+	"HourMode24":                   "24",
+	"TextAlignLeft":                "left",
+	"TextAlignMiddle":              "middle",
+	"TextAlighRight":               "right",
+	"TextDirLeft":                  "left",
+	"TextDirRight":                 "right",
+	"TextFont0":                    "0",
+	"TextFont1":                    "1",
+	"TextFont2":                    "2",
+	"TextFont3":                    "3",
+	"TextFont4":                    "4",
+	"TextFont5":                    "5",
+	"TextFont6":                    "6",
+	"TextFont7":                    "7",
+}
+
+// translateEnums translates enum names in args of command name to the words.
+// Only the args taking the enum values are translated, so free text,
+// like TEXT of text command, is kept as is.
+func translateEnums(name string, args []string) []string {
+	ret := append([]string(nil), args...)
+	tr := func(i int, cands []string) {
+		if i >= len(ret) || !hasString(cands, ret[i]) {
+			return
+		}
+		if v, ok := enumNames[ret[i]]; ok {
+			ret[i] = v
+		}
+	}
+
+	switch name {
+	case "settings":
+		if len(ret) > 2 && ret[0] == "set" {
+			tr(2, settingCandidates[ret[1]])
+		}
+	case "text":
+		// flags come before TEXT and all of them take a value
+		for i := 0; i < len(ret) && strings.HasPrefix(ret[i], "-") && ret[i] != "--"; i++ {
+			f, v, hasValue := strings.Cut(ret[i], "=")
+			f = "-" + strings.TrimLeft(f, "-") // flag takes -font and --font
+			if !hasValue {
+				tr(i+1, flagCandidates[f])
+				i++
+				continue
+			}
+			if hasString(flagCandidates[f], v) {
+				if e, ok := enumNames[v]; ok {
+					ret[i] = f + "=" + e
+				}
+			}
+		}
+	case "channel":
+		tr(0, argCandidates[name])
+		if len(ret) > 1 && ret[0] == "custom" {
+			tr(1, customIdxCandidates)
+		}
+	default:
+		tr(0, argCandidates[name])
+	}
+	return ret
+}
+
+func hasString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// argCandidates is the completion candidates of the first arg of the commands.
+var argCandidates = map[string][]string{
+	"screen":     {"on", "off"},
+	"noise":      {"on", "off"},
+	"channel":    {"faces", "cloud", "visualizer", "custom", "ChannelFaces", "ChannelCloud", "ChannelVisualizer", "ChannelCustom"},
+	"cloud":      {"recommend", "favorite", "artist", "CloudChannelRecommendGallery", "CloudChannelFavorite", "CloudChannelSubscribeArtist"},
+	"stopwatch":  {"start", "stop", "reset", "StopwatchStatusStart", "StopwatchStatusStop", "StopwatchStatusReset"},
+	"text":       {"-id", "-x", "-y", "-font", "-color", "-speed", "-align", "-dir", "-width"},
+	"image":      {"-speed"},
+	"settings":   {"get", "set"},
+	"countdown":  {"1m", "5m", "10m"},
+	"brightness": {"0", "25", "50", "75", "100"},
+}
+
+// customIdxCandidates is the completion candidates of index of 'channel custom'.
+var customIdxCandidates = []string{"0", "1", "2", "CustomIdx0", "CustomIdx1", "CustomIdx2"}
+
+// settingCandidates is the completion candidates of values of 'settings set KEY'.
+var settingCandidates = map[string][]string{
+	"brightness":    {"0", "25", "50", "75", "100"},
+	"temp-mode":     {"c", "f", "TempModeCelsius", "TempModeFahrenheit"},
+	"rotation":      {"0", "90", "180", "270", "RotationAngle0", "RotationAngle90", "RotationAngle180", "RotationAngle270"},
+	"mirror":        {"on", "off", "MirrorModeEnable", "MirrorModeDisable"},
+	"hour-mode":     {"12", "24", "HourMode12", "HourMode24"},
+	"highlight":     {"on", "off"},
+	"time":          {"now"},
+	"timezone":      {},
+	"white-balance": {},
+	"weather-area":  {},
+}
+
+// flagCandidates is the completion candidates of values of flags of the commands.
+var flagCandidates = map[string][]string{
+	"-align": {"left", "middle", "right", "TextAlignLeft", "TextAlignMiddle", "TextAlighRight"},
+	"-dir":   {"left", "right", "TextDirLeft", "TextDirRight"},
+	"-font":  {"0", "1", "2", "3", "4", "5", "6", "7", "TextFont0", "TextFont1", "TextFont2", "TextFont3", "TextFont4", "TextFont5", "TextFont6", "TextFont7"},
+	"-w":     {"16", "32", "64"},
+}
+
+func complete(line string) []string {
+	words := strings.Fields(line)
+	if len(words) == 0 || strings.HasSuffix(line, " ") {
+		// start a new word
+		words = append(words, "")
+	}
+
+	last := words[len(words)-1]
+	prefix := line[:len(line)-len(last)]
+
+	var cands []string
+	switch {
+	case len(words) == 1:
+		for n := range commands {
+			cands = append(cands, n)
+		}
+		cands = append(cands, "help", "exit")
+	case words[0] == "channel" && len(words) == 3 && words[1] == "custom":
+		cands = customIdxCandidates
+	case words[0] == "settings" && len(words) == 3 && words[1] == "set":
+		for k := range settingCandidates {
+			cands = append(cands, k)
+		}
+	case words[0] == "settings" && len(words) == 4 && words[1] == "set":
+		cands = settingCandidates[words[2]]
+	case len(words) > 2 && flagCandidates[words[len(words)-2]] != nil:
+		cands = flagCandidates[words[len(words)-2]]
+	default:
+		cands = argCandidates[words[0]]
+	}
+
+	var ret []string
+	for _, c := range cands {
+		if strings.HasPrefix(c, last) {
+			ret = append(ret, prefix+c)
+		}
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func runShell(c *divoom.Client, args []string) (interface{}, error) {
+	if len(args) != 0 {
+		return nil, usagef("shell takes no args")
+	}
+
+	ln := liner.NewLiner()
+	defer ln.Close()
+	ln.SetCtrlCAborts(true)
+	ln.SetCompleter(complete)
+
+	histPath := shellHistoryPath()
+	if f, err := os.Open(histPath); err == nil {
+		ln.ReadHistory(f)
+		f.Close()
+	}
+	defer func() {
+		if histPath == "" {
+			return
+		}
+		os.MkdirAll(filepath.Dir(histPath), 0755)
+		if f, err := os.Create(histPath); err == nil {
+			ln.WriteHistory(f)
+			f.Close()
+		}
+	}()
+
+	printState(c)
+	for {
+		line, err := ln.Prompt("divoom> ")
+		if err == liner.ErrPromptAborted || err == io.EOF {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		words := strings.Fields(line)
+		if len(words) == 0 {
+			continue
+		}
+		ln.AppendHistory(line)
+
+		name := words[0]
+		switch name {
+		case "exit", "quit":
+			return nil, nil
+		case "help":
+			usage()
+			continue
+		case "shell":
+			fmt.Fprintln(os.Stderr, "already in shell")
+			continue
+		}
+
+		cmd, ok := commands[name]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "unknown command: %s\n", name)
+			continue
+		}
+
+		ret, err := cmd.run(c, translateEnums(name, words[1:]))
+		if err != nil {
+			if _, ok := err.(*usageError); ok {
+				fmt.Fprintf(os.Stderr, "usage: %s %s\n", name, cmd.usage)
+			}
+			report(err)
+			continue
+		}
+		printResult(ret)
+		printState(c)
+	}
+}
+
+// printState prints brief state of the device as feedback of commands.
+func printState(c *divoom.Client) {
+	ch, err := c.GetCurrentChannel()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "state:", err)
+		return
+	}
+	s, err := c.GetAllSetting()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "state:", err)
+		return
+	}
+
+	screen := "off"
+	if s["LightSwitch"] == float64(1) {
+		screen = "on"
+	}
+	if flagJSON {
+		printResult(map[string]interface{}{
+			"channel":    channelName(ch),
+			"brightness": s["Brightness"],
+			"screen":     screen,
+			"clock_id":   s["CurClockId"],
+			"rotation":   s["GyrateAngle"],
+		})
+		return
+	}
+	fmt.Printf("[channel=%s brightness=%v screen=%s clock=%v rotation=%v]\n",
+		channelName(ch), s["Brightness"], screen, s["CurClockId"], s["GyrateAngle"])
+}
+
+func shellHistoryPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "divoom", "shell_history")
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTranslateEnums(t *testing.T) {
+	tcs := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"channel", []string{"ChannelCustom", "CustomIdx2"}, []string{"custom", "2"}},
+		{"channel", []string{"ChannelFaces", "CustomIdx2"}, []string{"faces", "CustomIdx2"}},
+		{"cloud", []string{"CloudChannelFavorite"}, []string{"favorite"}},
+		{"stopwatch", []string{"StopwatchStatusReset"}, []string{"reset"}},
+		{"stopwatch", []string{"TextFont1"}, []string{"TextFont1"}},
+		{"settings", []string{"set", "rotation", "RotationAngle90"}, []string{"set", "rotation", "90"}},
+		{"settings", []string{"set", "mirror", "TempModeCelsius"}, []string{"set", "mirror", "TempModeCelsius"}},
+		{"settings", []string{"set", "HourMode12", "HourMode12"}, []string{"set", "HourMode12", "HourMode12"}},
+		{
+			"text",
+			[]string{"-font", "TextFont3", "-align=TextAlignMiddle", "--dir", "TextDirRight", "-x", "TextFont1", "TextAlignLeft"},
+			[]string{"-font", "3", "-align=middle", "--dir", "right", "-x", "TextFont1", "TextAlignLeft"},
+		},
+		// free text is kept, even if it is an enum name
+		{"text", []string{"ChannelFaces"}, []string{"ChannelFaces"}},
+		{"text", []string{"-font", "1", "--", "-dir", "TextDirLeft"}, []string{"-font", "1", "--", "-dir", "TextDirLeft"}},
+		{"qr", []string{"TextFont1"}, []string{"TextFont1"}},
+	}
+	for _, tc := range tcs {
+		if got := translateEnums(tc.name, tc.args); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s %v = %v, want %v", tc.name, tc.args, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// divoomd is REST gateway of Divoom devices, such as Pixoo64, in the LAN.
+// It drives the devices in the registry, ~/.config/divoom/devices.yaml, by name.
+//
+// Usage:
+//
+//	divoomd [-addr :8080] [-registry path]
+//
+// Endpoints are listed in OpenAPI spec served on /openapi.json.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"time"
+
+	divoom "github.com/suapapa/go_divoom"
+)
+
+var (
+	flagAddr     string
+	flagRegistry string
+)
+
+func main() {
+	flag.StringVar(&flagAddr, "addr", ":8080", "address to listen")
+	flag.StringVar(&flagRegistry, "registry", "", "device registry (default ~/.config/divoom/devices.yaml)")
+	flag.Parse()
+
+	if flagRegistry == "" {
+		var err error
+		flagRegistry, err = divoom.DefaultRegistryPath()
+		chk(err)
+	}
+
+	reg, err := divoom.LoadRegistry(flagRegistry)
+	chk(err)
+	if len(reg.Names()) == 0 {
+		log.Println("registry is empty. finding devices in the LAN...")
+		_, _, err = reg.Refresh()
+		chk(err)
+		chk(reg.Save())
+	}
+	log.Printf("devices: %v\n", reg.Names())
+
+	s := newServer(reg)
+	log.Printf("listening on %s\n", flagAddr)
+	hs := &http.Server{
+		Addr:              flagAddr,
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       time.Minute, // for uploads
+		WriteTimeout:      3 * time.Minute,
+	}
+	log.Fatal(hs.ListenAndServe())
+}
+
+func chk(err error) {
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// openAPISpec generates OpenAPI 3 spec from the routes.
+// Schemas of request and response bodies are reflected from their Go types.
+func openAPISpec(routes []*route) map[string]interface{} {
+	paths := make(map[string]interface{})
+	for _, rt := range routes {
+		p, ok := paths[rt.path].(map[string]interface{})
+		if !ok {
+			p = make(map[string]interface{})
+			paths[rt.path] = p
+		}
+
+		op := map[string]interface{}{
+			"summary": rt.summary,
+		}
+
+		var params []interface{}
+		if rt.needsName {
+			params = append(params, map[string]interface{}{
+				"name":        "name",
+				"in":          "path",
+				"required":    true,
+				"description": "device name in the registry",
+				"schema":      map[string]interface{}{"type": "string"},
+			})
+		}
+		for _, qp := range rt.params {
+			params = append(params, map[string]interface{}{
+				"name":        qp.name,
+				"in":          "query",
+				"description": qp.desc,
+				"schema":      map[string]interface{}{"type": qp.typ},
+			})
+		}
+		if params != nil {
+			op["parameters"] = params
+		}
+
+		switch {
+		case rt.req != nil:
+			op["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{
+						"schema": schemaOf(reflect.TypeOf(rt.req)),
+					},
+				},
+			}
+		case rt.reqForm:
+			op["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"multipart/form-data": map[string]interface{}{
+						"schema": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"file":  map[string]interface{}{"type": "string", "format": "binary"},
+								"speed": map[string]interface{}{"type": "integer"},
+							},
+							"required": []string{"file"},
+						},
+					},
+				},
+			}
+		}
+
+		resp := rt.resp
+		if resp == nil {
+			resp = okResult{}
+		}
+		op["responses"] = map[string]interface{}{
+			"200": map[string]interface{}{
+				"description": "OK",
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{
+						"schema": schemaOf(reflect.TypeOf(resp)),
+					},
+				},
+			},
+			"default": map[string]interface{}{
+				"description": "error; 400 for invalid request, 404 for unknown device, 413 for too large upload, 502 when the device fails",
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{
+						"schema": schemaOf(reflect.TypeOf(errorResult{})),
+					},
+				},
+			},
+		}
+
+		p[strings.ToLower(rt.method)] = op
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "divoomd",
+			"version": "1.0",
+		},
+		"paths": paths,
+	}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func schemaOf(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object"}
+	case reflect.Struct:
+		props := make(map[string]interface{})
+		addFields(props, t)
+		return map[string]interface{}{"type": "object", "properties": props}
+	}
+	return map[string]interface{}{}
+}
+
+func addFields(props map[string]interface{}, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			addFields(props, ft)
+			continue
+		}
+		if f.PkgPath != "" {
+			continue // unexported
+		}
+
+		name := f.Name
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		if n := strings.Split(tag, ",")[0]; n != "" {
+			name = n
+		}
+
+		s := schemaOf(f.Type)
+		if strings.Contains(tag, ",string") {
+			s = map[string]interface{}{"type": "string"}
+		}
+		props[name] = s
+	}
+}
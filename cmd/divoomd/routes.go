@@ -0,0 +1,530 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	"github.com/pkg/errors"
+	divoom "github.com/suapapa/go_divoom"
+)
+
+const (
+	// maxUploadSize limits size of uploaded or downloaded image
+	maxUploadSize = 8 << 20
+	// maxFormSize limits body of upload form; the image and room for the other fields
+	maxFormSize = maxUploadSize + 64<<10
+)
+
+// downloadClient gets images of /animation/url.
+var downloadClient = &http.Client{Timeout: 30 * time.Second}
+
+type brightnessReq struct {
+	Brightness int `json:"brightness"`
+}
+
+type onOffReq struct {
+	On bool `json:"on"`
+}
+
+type channelReq struct {
+	Channel    string `json:"channel"`     // faces, cloud, visualizer or custom
+	CustomPage *int   `json:"custom_page"` // 0~2, for custom channel
+}
+
+type channelResp struct {
+	Channel string `json:"channel"`
+	Index   int    `json:"index"`
+}
+
+type faceReq struct {
+	ClockID int `json:"clock_id"`
+}
+
+type visualizerReq struct {
+	Position int `json:"position"`
+}
+
+type cloudReq struct {
+	Index int `json:"index"` // 0 recommend gallery, 1 favorite, 2 subscribed artist
+}
+
+type playGifReq struct {
+	Type int    `json:"type"` // 0 file, 1 folder, 2 net
+	Name string `json:"name"`
+}
+
+type animationURLReq struct {
+	URL   string `json:"url"`
+	Speed int    `json:"speed"` // frame duration in msec for still image
+}
+
+type picIDResp struct {
+	PicID int `json:"pic_id"`
+}
+
+type textReq struct {
+	ID           int    `json:"id"`
+	X            int    `json:"x"`
+	Y            int    `json:"y"`
+	Dir          int    `json:"dir"`  // 0 left, 1 right
+	Font         int    `json:"font"` // 0~7
+	Width        int    `json:"width"`
+	Text         string `json:"text"`
+	Speed        int    `json:"speed"`
+	Color        string `json:"color"`
+	Align        int    `json:"align"` // 1 left, 2 middle, 3 right
+	CheckCharset bool   `json:"check_charset"`
+}
+
+type countdownReq struct {
+	Seconds int  `json:"seconds"`
+	Start   bool `json:"start"`
+}
+
+type stopwatchReq struct {
+	Status int `json:"status"` // 0 stop, 1 start, 2 reset
+}
+
+type scoreboardReq struct {
+	Red  int `json:"red"`
+	Blue int `json:"blue"`
+}
+
+type timeZoneReq struct {
+	TimeZone string `json:"time_zone"`
+}
+
+type weatherAreaReq struct {
+	Longitude string `json:"longitude"`
+	Latitude  string `json:"latitude"`
+}
+
+type timeReq struct {
+	Time *time.Time `json:"time"` // now if omitted
+}
+
+type timeResp struct {
+	Time time.Time `json:"time"`
+}
+
+type modeReq struct {
+	Mode int `json:"mode"`
+}
+
+type whiteBalanceReq struct {
+	R int `json:"r"`
+	G int `json:"g"`
+	B int `json:"b"`
+}
+
+type deviceResp struct {
+	Name string `json:"name"`
+	divoom.RegisteredDevice
+}
+
+type refreshResp struct {
+	Added []string `json:"added"`
+	Moved []string `json:"moved"`
+}
+
+type dialListResp struct {
+	Total int           `json:"total"`
+	Dials []divoom.Dial `json:"dials"`
+}
+
+func routes() []*route {
+	rs := []*route{
+		{
+			method: http.MethodGet, path: "/devices", summary: "list registered devices",
+			resp: []deviceResp{},
+			handle: func(ctx *reqCtx) (interface{}, error) {
+				ret := []deviceResp{}
+				for _, n := range ctx.s.reg.Names() {
+					rd, err := ctx.s.reg.Get(n)
+					if err != nil {
+						continue
+					}
+					ret = append(ret, deviceResp{Name: n, RegisteredDevice: *rd})
+				}
+				return ret, nil
+			},
+		},
+		{
+			method: http.MethodPost, path: "/devices/refresh", summary: "find devices in the LAN and update the registry",
+			resp: refreshResp{},
+			handle: func(ctx *reqCtx) (interface{}, error) {
+				added, moved, err := ctx.s.reg.Refresh()
+				if err != nil {
+					return nil, err
+				}
+				err = ctx.s.reg.Save()
+				if err != nil {
+					return nil, err
+				}
+				return refreshResp{Added: added, Moved: moved}, nil
+			},
+		},
+		{
+			method: http.MethodGet, path: "/dial-types", summary: "list dial types of faces channel",
+			resp: []string{},
+			handle: func(ctx *reqCtx) (interface{}, error) {
+				return divoom.DialType()
+			},
+		},
+		{
+			method: http.MethodGet, path: "/dials", summary: "list dials of the type",
+			params: []param{{"type", "dial type", "string"}, {"page", "page from 1", "integer"}},
+			resp:   dialListResp{},
+			handle: func(ctx *reqCtx) (interface{}, error) {
+				page, _ := strconv.Atoi(ctx.r.URL.Query().Get("page"))
+				if page < 1 {
+					page = 1
+				}
+				dials, tot, err := divoom.DialList(ctx.r.URL.Query().Get("type"), page)
+				if err != nil {
+					return nil, err
+				}
+				return dialListResp{Total: tot, Dials: dials}, nil
+			},
+		},
+		{
+			method: http.MethodGet, path: "/fonts", summary: "list fonts",
+			resp: []*divoom.Font{},
+			handle: func(ctx *reqCtx) (interface{}, error) {
+				return divoom.GetFontList()
+			},
+		},
+		{
+			method: http.MethodPost, path: "/devices/{name}/brightness", summary: "set brightness",
+			req: brightnessReq{},
+			handle: func(ctx *reqCtx) (interface{}, error) {
+				return nil, ctx.c.SetBrightness(ctx.body.(*brightnessReq).Brightness)
+			},
+		},
+		{
+			method: http.MethodPost, path: "/devices/{name}/screen", summary: "turn screen on or off",
+			req: onOffReq{},
+			handle: func(ctx *reqCtx) (interface{}, error) {
+				return nil, ctx.c.ScreenSwitch(ctx.body.(*onOffReq).On)
+			},
+		},
+		{
+			method: http.MethodGet, path: "/devices/{name}/channel", summary: "get current channel",
+			resp: channelResp{},
+			handle: func(ctx *reqCtx) (interface{}, error) {
+				ch, err := ctx.c.GetCurrentChannel()
+				if err != nil {
+					return nil, err
+				}
+				return channelResp{Channel: channelNames[ch], Index: int(ch)}, nil
+			},
+		},
+		{
+			method: http.MethodPost, path: "/devices/{name}/channel", summary: "select channel",
+			req: channelReq{},
+			handle: func(ctx *reqCtx) (interface{}, error) {
+				req := ctx.body.(*channelReq)
+				if p := req.CustomPage; p != nil && (*p < 0 || *p > 2) {
+					return nil, badRequest("custom_page %d is out of 0~2", *p)
+				}
+				for ch, n := range channelNames {
+					if n != req.Channel {
+						continue
+					}
+					err := ctx.c.SelectChannel(ch)
+					if err != nil || ch != divoom.ChannelCustom || req.CustomPage == nil {
+						return nil, err
+					}
+					return nil, ctx.c.CustomChannel(divoom.CustomIdx(*req.CustomPage))
+				}
+				return nil, badRequest("unknown channel %q", req.Channel)
+			},
+		},
+		{
+			method: http.MethodGet, path: "/devices/{name}/face", summary: "get selected face",
+			resp: divoom.FaceID{},
+			handle: func(ctx *reqCtx) (interface{}, error) {
+				return ctx.c.GetSelectFaceID()
+			},
+		},
+		{
+			method: http.MethodPost, path: "/devices/{name}/face", summary: "select face of faces channel",
+			req: faceReq{},
+			handle: func(ctx *reqCtx) (interface{}, error) {
+				return nil, ctx.c.SelectFacesChannel(ctx.body.(*faceReq).ClockID)
+			},
+		},
+		{
+			method: http.MethodPost, path: "/devices/{name}/visualizer", summary: "select visualizer",
+			req: visualizerReq{},
+			handle: func(ctx *reqCtx) (interface{}, error) {
+				return nil, ctx.c.VisualizerChannel(ctx.body.(*visualizerReq).Position)
+			},
+		},
+		{
+			method: http.MethodPost, path: "/devices/{name}/cloud", summary: "select cloud channel",
+			req: cloudReq{},
+			handle: func(ctx *reqCtx) (interface{}, error) {
+				return nil, ctx.c.CloudChannel(divoom.CloudChannelIdx(ctx.body.(*cloudReq).Index))
+			},
+		},
+		{
+			method: http.MethodPost, path: "/devices/{name}/gif/play", summary: "play gif file, folder or url",
+			req: playGifReq{},
+			handle: func(ctx *reqCtx) (interface{}, error) {
+				req := ctx.body.(*playGifReq)
+				return nil, ctx.c.PlayGif(divoom.PlayGIFType(req.Type), req.Name)
+			},
+		},
+		{
+			method: http.MethodPost, path: "/devices/{name}/animation", summary: "upload gif or image as multipart form, file field",
+			reqForm: true,
+			handle: func(ctx *reqCtx) (interface{}, error) {
+				ctx.r.Body = http.MaxBytesReader(ctx.w, ctx.r.Body, maxFormSize)
+				err := ctx.r.ParseMultipartForm(maxUploadSize)
+				var mbe *http.MaxBytesError
+				if errors.As(err, &mbe) {
+					return nil, &httpError{code: http.StatusRequestEntityTooLarge, msg: fmt.Sprintf("form is larger than %d bytes", maxFormSize)}
+				}
+				if err != nil {
+					return nil, badRequest("invalid form: %v", err)
+				}
+				f, _, err := ctx.r.FormFile("file")
+				if err != nil {
+					return nil, badRequest("want file: %v", err)
+				}
+				defer f.Close()
+				speed, _ := strconv.Atoi(ctx.r.FormValue("speed"))
+				return nil, sendAnimation(ctx, f, speed)
+			},
+		},
+		{
+			method: http.MethodPost, path: "/devices/{name}/animation/url", summary: "upload gif or image of url",
+			req: animationURLReq{},
+			handle: func(ctx *reqCtx) (interface{}, error) {
+				req := ctx.body.(*animationURLReq)
+				u, err := url.Parse(req.URL)
+				if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+					return nil, badRequest("want http or https url: %q", req.URL)
+				}
+				resp, err := downloadClient.Get(u.String())
+				if err != nil {
+					return nil, badRequest("fail to get %s: %v", req.URL, err)
+				}
+				defer resp.Body.Close()
+				if resp.StatusCode != http.StatusOK {
+					return nil, badRequest("fail to get %s: %s", req.URL, resp.Status)
+				}
+				// sendAnimation reads up to maxUploadSize
+				return nil, sendAnimation(ctx, resp.Body, req.Speed)
+			},
+		},
+		{
+			method: http.MethodGet, path: "/devices/{name}/animation/id", summary: "get pic id for next animation",
+			resp: picIDResp{},
+			handle: func(ctx *reqCtx) (interface{}, error) {
+				id, err := ctx.c.GetSendingAnimationPicID()
+				if err != nil {
+					return nil, err
+				}
+				return picIDResp{PicID: id}, nil
+			},
+		},
+		{
+			method: http.MethodPost, path: "/devices/{name}/animation/reset", summary: "reset pic id of animation",
+			handle: func(ctx *reqCtx) (interface{}, error) {
+				return nil, ctx.c.ResetSendingAnimationPicID()
+			},
+		},
+		{
+			method: http.MethodPost, path: "/devices/{name}/text", summary: "draw text over the animation",
+			req: textReq{},
+			handle: func(ctx *reqCtx) (interface{}, error) {
+				req := ctx.body.(*textReq)
+				if req.CheckCharset {
+					err := divoom.CheckTextFont(req.Font, req.Text)
+					if err != nil {
+						return nil, badRequest("%v", err)
+					}
+				}
+				return nil, ctx.c.SendText(req.ID, req.X, req.Y, divoom.TextDir(req.Dir), divoom.TextFont(req.Font),
+					req.Width, req.Text, req.Speed, req.Color, divoom.TextAlign(req.Align))
+			},
+		},
+		{
+			method: http.MethodDelete, path: "/devices/{name}/text", summary: "clear all text area",
+			handle: func(ctx *reqCtx) (interface{}, error) {
+				return nil, ctx.c.ClearAllTextArea()
+			},
+		},
+		{
+			method: http.MethodPost, path: "/devices/{name}/countdown", summary: "set countdown tool",
+			req: countdownReq{},
+			handle: func(ctx *reqCtx) (interface{}, error) {
+				req := ctx.body.(*countdownReq)
+				return nil, ctx.c.SetCountdownTool(time.Duration(req.Seconds)*time.Second, req.Start)
+			},
+		},
+		{
+			method: http.MethodPost, path: "/devices/{name}/stopwatch", summary: "control stopwatch tool",
+			req: stopwatchReq{},
+			handle: func(ctx *reqCtx) (interface{}, error) {
+				return nil, ctx.c.SetStopwatchTool(divoom.StopwatchStatus(ctx.body.(*stopwatchReq).Status))
+			},
+		},
+		{
+			method: http.MethodPost, path: "/devices/{name}/scoreboard", summary: "set scoreboard tool",
+			req: scoreboardReq{},
+			handle: func(ctx *reqCtx) (interface{}, error) {
+				req := ctx.body.(*scoreboardReq)
+				return nil, ctx.c.SetScoreboardTool(req.Red, req.Blue)
+			},
+		},
+		{
+			method: http.MethodPost, path: "/devices/{name}/noise", summary: "turn noise meter tool on or off",
+			req: onOffReq{},
+			handle: func(ctx *reqCtx) (interface{}, error) {
+				return nil, ctx.c.SetNoiseTool(ctx.body.(*onOffReq).On)
+			},
+		},
+		{
+			method: http.MethodGet, path: "/devices/{name}/settings", summary: "get all settings",
+			resp: map[string]interface{}{},
+			handle: func(ctx *reqCtx) (interface{}, error) {
+				return ctx.c.GetAllSetting()
+			},
+		},
+		{
+			method: http.MethodPost, path: "/devices/{name}/settings/time-zone", summary: "set time zone, like GMT+9",
+			req: timeZoneReq{},
+			handle: func(ctx *reqCtx) (interface{}, error) {
+				return nil, ctx.c.SetTimeZone(ctx.body.(*timeZoneReq).TimeZone)
+			},
+		},
+		{
+			method: http.MethodPost, path: "/devices/{name}/settings/weather-area", summary: "set coordinates for weather",
+			req: weatherAreaReq{},
+			handle: func(ctx *reqCtx) (interface{}, error) {
+				req := ctx.body.(*weatherAreaReq)
+				return nil, ctx.c.WeatherAreaSetting(req.Longitude, req.Latitude)
+			},
+		},
+		{
+			method: http.MethodGet, path: "/devices/{name}/time", summary: "get device time",
+			resp: timeResp{},
+			handle: func(ctx *reqCtx) (interface{}, error) {
+				t, err := ctx.c.DeviceTime()
+				if err != nil {
+					return nil, err
+				}
+				return timeResp{Time: t}, nil
+			},
+		},
+		{
+			method: http.MethodPost, path: "/devices/{name}/time", summary: "set device time",
+			req: timeReq{},
+			handle: func(ctx *reqCtx) (interface{}, error) {
+				t := time.Now()
+				if req := ctx.body.(*timeReq); req.Time != nil {
+					t = *req.Time
+				}
+				return nil, ctx.c.SetSystemTime(t)
+			},
+		},
+		{
+			method: http.MethodPost, path: "/devices/{name}/settings/temperature-mode", summary: "set temperature mode; 0 celsius, 1 fahrenheit",
+			req: modeReq{},
+			handle: func(ctx *reqCtx) (interface{}, error) {
+				return nil, ctx.c.SetTemperatureMode(divoom.TempMode(ctx.body.(*modeReq).Mode))
+			},
+		},
+		{
+			method: http.MethodPost, path: "/devices/{name}/settings/rotation", summary: "set rotation; 0, 1, 2, 3 for 0, 90, 180, 270 degree",
+			req: modeReq{},
+			handle: func(ctx *reqCtx) (interface{}, error) {
+				return nil, ctx.c.SetRotationAngle(divoom.RotationAngle(ctx.body.(*modeReq).Mode))
+			},
+		},
+		{
+			method: http.MethodPost, path: "/devices/{name}/settings/mirror", summary: "set mirror mode; 0 disable, 1 enable",
+			req: modeReq{},
+			handle: func(ctx *reqCtx) (interface{}, error) {
+				return nil, ctx.c.SetMirrorMode(divoom.MirrorMode(ctx.body.(*modeReq).Mode))
+			},
+		},
+		{
+			method: http.MethodPost, path: "/devices/{name}/settings/hour-mode", summary: "set hour mode; 0 12 hours, 1 24 hours",
+			req: modeReq{},
+			handle: func(ctx *reqCtx) (interface{}, error) {
+				return nil, ctx.c.SetHourMode(divoom.HourMode(ctx.body.(*modeReq).Mode))
+			},
+		},
+		{
+			method: http.MethodPost, path: "/devices/{name}/settings/high-light", summary: "turn high light mode on or off",
+			req: onOffReq{},
+			handle: func(ctx *reqCtx) (interface{}, error) {
+				return nil, ctx.c.SetHighLightMode(ctx.body.(*onOffReq).On)
+			},
+		},
+		{
+			method: http.MethodPost, path: "/devices/{name}/settings/white-balance", summary: "set white balance; 0~100 each",
+			req: whiteBalanceReq{},
+			handle: func(ctx *reqCtx) (interface{}, error) {
+				req := ctx.body.(*whiteBalanceReq)
+				return nil, ctx.c.SetWhiteBalance(req.R, req.G, req.B)
+			},
+		},
+	}
+
+	rs = append(rs, &route{
+		method: http.MethodGet, path: "/openapi.json", summary: "OpenAPI spec of this server",
+		resp: map[string]interface{}{},
+		handle: func(ctx *reqCtx) (interface{}, error) {
+			return openAPISpec(ctx.s.routes), nil
+		},
+	})
+
+	return rs
+}
+
+var channelNames = map[divoom.Channel]string{
+	divoom.ChannelFaces:      "faces",
+	divoom.ChannelCloud:      "cloud",
+	divoom.ChannelVisualizer: "visualizer",
+	divoom.ChannelCustom:     "custom",
+}
+
+// sendAnimation sends gif, APNG, WebP or still image of r to the device
+// with Client.SendImage. Still image is shown for speed msec.
+func sendAnimation(ctx *reqCtx, r io.Reader, speed int) error {
+	b, err := io.ReadAll(io.LimitReader(r, maxUploadSize+1))
+	if err != nil {
+		return badRequest("fail to read image: %v", err)
+	}
+	if len(b) > maxUploadSize {
+		return &httpError{code: http.StatusRequestEntityTooLarge, msg: fmt.Sprintf("image is larger than %d bytes", maxUploadSize)}
+	}
+	// tell bad images from failures of the device
+	if _, _, err := image.DecodeConfig(bytes.NewReader(b)); err != nil && !divoom.IsWebP(b) {
+		return badRequest("unknown image format: %v", err)
+	}
+	if speed <= 0 {
+		speed = 1000
+	}
+
+	err = ctx.c.ResetSendingAnimationPicID()
+	if err != nil {
+		return errors.Wrap(err, "fail to send animation")
+	}
+	return ctx.c.SendImage(1, bytes.NewReader(b), speed)
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	divoom "github.com/suapapa/go_divoom"
+)
+
+// route is an endpoint of the server. OpenAPI spec is generated from the routes.
+type route struct {
+	method  string
+	path    string // segments in braces are path params, like /devices/{name}
+	summary string
+
+	req       interface{} // zero value of JSON request body, nil if none
+	reqForm   bool        // request body is multipart form
+	resp      interface{} // zero value of response, nil if none
+	params    []param     // query params
+	needsName bool        // path has {name} of a device. set by newServer
+
+	handle func(ctx *reqCtx) (interface{}, error)
+}
+
+type param struct {
+	name, desc string
+	typ        string // integer or string
+}
+
+type reqCtx struct {
+	w      http.ResponseWriter
+	r      *http.Request
+	s      *server
+	c      *divoom.Client
+	name   string
+	body   interface{} // pointer to decoded request body
+	device *divoom.RegisteredDevice
+}
+
+type httpError struct {
+	code int
+	msg  string
+}
+
+func (e *httpError) Error() string {
+	return e.msg
+}
+
+func badRequest(format string, a ...interface{}) error {
+	return &httpError{code: http.StatusBadRequest, msg: fmt.Sprintf(format, a...)}
+}
+
+type server struct {
+	reg    *divoom.Registry
+	routes []*route
+	opts   []divoom.ClientOption // options of the device clients
+
+	mu      sync.Mutex
+	clients map[string]*deviceClient
+}
+
+type deviceClient struct {
+	*divoom.Client
+	ip string
+}
+
+// newServer returns server of the devices in reg. opts are given to the device clients.
+func newServer(reg *divoom.Registry, opts ...divoom.ClientOption) *server {
+	s := &server{
+		reg:     reg,
+		opts:    opts,
+		clients: make(map[string]*deviceClient),
+	}
+	s.routes = routes()
+	for _, rt := range s.routes {
+		rt.needsName = strings.Contains(rt.path, "{name}")
+	}
+	return s
+}
+
+func (s *server) client(name string) (*divoom.Client, *divoom.RegisteredDevice, error) {
+	rd, err := s.reg.Get(name)
+	if err != nil {
+		return nil, nil, &httpError{code: http.StatusNotFound, msg: fmt.Sprintf("device %q is not registered", name)}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// make new client if ip is moved
+	dc, ok := s.clients[name]
+	if !ok || dc.ip != rd.IP {
+		dc = &deviceClient{
+			Client: divoom.NewClient(rd.Device(name), s.opts...),
+			ip:     rd.IP,
+		}
+		s.clients[name] = dc
+	}
+	return dc.Client, rd, nil
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt, pathParams, methodOK := s.match(r)
+	if rt == nil {
+		if methodOK {
+			writeError(w, &httpError{code: http.StatusMethodNotAllowed, msg: "method not allowed"})
+			return
+		}
+		writeError(w, &httpError{code: http.StatusNotFound, msg: "not found"})
+		return
+	}
+
+	ctx := &reqCtx{w: w, r: r, s: s, name: pathParams["name"]}
+	if rt.needsName {
+		var err error
+		ctx.c, ctx.device, err = s.client(ctx.name)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+
+	if rt.req != nil {
+		ctx.body = reflect.New(reflect.TypeOf(rt.req)).Interface()
+		err := json.NewDecoder(r.Body).Decode(ctx.body)
+		if err != nil {
+			writeError(w, badRequest("invalid body: %v", err))
+			return
+		}
+	}
+
+	ret, err := rt.handle(ctx)
+	if err != nil {
+		log.Printf("%s %s: %v\n", r.Method, r.URL.Path, err)
+		writeError(w, err)
+		return
+	}
+
+	if ret == nil {
+		ret = okResult{OK: true}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ret)
+}
+
+// match finds route of the request. methodOK is true if path matches but method doesn't.
+func (s *server) match(r *http.Request) (rt *route, pathParams map[string]string, methodOK bool) {
+	segs := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	for _, rt := range s.routes {
+		pp, ok := matchPath(rt.path, segs)
+		if !ok {
+			continue
+		}
+		if rt.method != r.Method {
+			methodOK = true
+			continue
+		}
+		return rt, pp, false
+	}
+	return nil, nil, methodOK
+}
+
+func matchPath(path string, segs []string) (map[string]string, bool) {
+	rsegs := strings.Split(strings.Trim(path, "/"), "/")
+	if len(rsegs) != len(segs) {
+		return nil, false
+	}
+
+	pp := make(map[string]string)
+	for i, rs := range rsegs {
+		if strings.HasPrefix(rs, "{") && strings.HasSuffix(rs, "}") {
+			pp[rs[1:len(rs)-1]] = segs[i]
+			continue
+		}
+		if rs != segs[i] {
+			return nil, false
+		}
+	}
+	return pp, true
+}
+
+type okResult struct {
+	OK bool `json:"ok"`
+}
+
+type errorResult struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	code := http.StatusBadGateway // device fails the command
+	if he, ok := errors.Cause(err).(*httpError); ok {
+		code = he.code
+	}
+	switch errors.Cause(err) {
+	case divoom.ErrInvalidBrightness, divoom.ErrInvalidWhiteBalance, divoom.ErrInvalidScore,
+		divoom.ErrInvalidCountdown, divoom.ErrInvalidPicNum, divoom.ErrInvalidPicWidth:
+		code = http.StatusBadRequest
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(errorResult{Error: err.Error()})
+}
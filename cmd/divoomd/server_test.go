@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	divoom "github.com/suapapa/go_divoom"
+)
+
+// fakeDevice replies to commands by replies, or error_code 0, and keeps them.
+type fakeDevice struct {
+	replies map[string]string // command to reply
+
+	mu   sync.Mutex
+	cmds []map[string]interface{}
+}
+
+func (d *fakeDevice) RoundTrip(req *http.Request) (*http.Response, error) {
+	cmd := make(map[string]interface{})
+	err := json.NewDecoder(req.Body).Decode(&cmd)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	d.cmds = append(d.cmds, cmd)
+	d.mu.Unlock()
+
+	reply, ok := d.replies[cmd["Command"].(string)]
+	if !ok {
+		reply = `{"error_code": 0}`
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(reply)),
+		Request:    req,
+	}, nil
+}
+
+func (d *fakeDevice) commands() []map[string]interface{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]map[string]interface{}(nil), d.cmds...)
+}
+
+func newTestServer(t *testing.T, dev *fakeDevice) *httptest.Server {
+	t.Helper()
+	reg, err := divoom.LoadRegistry(filepath.Join(t.TempDir(), "devices.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg.Add("office", &divoom.Device{DeviceName: "Pixoo64", DeviceID: 1, DevicePrivateIP: "192.168.0.10", Hardware: 400})
+
+	srv := httptest.NewServer(newServer(reg, divoom.WithHTTPClient(&http.Client{Transport: dev})))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestDeviceRoutes(t *testing.T) {
+	dev := &fakeDevice{replies: map[string]string{
+		"Channel/GetIndex": `{"error_code": 0, "SelectIndex": 2}`,
+	}}
+	srv := newTestServer(t, dev)
+
+	tcs := []struct {
+		method, path, body string
+		wantStatus         int
+		wantBody           string
+		wantCmd            map[string]interface{}
+	}{
+		{"GET", "/devices", "", 200, `"name":"office"`, nil},
+		{"POST", "/devices/office/brightness", `{"brightness": 30}`, 200, `"ok":true`,
+			map[string]interface{}{"Command": "Channel/SetBrightness", "Brightness": float64(30)}},
+		{"POST", "/devices/office/brightness", `{"brightness": 130}`, 400, "brightness", nil},
+		{"POST", "/devices/office/brightness", `{"brightness":`, 400, "invalid body", nil},
+		{"POST", "/devices/office/screen", `{"on": true}`, 200, `"ok":true`,
+			map[string]interface{}{"Command": "Channel/OnOffScreen", "OnOff": float64(1)}},
+		{"GET", "/devices/office/channel", "", 200, `"channel":"visualizer"`,
+			map[string]interface{}{"Command": "Channel/GetIndex"}},
+		{"POST", "/devices/office/channel", `{"channel": "custom", "custom_page": 1}`, 200, `"ok":true`,
+			map[string]interface{}{"Command": "Channel/SetCustomPageIndex", "CustomPageIndex": float64(1)}},
+		{"POST", "/devices/office/channel", `{"channel": "custom", "custom_page": 5}`, 400, "custom_page", nil},
+		{"POST", "/devices/office/channel", `{"channel": "radio"}`, 400, "unknown channel", nil},
+		{"POST", "/devices/home/brightness", `{"brightness": 30}`, 404, "not registered", nil},
+		{"GET", "/devices/office/brightness", "", 405, "method not allowed", nil},
+		{"GET", "/nowhere", "", 404, "not found", nil},
+	}
+
+	for _, tc := range tcs {
+		sent := len(dev.commands())
+		req, _ := http.NewRequest(tc.method, srv.URL+tc.path, strings.NewReader(tc.body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		name := tc.method + " " + tc.path + " " + tc.body
+		if resp.StatusCode != tc.wantStatus || !strings.Contains(string(b), tc.wantBody) {
+			t.Errorf("%s: %d %s, want %d with %q", name, resp.StatusCode, b, tc.wantStatus, tc.wantBody)
+		}
+
+		cmds := dev.commands()[sent:]
+		if tc.wantCmd == nil {
+			if tc.wantStatus != 200 && len(cmds) != 0 {
+				t.Errorf("%s: sent %v on error", name, cmds)
+			}
+			continue
+		}
+		if len(cmds) == 0 {
+			t.Errorf("%s: nothing sent", name)
+			continue
+		}
+		last := cmds[len(cmds)-1]
+		for k, v := range tc.wantCmd {
+			if last[k] != v {
+				t.Errorf("%s: sent %v, want %s of %v", name, last, k, v)
+			}
+		}
+	}
+}
+
+func postForm(t *testing.T, url string, file []byte) (*http.Response, string) {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", "image")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write(file)
+	mw.WriteField("speed", "500")
+	mw.Close()
+
+	resp, err := http.Post(url, mw.FormDataContentType(), &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	return resp, string(b)
+}
+
+func TestUploadAnimation(t *testing.T) {
+	dev := &fakeDevice{}
+	srv := newTestServer(t, dev)
+	url := srv.URL + "/devices/office/animation"
+
+	// still image is fitted, cropped and resized, like Client.SendImage
+	var img bytes.Buffer
+	png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 100, 50)))
+	if resp, body := postForm(t, url, img.Bytes()); resp.StatusCode != 200 {
+		t.Fatalf("upload png: %s %s", resp.Status, body)
+	}
+	cmds := dev.commands()
+	last := cmds[len(cmds)-1]
+	if last["Command"] != "Draw/SendHttpGif" || last["PicWidth"] != float64(32) || last["PicSpeed"] != float64(500) {
+		t.Errorf("sent %v for 100x50 png, want 32 width of 500 msec", last)
+	}
+
+	tcs := []struct {
+		name       string
+		file       []byte
+		wantStatus int
+		wantBody   string
+	}{
+		{"not image", []byte("hello"), 400, "unknown image format"},
+		{"large image", bytes.Repeat([]byte{0}, maxUploadSize+1), 413, "image is larger"},
+		// body is cut before the form is parsed, not spilled to disk
+		{"large form", bytes.Repeat([]byte{0}, maxFormSize+1), 413, "form is larger"},
+	}
+	for _, tc := range tcs {
+		sent := len(dev.commands())
+		resp, body := postForm(t, url, tc.file)
+		if resp.StatusCode != tc.wantStatus || !strings.Contains(body, tc.wantBody) {
+			t.Errorf("%s: %s %s, want %d with %q", tc.name, resp.Status, body, tc.wantStatus, tc.wantBody)
+		}
+		if cmds := dev.commands()[sent:]; len(cmds) != 0 {
+			t.Errorf("%s: sent %v", tc.name, cmds)
+		}
+	}
+}
+
+func TestOpenAPI(t *testing.T) {
+	srv := newTestServer(t, &fakeDevice{})
+
+	resp, err := http.Get(srv.URL + "/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var spec struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]map[string]struct {
+			Parameters []struct {
+				Name string `json:"name"`
+				In   string `json:"in"`
+			} `json:"parameters"`
+			RequestBody struct {
+				Content map[string]struct {
+					Schema struct {
+						Properties map[string]struct {
+							Type string `json:"type"`
+						} `json:"properties"`
+					} `json:"schema"`
+				} `json:"content"`
+			} `json:"requestBody"`
+		} `json:"paths"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&spec); err != nil {
+		t.Fatal(err)
+	}
+	if spec.OpenAPI != "3.0.3" {
+		t.Errorf("openapi = %q", spec.OpenAPI)
+	}
+
+	for _, rt := range routes() {
+		op, ok := spec.Paths[rt.path][strings.ToLower(rt.method)]
+		if !ok {
+			t.Errorf("no %s %s in spec", rt.method, rt.path)
+			continue
+		}
+		if strings.Contains(rt.path, "{name}") && (len(op.Parameters) == 0 || op.Parameters[0].In != "path") {
+			t.Errorf("%s %s: no path param of name", rt.method, rt.path)
+		}
+	}
+
+	bright := spec.Paths["/devices/{name}/brightness"]["post"].RequestBody.Content["application/json"].Schema
+	if bright.Properties["brightness"].Type != "integer" {
+		t.Errorf("schema of brightness request = %+v", bright)
+	}
+	upload := spec.Paths["/devices/{name}/animation"]["post"].RequestBody.Content["multipart/form-data"].Schema
+	if upload.Properties["file"].Type != "string" {
+		t.Errorf("schema of upload = %+v", upload)
+	}
+}
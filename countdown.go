@@ -0,0 +1,208 @@
+package divoom
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// MaxCountdown is the longest duration the countdown tool can show.
+const MaxCountdown = 99*time.Minute + 59*time.Second
+
+// Countdown controls countdown tool of the device and tracks its state.
+// Device doesn't report the remaining time, so it is estimated locally.
+type Countdown struct {
+	c *Client
+
+	mu        sync.Mutex
+	dur       time.Duration
+	remain    time.Duration
+	startedAt time.Time
+	running   bool
+	timer     *time.Timer
+	gen       int
+	done      chan struct{}
+	onDone    func()
+}
+
+// NewCountdown returns a Countdown of dur. It isn't sent to the device until Start.
+func NewCountdown(c *Client, dur time.Duration) (*Countdown, error) {
+	dur = dur.Truncate(time.Second)
+	if dur < 0 || dur > MaxCountdown {
+		return nil, ErrInvalidCountdown
+	}
+
+	return &Countdown{
+		c:      c,
+		dur:    dur,
+		remain: dur,
+		done:   make(chan struct{}),
+	}, nil
+}
+
+// OnDone sets callback which is called when the countdown reaches zero.
+func (cd *Countdown) OnDone(f func()) {
+	cd.mu.Lock()
+	defer cd.mu.Unlock()
+
+	cd.onDone = f
+}
+
+// Done returns a channel which is closed when the countdown reaches zero.
+// Reset makes a new channel and the channel got before Reset is never closed
+// unless the countdown reached zero before, so get it again after Reset.
+func (cd *Countdown) Done() <-chan struct{} {
+	cd.mu.Lock()
+	defer cd.mu.Unlock()
+
+	return cd.done
+}
+
+// Start starts, or resumes, the countdown from remaining time.
+func (cd *Countdown) Start() error {
+	cd.mu.Lock()
+	defer cd.mu.Unlock()
+
+	if cd.running {
+		return nil
+	}
+	if cd.remain <= 0 {
+		return errors.New("fail to start countdown: no time remains")
+	}
+
+	err := cd.c.SetCountdownTool(cd.remain, true)
+	if err != nil {
+		return errors.Wrap(err, "fail to start countdown")
+	}
+
+	cd.running = true
+	cd.startedAt = time.Now()
+	cd.gen++
+	gen := cd.gen
+	cd.timer = time.AfterFunc(cd.remain, func() { cd.expire(gen) })
+
+	return nil
+}
+
+// Stop pauses the countdown. Start resumes it.
+// Stopping in the last second finishes the countdown as it reached zero.
+func (cd *Countdown) Stop() error {
+	cd.mu.Lock()
+	f, err := cd.stopLocked()
+	cd.mu.Unlock()
+
+	if f != nil {
+		f()
+	}
+	return err
+}
+
+// stopLocked stops the countdown and returns OnDone callback to call if it finished.
+func (cd *Countdown) stopLocked() (func(), error) {
+	if !cd.running {
+		return nil, nil
+	}
+
+	remain := cd.remainLocked().Truncate(time.Second)
+	err := cd.c.SetCountdownTool(remain, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to stop countdown")
+	}
+
+	cd.timer.Stop()
+	cd.gen++
+	if remain == 0 {
+		return cd.finishLocked(), nil
+	}
+	cd.running = false
+	cd.remain = remain
+
+	return nil, nil
+}
+
+// Reset stops the countdown and sets it to dur.
+func (cd *Countdown) Reset(dur time.Duration) error {
+	dur = dur.Truncate(time.Second)
+	if dur < 0 || dur > MaxCountdown {
+		return ErrInvalidCountdown
+	}
+
+	cd.mu.Lock()
+	defer cd.mu.Unlock()
+
+	err := cd.c.SetCountdownTool(dur, false)
+	if err != nil {
+		return errors.Wrap(err, "fail to reset countdown")
+	}
+
+	if cd.timer != nil {
+		cd.timer.Stop()
+	}
+	cd.gen++
+	cd.running = false
+	cd.dur = dur
+	cd.remain = dur
+	cd.done = make(chan struct{})
+
+	return nil
+}
+
+// Running reports whether the countdown is running.
+func (cd *Countdown) Running() bool {
+	cd.mu.Lock()
+	defer cd.mu.Unlock()
+
+	return cd.running
+}
+
+// Duration returns the duration the countdown was set to.
+func (cd *Countdown) Duration() time.Duration {
+	cd.mu.Lock()
+	defer cd.mu.Unlock()
+
+	return cd.dur
+}
+
+// Remaining returns estimated remaining time.
+func (cd *Countdown) Remaining() time.Duration {
+	cd.mu.Lock()
+	defer cd.mu.Unlock()
+
+	return cd.remainLocked()
+}
+
+func (cd *Countdown) remainLocked() time.Duration {
+	if !cd.running {
+		return cd.remain
+	}
+
+	remain := cd.remain - time.Since(cd.startedAt)
+	if remain < 0 {
+		remain = 0
+	}
+	return remain
+}
+
+func (cd *Countdown) expire(gen int) {
+	cd.mu.Lock()
+	if cd.gen != gen {
+		// reset or stopped after the timer fired
+		cd.mu.Unlock()
+		return
+	}
+	f := cd.finishLocked()
+	cd.mu.Unlock()
+
+	if f != nil {
+		f()
+	}
+}
+
+// finishLocked marks the countdown reached zero and returns OnDone callback to call.
+func (cd *Countdown) finishLocked() func() {
+	cd.running = false
+	cd.remain = 0
+	close(cd.done)
+	return cd.onDone
+}
@@ -0,0 +1,114 @@
+package divoom
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCountdownRestart(t *testing.T) {
+	c, _ := newFakeClient()
+	cd, err := NewCountdown(c, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dones int32
+	cd.OnDone(func() { atomic.AddInt32(&dones, 1) })
+
+	// timer of the first start may fire while it's restarted.
+	// it must not finish the restarted one
+	if err := cd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	cd.mu.Lock()
+	stale := cd.gen
+	cd.mu.Unlock()
+	if err := cd.Reset(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	done := cd.Done()
+	if err := cd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	cd.expire(stale)
+
+	select {
+	case <-done:
+		t.Fatal("restarted countdown is done by timer of the first start")
+	default:
+	}
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("restarted countdown is not done")
+	}
+	time.Sleep(100 * time.Millisecond)
+	if n := atomic.LoadInt32(&dones); n != 1 {
+		t.Errorf("OnDone called %d times, want 1", n)
+	}
+	if cd.Running() || cd.Remaining() != 0 {
+		t.Errorf("running %v, remaining %v after done", cd.Running(), cd.Remaining())
+	}
+}
+
+func TestCountdownStopInLastSecond(t *testing.T) {
+	c, d := newFakeClient()
+	cd, err := NewCountdown(c, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := cd.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-cd.Done():
+	default:
+		t.Error("countdown stopped in its last second is not done")
+	}
+	if cmd := d.last(); cmd["Status"] != float64(0) || cmd["Second"] != float64(0) {
+		t.Errorf("sent %v", cmd)
+	}
+}
+
+func TestCountdownConcurrent(t *testing.T) {
+	c, _ := newFakeClient()
+	cd, err := NewCountdown(c, 2*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cd.OnDone(func() {})
+
+	// run with -race; closing done twice panics
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				switch (i + j) % 4 {
+				case 0:
+					cd.Start()
+				case 1:
+					cd.Stop()
+				case 2:
+					cd.Reset(time.Second)
+				case 3:
+					cd.Remaining()
+					cd.Done()
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if err := cd.Reset(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if cd.Running() || cd.Remaining() != time.Second {
+		t.Errorf("running %v, remaining %v after reset", cd.Running(), cd.Remaining())
+	}
+}
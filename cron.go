@@ -0,0 +1,117 @@
+package divoom
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSpec is a parsed 5 field cron expression; minute hour day month weekday.
+type cronSpec struct {
+	minute, hour, dom, month, dow uint64 // bit set of allowed values
+	domStar, dowStar              bool   // day or weekday field starts with *
+}
+
+var cronFieldRanges = [5][2]int{
+	{0, 59}, // minute
+	{0, 23}, // hour
+	{1, 31}, // day of month
+	{1, 12}, // month
+	{0, 6},  // day of week, 0 is Sunday
+}
+
+// parseCron parses cron expression like "*/15 22-23 * * 1-5".
+// Each field takes *, numbers, ranges (a-b), lists (a,b) and steps (/n)
+// of * or ranges, like */15 or 0-30/10. Step of a number, like 5/15, is not supported.
+// As in standard cron, when both day of month and day of week are restricted,
+// that is neither starts with *, a day matching either of them matches.
+func parseCron(expr string) (*cronSpec, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron %q should have 5 fields", expr)
+	}
+
+	var bits [5]uint64
+	for i, f := range fields {
+		b, err := parseCronField(f, cronFieldRanges[i][0], cronFieldRanges[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron %q: %v", expr, err)
+		}
+		bits[i] = b
+	}
+
+	return &cronSpec{
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		dow:    bits[4],
+
+		domStar: strings.HasPrefix(fields[2], "*"),
+		dowStar: strings.HasPrefix(fields[4], "*"),
+	}, nil
+}
+
+func parseCronField(f string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(f, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			s, err := strconv.Atoi(part[i+1:])
+			if err != nil || s < 1 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			if part[:i] != "*" && !strings.Contains(part[:i], "-") {
+				return 0, fmt.Errorf("step of %q needs * or range", part)
+			}
+			step = s
+			part = part[:i]
+		}
+
+		lo, hi := min, max
+		if part != "*" {
+			if i := strings.Index(part, "-"); i >= 0 {
+				var err error
+				lo, err = strconv.Atoi(part[:i])
+				if err != nil {
+					return 0, fmt.Errorf("invalid range %q", part)
+				}
+				hi, err = strconv.Atoi(part[i+1:])
+				if err != nil {
+					return 0, fmt.Errorf("invalid range %q", part)
+				}
+			} else {
+				v, err := strconv.Atoi(part)
+				if err != nil {
+					return 0, fmt.Errorf("invalid value %q", part)
+				}
+				lo, hi = v, v
+			}
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q is out of range %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// match reports whether t, in minute resolution, matches the spec.
+func (cs *cronSpec) match(t time.Time) bool {
+	if cs.minute&(1<<uint(t.Minute())) == 0 ||
+		cs.hour&(1<<uint(t.Hour())) == 0 ||
+		cs.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+
+	dom := cs.dom&(1<<uint(t.Day())) != 0
+	dow := cs.dow&(1<<uint(t.Weekday())) != 0
+	if cs.domStar || cs.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
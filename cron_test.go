@@ -0,0 +1,73 @@
+package divoom
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	// 2022-06-06 is Monday
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2022, 6, day, hour, min, 0, 0, time.UTC)
+	}
+
+	tcs := []struct {
+		expr  string
+		match []time.Time
+		miss  []time.Time
+	}{
+		{"* * * * *", []time.Time{at(6, 0, 0), at(30, 23, 59)}, nil},
+		{"*/15 22-23 * * 1-5", []time.Time{at(6, 22, 0), at(10, 23, 45)}, []time.Time{at(6, 22, 5), at(6, 21, 0), at(11, 22, 0)}},
+		{"0,30 7 * * *", []time.Time{at(6, 7, 0), at(6, 7, 30)}, []time.Time{at(6, 7, 15)}},
+		{"10-20/5 * * * *", []time.Time{at(6, 0, 10), at(6, 0, 15), at(6, 0, 20)}, []time.Time{at(6, 0, 25), at(6, 0, 12)}},
+		{"0 0 1 * *", []time.Time{at(1, 0, 0)}, []time.Time{at(2, 0, 0)}},
+		{"0 0 * 7 *", nil, []time.Time{at(1, 0, 0)}},
+		// day and weekday are ORed when both are restricted
+		{"0 0 1 * 1", []time.Time{at(1, 0, 0), at(6, 0, 0)}, []time.Time{at(7, 0, 0)}},
+		// but ANDed when either starts with *
+		{"0 0 */2 * 1", []time.Time{at(13, 0, 0)}, []time.Time{at(6, 0, 0), at(1, 0, 0)}},
+		{"0 0 1 * *", []time.Time{at(1, 0, 0)}, []time.Time{at(6, 0, 0)}},
+	}
+
+	for _, tc := range tcs {
+		cs, err := parseCron(tc.expr)
+		if err != nil {
+			t.Errorf("%q: %v", tc.expr, err)
+			continue
+		}
+		for _, m := range tc.match {
+			if !cs.match(m) {
+				t.Errorf("%q doesn't match %v", tc.expr, m)
+			}
+		}
+		for _, m := range tc.miss {
+			if cs.match(m) {
+				t.Errorf("%q matches %v", tc.expr, m)
+			}
+		}
+	}
+}
+
+func TestParseCronError(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 7",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"1-a * * * *",
+		// step of a number isn't supported
+		"5/15 * * * *",
+		"0,5/15 * * * *",
+	} {
+		if _, err := parseCron(expr); err == nil {
+			t.Errorf("no error for %q", expr)
+		}
+	}
+}
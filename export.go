@@ -0,0 +1,281 @@
+package divoom
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// AnimationGIF converts arguments of SendAnimation back to GIF.
+// Frames of more than 256 colors are dithered to Plan 9 palette;
+// use AnimationStrip to keep them exact.
+func AnimationGIF(width int, speedMSecs []int, picDatas [][]byte) (*gif.GIF, error) {
+	if len(speedMSecs) < len(picDatas) {
+		return nil, fmt.Errorf("fail to convert animation: %d speeds for %d frames", len(speedMSecs), len(picDatas))
+	}
+
+	g := &gif.GIF{}
+	for i, d := range picDatas {
+		img, err := rgb24BytesToImg(width, d)
+		if err != nil {
+			return nil, errors.Wrapf(err, "fail to convert animation frame %d", i)
+		}
+		g.Image = append(g.Image, toPaletted(img))
+		g.Delay = append(g.Delay, speedMSecs[i]/10)
+	}
+	return g, nil
+}
+
+// AnimationStrip converts frames of SendAnimation back to an image of
+// the frames in a row, from left to right.
+func AnimationStrip(width int, picDatas [][]byte) (*image.RGBA, error) {
+	strip := image.NewRGBA(image.Rect(0, 0, width*len(picDatas), width))
+	for i, d := range picDatas {
+		img, err := rgb24BytesToImg(width, d)
+		if err != nil {
+			return nil, errors.Wrapf(err, "fail to convert animation frame %d", i)
+		}
+		draw.Draw(strip, img.Rect.Add(image.Pt(i*width, 0)), img, image.Point{}, draw.Src)
+	}
+	return strip, nil
+}
+
+// rgb24BytesToImg is the reverse of imgToRGB24Bytes.
+func rgb24BytesToImg(width int, data []byte) (*image.RGBA, error) {
+	if width < 1 || len(data) != width*width*3 {
+		return nil, fmt.Errorf("want %d bytes of %dx%d frame but %d", width*width*3, width, width, len(data))
+	}
+	img := image.NewRGBA(image.Rect(0, 0, width, width))
+	for i, j := 0, 0; i < len(data); i, j = i+3, j+4 {
+		img.Pix[j] = data[i]
+		img.Pix[j+1] = data[i+1]
+		img.Pix[j+2] = data[i+2]
+		img.Pix[j+3] = 0xff
+	}
+	return img, nil
+}
+
+// toPaletted converts img to paletted image of its own colors if they fit.
+func toPaletted(img *image.RGBA) *image.Paletted {
+	var pal color.Palette
+	seen := make(map[color.RGBA]bool)
+	for i := 0; i < len(img.Pix) && len(pal) <= 256; i += 4 {
+		c := color.RGBA{img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3]}
+		if !seen[c] {
+			seen[c] = true
+			pal = append(pal, c)
+		}
+	}
+
+	if len(pal) > 256 {
+		p := image.NewPaletted(img.Rect, palette.Plan9)
+		draw.FloydSteinberg.Draw(p, img.Rect, img, image.Point{})
+		return p
+	}
+	p := image.NewPaletted(img.Rect, pal)
+	draw.Draw(p, img.Rect, img, image.Point{}, draw.Src)
+	return p
+}
+
+// AnimationExportMeta is metadata of an exported animation, saved next to it in JSON.
+type AnimationExportMeta struct {
+	Device     *Device   `json:"device"`
+	PicID      int       `json:"pic_id"`
+	Time       time.Time `json:"time"`
+	Width      int       `json:"width"`
+	SpeedMSecs []int     `json:"speed_msecs"`
+}
+
+// AnimationExporter saves every animation sent by Client to a directory.
+// Set it to Client with WithAnimationExporter.
+//
+// Each animation is saved, when all of its frames are sent successfully,
+// as DIR/IP_PICID_TIME.gif, or .png of AnimationStrip if Strip is set,
+// with metadata of AnimationExportMeta in DIR/IP_PICID_TIME.json.
+// Files are written in background not to delay the requests;
+// call Wait before exit to finish them.
+type AnimationExporter struct {
+	Dir   string
+	Strip bool // save PNG strip instead of GIF
+
+	// OnError is called when an animation is failed to be saved.
+	// It may be called from other goroutines.
+	OnError func(error)
+
+	mu      sync.Mutex
+	pending map[string]*exportUpload
+	saving  sync.WaitGroup
+}
+
+type exportUpload struct {
+	width      int
+	speedMSecs []int
+	picDatas   [][]byte
+	got        int
+}
+
+// NewAnimationExporter returns AnimationExporter saving animations to dir.
+func NewAnimationExporter(dir string) *AnimationExporter {
+	return &AnimationExporter{
+		Dir:     dir,
+		pending: make(map[string]*exportUpload),
+	}
+}
+
+// WithAnimationExporter makes Client save every animation it sends with e.
+func WithAnimationExporter(e *AnimationExporter) ClientOption {
+	return WithMiddleware(e.Middleware())
+}
+
+// Middleware returns Middleware which collects frames of Draw/SendHttpGif.
+func (e *AnimationExporter) Middleware() Middleware {
+	return func(next Handler) Handler {
+		return func(req *Request) (*Reply, error) {
+			reply, err := next(req)
+			if err == nil && reply.ErrorCode == 0 && req.Command == "Draw/SendHttpGif" {
+				e.collect(req)
+			}
+			return reply, err
+		}
+	}
+}
+
+func (e *AnimationExporter) collect(req *Request) {
+	picNum := intValue(req.Data["PicNum"])
+	width := intValue(req.Data["PicWidth"])
+	offset := intValue(req.Data["PicOffset"])
+	id := intValue(req.Data["PicID"])
+	if picNum < 1 || offset < 0 || offset >= picNum {
+		return
+	}
+	s, _ := req.Data["PicData"].(string)
+	picData, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		e.onError(errors.Wrap(err, "fail to export animation"))
+		return
+	}
+
+	key := fmt.Sprintf("%s_%d", req.Device.DevicePrivateIP, id)
+	e.mu.Lock()
+	u := e.pending[key]
+	if u == nil || offset == 0 || len(u.picDatas) != picNum || u.width != width {
+		u = &exportUpload{
+			width:      width,
+			speedMSecs: make([]int, picNum),
+			picDatas:   make([][]byte, picNum),
+		}
+		e.pending[key] = u
+	}
+	if u.picDatas[offset] == nil {
+		u.got++
+	}
+	u.picDatas[offset] = picData
+	u.speedMSecs[offset] = intValue(req.Data["PicSpeed"])
+	done := u.got == picNum
+	if done {
+		delete(e.pending, key)
+	}
+	e.mu.Unlock()
+
+	if !done {
+		return
+	}
+	meta := &AnimationExportMeta{
+		Device:     req.Device,
+		PicID:      id,
+		Time:       time.Now(),
+		Width:      u.width,
+		SpeedMSecs: u.speedMSecs,
+	}
+	e.saving.Add(1)
+	go func() {
+		defer e.saving.Done()
+		if err := e.save(meta, u.picDatas); err != nil {
+			e.onError(err)
+		}
+	}()
+}
+
+// Wait blocks until the animations being saved are written.
+func (e *AnimationExporter) Wait() {
+	e.saving.Wait()
+}
+
+func (e *AnimationExporter) save(meta *AnimationExportMeta, picDatas [][]byte) error {
+	name := fmt.Sprintf("%s_%d_%s", meta.Device.DevicePrivateIP, meta.PicID, meta.Time.Format("20060102T150405.000"))
+	name = filepath.Join(e.Dir, strings.NewReplacer(":", "-", "/", "-").Replace(name))
+
+	var err error
+	if e.Strip {
+		err = writeFile(name+".png", func(f *os.File) error {
+			strip, err := AnimationStrip(meta.Width, picDatas)
+			if err != nil {
+				return err
+			}
+			return png.Encode(f, strip)
+		})
+	} else {
+		err = writeFile(name+".gif", func(f *os.File) error {
+			g, err := AnimationGIF(meta.Width, meta.SpeedMSecs, picDatas)
+			if err != nil {
+				return err
+			}
+			return gif.EncodeAll(f, g)
+		})
+	}
+	if err != nil {
+		return errors.Wrap(err, "fail to export animation")
+	}
+
+	b, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "fail to export animation")
+	}
+	err = os.WriteFile(name+".json", b, 0644)
+	if err != nil {
+		return errors.Wrap(err, "fail to export animation")
+	}
+	return nil
+}
+
+func (e *AnimationExporter) onError(err error) {
+	if e.OnError != nil {
+		e.OnError(err)
+	}
+}
+
+func writeFile(path string, write func(f *os.File) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = write(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// intValue returns v of request data as int. It may be float64 if the data came from JSON.
+func intValue(v interface{}) int {
+	switch n := v.(type) {
+	case int:
+		return n
+	case float64:
+		return int(n)
+	}
+	return -1
+}
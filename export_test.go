@@ -0,0 +1,129 @@
+package divoom
+
+import (
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func solid(c color.Color) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	draw.Draw(img, img.Rect, image.NewUniform(c), image.Point{}, draw.Src)
+	return img
+}
+
+// exportedFiles returns paths of exported files in dir by their extension.
+func exportedFiles(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	ents, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string)
+	for _, ent := range ents {
+		if !strings.HasPrefix(ent.Name(), "pixoo_7_") {
+			t.Errorf("unexpected file name %s", ent.Name())
+		}
+		files[filepath.Ext(ent.Name())] = filepath.Join(dir, ent.Name())
+	}
+	return files
+}
+
+func TestAnimationExporter(t *testing.T) {
+	red, blue := color.RGBA{255, 0, 0, 255}, color.RGBA{0, 0, 255, 255}
+
+	for _, strip := range []bool{false, true} {
+		dir := t.TempDir()
+		e := NewAnimationExporter(dir)
+		e.Strip = strip
+		e.OnError = func(err error) { t.Error(err) }
+
+		dev := &fakeDevice{}
+		c := NewClient(&Device{DevicePrivateIP: "pixoo"},
+			WithHTTPClient(&http.Client{Transport: dev}), WithAnimationExporter(e))
+		err := c.SendAnimationImgs(7, []int{100, 200}, []image.Image{solid(red), solid(blue)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.Wait()
+
+		files := exportedFiles(t, dir)
+		if len(files) != 2 {
+			t.Fatalf("strip %v: exported %v, want image and json", strip, files)
+		}
+
+		b, err := os.ReadFile(files[".json"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		var meta AnimationExportMeta
+		if err := json.Unmarshal(b, &meta); err != nil {
+			t.Fatal(err)
+		}
+		if meta.PicID != 7 || meta.Width != 16 || !reflect.DeepEqual(meta.SpeedMSecs, []int{100, 200}) {
+			t.Errorf("strip %v: meta = %+v", strip, meta)
+		}
+
+		f, err := os.Open(files[".gif"] + files[".png"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		var frames []image.Image
+		if strip {
+			img, err := png.Decode(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if img.Bounds().Dx() != 32 || img.Bounds().Dy() != 16 {
+				t.Errorf("strip is %v, want 32x16", img.Bounds())
+			}
+			sub := img.(interface {
+				SubImage(image.Rectangle) image.Image
+			})
+			frames = []image.Image{sub.SubImage(image.Rect(0, 0, 16, 16)), sub.SubImage(image.Rect(16, 0, 32, 16))}
+		} else {
+			g, err := gif.DecodeAll(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(g.Delay, []int{10, 20}) {
+				t.Errorf("gif delays = %v, want [10 20]", g.Delay)
+			}
+			for _, p := range g.Image {
+				frames = append(frames, p)
+			}
+		}
+		f.Close()
+
+		for i, want := range []color.RGBA{red, blue} {
+			b := frames[i].Bounds()
+			if got := color.RGBAModel.Convert(frames[i].At(b.Min.X+3, b.Min.Y+5)); got != want {
+				t.Errorf("strip %v: frame %d is %v, want %v", strip, i, got, want)
+			}
+		}
+	}
+}
+
+func TestAnimationExporterFailed(t *testing.T) {
+	dir := t.TempDir()
+	e := NewAnimationExporter(dir)
+	c := replayClient(t, nil, WithAnimationExporter(e))
+
+	// no reply for the frames
+	if err := c.SendAnimationImgs(7, []int{100}, []image.Image{solid(color.White)}); err == nil {
+		t.Fatal("no error without device")
+	}
+	e.Wait()
+	if files := exportedFiles(t, dir); len(files) != 0 {
+		t.Errorf("exported %v of failed animation", files)
+	}
+}
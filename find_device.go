@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"sync"
 
 	"github.com/pkg/errors"
 )
@@ -18,6 +20,8 @@ type Device struct {
 	DeviceName      string `json:"DeviceName"`
 	DeviceID        int    `json:"DeviceId"`
 	DevicePrivateIP string `json:"DevicePrivateIP"`
+	DeviceMac       string `json:"DeviceMac"`
+	Hardware        int    `json:"Hardware"`
 }
 
 func FindDevice() ([]*Device, error) {
@@ -43,19 +47,75 @@ func FindDevice() ([]*Device, error) {
 type Client struct {
 	dev *Device
 	url string
+
+	hc          *http.Client
+	metrics     Metrics
+	middlewares []Middleware
+
+	mu       sync.Mutex
+	long     string // from WeatherAreaSetting
+	lat      string
+	timeZone string // from SetTimeZone
 }
 
-func NewClient(d *Device) *Client {
-	return &Client{
+// ClientOption configures Client in NewClient.
+type ClientOption func(*Client)
+
+func NewClient(d *Device, opts ...ClientOption) *Client {
+	c := &Client{
 		dev: d,
 		url: fmt.Sprintf("http://%s:80/post", d.DevicePrivateIP),
+		hc:  http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
+	return c
+}
+
+// WithHTTPClient makes Client use hc for requests to the device.
+func WithHTTPClient(hc *http.Client) ClientOption {
+	return func(c *Client) {
+		c.hc = hc
+	}
+}
+
+// Device returns the device of the client.
+func (c *Client) Device() *Device {
+	return c.dev
 }
 
 func (c *Client) do(data map[string]interface{}) (*http.Response, error) {
+	cmd, _ := data["Command"].(string)
+	req := &Request{
+		Device:  c.dev,
+		Command: cmd,
+		Data:    data,
+	}
+
+	h := c.send
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		h = c.middlewares[i](h)
+	}
+
+	reply, err := h(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// callers decode the reply from the body
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewReader(reply.Body)),
+	}, nil
+}
+
+// send posts req to the device. It is the end of the middleware chain.
+// Non-2xx status and reply which is not JSON are errors.
+func (c *Client) send(r *Request) (*Reply, error) {
 	var buf bytes.Buffer
 	jEnc := json.NewEncoder(&buf)
-	err := jEnc.Encode(&data)
+	err := jEnc.Encode(&r.Data)
 	if err != nil {
 		return nil, errors.Wrap(err, "fail to do")
 	}
@@ -64,5 +124,28 @@ func (c *Client) do(data map[string]interface{}) (*http.Response, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "fail to do")
 	}
-	return http.DefaultClient.Do(req)
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("fail to do %s: %s", r.Command, resp.Status)
+	}
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to do")
+	}
+
+	var ret errorCode
+	err = json.Unmarshal(b, &ret)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fail to do %s: invalid reply", r.Command)
+	}
+	return &Reply{
+		Body:      b,
+		ErrorCode: ret.ErrorCode,
+	}, nil
 }
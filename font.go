@@ -0,0 +1,150 @@
+package divoom
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrFontNotFound       = fmt.Errorf("font is not in font list")
+	ErrInvalidTextFont    = fmt.Errorf("font can't be used as text font 0~7")
+	ErrUnsupportedCharset = fmt.Errorf("string has characters not supported by font")
+)
+
+type getFontListResult struct {
+	ReturnCode    int     `json:"ReturnCode"`
+	ReturnMessage string  `json:"ReturnMessage"`
+	FontList      []*Font `json:"FontList"`
+}
+type Font struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Width   int    `json:"width,string"`
+	High    int    `json:"high,string"`
+	Charset string `json:"charset"`
+	Type    int    `json:"type"`
+}
+
+var (
+	fontListMu    sync.Mutex
+	fontListCache []*Font
+)
+
+// fontListURL is the API of font list. Tests replace it.
+var fontListURL = "https://app.divoom-gz.com/Device/GetTimeDialFontList"
+
+// GetFontList returns font list of the device.
+// The list is fetched once and cached. Use RefreshFontList to fetch it again.
+func GetFontList() ([]*Font, error) {
+	fontListMu.Lock()
+	defer fontListMu.Unlock()
+
+	if fontListCache != nil {
+		return fontListCache, nil
+	}
+
+	fl, err := getFontList()
+	if err != nil {
+		return nil, err
+	}
+	fontListCache = fl
+	return fl, nil
+}
+
+// RefreshFontList drops cached font list and fetches it again.
+func RefreshFontList() ([]*Font, error) {
+	fontListMu.Lock()
+	fontListCache = nil
+	fontListMu.Unlock()
+
+	return GetFontList()
+}
+
+func getFontList() ([]*Font, error) {
+	resp, err := http.Post(fontListURL, "", nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to get font list")
+	}
+	defer resp.Body.Close()
+
+	var ret getFontListResult
+	err = json.NewDecoder(resp.Body).Decode(&ret)
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to get font list")
+	}
+
+	if ret.ReturnCode != 0 {
+		return nil, fmt.Errorf("fail to get font list: %s", ret.ReturnMessage)
+	}
+
+	return ret.FontList, nil
+}
+
+// FontByID finds font of given id in font list.
+func FontByID(id int) (*Font, error) {
+	fl, err := GetFontList()
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to find font")
+	}
+
+	for _, f := range fl {
+		if f.ID == id {
+			return f, nil
+		}
+	}
+
+	return nil, ErrFontNotFound
+}
+
+// TextFont returns font value for SendText.
+// SendText only takes font 0~7, so other fonts return ErrInvalidTextFont.
+func (f *Font) TextFont() (TextFont, error) {
+	if f.ID < int(TextFont0) || f.ID > int(TextFont7) {
+		return TextFont0, ErrInvalidTextFont
+	}
+	return TextFont(f.ID), nil
+}
+
+// DisplayListFont returns font value for item of display list.
+// Display list takes font id of font list as is.
+func (f *Font) DisplayListFont() int {
+	return f.ID
+}
+
+// Supports reports whether all characters of str can be drawn with the font.
+// Empty charset means the font has English letters, Arabic figures and
+// punctuation, which is printable ASCII.
+func (f *Font) Supports(str string) bool {
+	for _, r := range str {
+		if f.Charset == "" {
+			if r < ' ' || r > '~' {
+				return false
+			}
+			continue
+		}
+		if !strings.ContainsRune(f.Charset, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckTextFont checks the font of given id exists and supports str.
+// Call it before SendText to avoid broken text on the screen.
+func CheckTextFont(fontID int, str string) error {
+	f, err := FontByID(fontID)
+	if err != nil {
+		return errors.Wrap(err, "fail to check text font")
+	}
+
+	if !f.Supports(str) {
+		return ErrUnsupportedCharset
+	}
+
+	return nil
+}
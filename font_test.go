@@ -0,0 +1,125 @@
+package divoom
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// fakeFontList serves recorded reply of testdata/font_list.json as the font list API
+// and drops cached font list. It returns count of the requests.
+func fakeFontList(t *testing.T) *int32 {
+	t.Helper()
+	reply, err := os.ReadFile("testdata/font_list.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reqs := new(int32)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(reqs, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(reply)
+	}))
+
+	oldURL := fontListURL
+	fontListURL = srv.URL
+	fontListCache = nil
+	t.Cleanup(func() {
+		srv.Close()
+		fontListURL = oldURL
+		fontListCache = nil
+	})
+	return reqs
+}
+
+func TestGetFontList(t *testing.T) {
+	reqs := fakeFontList(t)
+
+	fl, err := GetFontList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fl) != 3 {
+		t.Fatalf("got %d fonts, want 3", len(fl))
+	}
+	// width and high are strings in the reply
+	want := Font{ID: 4, Name: "16*16 Arabic figures", Width: 16, High: 16, Charset: "0123456789:", Type: 1}
+	if *fl[1] != want {
+		t.Errorf("font = %+v, want %+v", *fl[1], want)
+	}
+
+	if _, err := GetFontList(); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(reqs); n != 1 {
+		t.Errorf("font list is fetched %d times, want cached", n)
+	}
+	if _, err := RefreshFontList(); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(reqs); n != 2 {
+		t.Errorf("font list is fetched %d times after refresh, want 2", n)
+	}
+}
+
+func TestFontSupports(t *testing.T) {
+	ascii := &Font{ID: 2}
+	digits := &Font{ID: 4, Charset: "0123456789:"}
+	tcs := []struct {
+		f    *Font
+		str  string
+		want bool
+	}{
+		{ascii, "", true},
+		{ascii, "Hello, World! ~", true},
+		{ascii, "tab\t", false},
+		{ascii, "café", false},
+		{digits, "12:34", true},
+		{digits, "12:34 PM", false},
+	}
+	for _, tc := range tcs {
+		if got := tc.f.Supports(tc.str); got != tc.want {
+			t.Errorf("font %d supports %q = %v, want %v", tc.f.ID, tc.str, got, tc.want)
+		}
+	}
+}
+
+func TestCheckTextFont(t *testing.T) {
+	fakeFontList(t)
+
+	tcs := []struct {
+		id      int
+		str     string
+		wantErr error
+	}{
+		{2, "hello", nil},
+		{4, "12:34", nil},
+		{4, "hello", ErrUnsupportedCharset},
+		{99, "hello", ErrFontNotFound},
+	}
+	for _, tc := range tcs {
+		err := CheckTextFont(tc.id, tc.str)
+		if errors.Cause(err) != tc.wantErr {
+			t.Errorf("font %d, %q: error = %v, want %v", tc.id, tc.str, err, tc.wantErr)
+		}
+	}
+
+	f, err := FontByID(52)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.TextFont(); err != ErrInvalidTextFont {
+		t.Errorf("text font of font 52: error = %v, want ErrInvalidTextFont", err)
+	}
+	f, err = FontByID(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tf, err := f.TextFont(); err != nil || tf != TextFont4 {
+		t.Errorf("text font of font 4 = %v, %v", tf, err)
+	}
+}
@@ -0,0 +1,237 @@
+package divoom
+
+import (
+	"fmt"
+	"image"
+	"image/gif"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Group is a set of clients which are controlled together, like a wall of Pixoos.
+type Group struct {
+	clients []*Client
+}
+
+// NewGroup returns Group of clients.
+func NewGroup(clients ...*Client) *Group {
+	return &Group{clients: clients}
+}
+
+// Clients returns the clients of the group.
+func (g *Group) Clients() []*Client {
+	return append([]*Client(nil), g.clients...)
+}
+
+// Do calls f with every client of the group concurrently and waits for them.
+// It returns GroupError holding errors of the failed devices, or nil if all succeed.
+func (g *Group) Do(f func(c *Client) error) error {
+	return g.do(func(i int, c *Client) error {
+		return f(c)
+	})
+}
+
+func (g *Group) do(f func(i int, c *Client) error) error {
+	errs := make([]error, len(g.clients))
+
+	var wg sync.WaitGroup
+	for i, c := range g.clients {
+		wg.Add(1)
+		go func(i int, c *Client) {
+			defer wg.Done()
+			errs[i] = f(i, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	var ge GroupError
+	for i, err := range errs {
+		if err != nil {
+			ge = append(ge, &MemberError{Device: g.clients[i].dev, Err: err})
+		}
+	}
+	if len(ge) == 0 {
+		return nil
+	}
+	return ge
+}
+
+// MemberError is error of a device in Group.
+type MemberError struct {
+	Device *Device
+	Err    error
+}
+
+func (e *MemberError) Error() string {
+	name := e.Device.DeviceName
+	if name == "" {
+		name = e.Device.DevicePrivateIP
+	}
+	return fmt.Sprintf("%s: %v", name, e.Err)
+}
+
+func (e *MemberError) Unwrap() error {
+	return e.Err
+}
+
+// GroupError is errors of the devices which failed in an operation of Group.
+type GroupError []*MemberError
+
+func (e GroupError) Error() string {
+	msgs := make([]string, len(e))
+	for i, me := range e {
+		msgs[i] = me.Error()
+	}
+	return fmt.Sprintf("%d device(s) failed: %s", len(e), strings.Join(msgs, "; "))
+}
+
+func (e GroupError) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, me := range e {
+		errs[i] = me
+	}
+	return errs
+}
+
+// SetBrightness sets brightness of all devices.
+func (g *Group) SetBrightness(brightness int) error {
+	return g.Do(func(c *Client) error {
+		return c.SetBrightness(brightness)
+	})
+}
+
+// ScreenSwitch turns screen of all devices on or off.
+func (g *Group) ScreenSwitch(on bool) error {
+	return g.Do(func(c *Client) error {
+		return c.ScreenSwitch(on)
+	})
+}
+
+// SelectChannel selects channel of all devices.
+func (g *Group) SelectChannel(idx Channel) error {
+	return g.Do(func(c *Client) error {
+		return c.SelectChannel(idx)
+	})
+}
+
+// SelectFacesChannel selects clock face of all devices.
+func (g *Group) SelectFacesChannel(id int) error {
+	return g.Do(func(c *Client) error {
+		return c.SelectFacesChannel(id)
+	})
+}
+
+// ResetSendingAnimationPicID resets animation PicID of all devices.
+func (g *Group) ResetSendingAnimationPicID() error {
+	return g.Do(func(c *Client) error {
+		return c.ResetSendingAnimationPicID()
+	})
+}
+
+// SendAnimationImgs sends the animation to all devices.
+// Each device starts to play it as soon as its upload is done;
+// use SyncSendAnimationImgs to start them together.
+func (g *Group) SendAnimationImgs(id int, speedMSecs []int, imgs []image.Image) error {
+	return g.Do(func(c *Client) error {
+		return c.SendAnimationImgs(id, speedMSecs, imgs)
+	})
+}
+
+// SendAnimationGif sends the gif animation to all devices.
+func (g *Group) SendAnimationGif(id int, gifImg *gif.GIF) error {
+	return g.Do(func(c *Client) error {
+		return c.SendAnimationGif(id, gifImg)
+	})
+}
+
+// Animation is frames of an animation with duration of each frame.
+type Animation struct {
+	SpeedMSecs []int
+	Imgs       []image.Image
+}
+
+// SyncSendAnimationImgs sends the animation to all devices and
+// starts playing it on them at the same time. See SyncSendAnimations.
+func (g *Group) SyncSendAnimationImgs(id int, speedMSecs []int, imgs []image.Image) error {
+	anims := make([]Animation, len(g.clients))
+	for i := range anims {
+		anims[i] = Animation{SpeedMSecs: speedMSecs, Imgs: imgs}
+	}
+	return g.SyncSendAnimations(id, anims)
+}
+
+// SyncSendAnimations sends anims[i] to i-th client of the group and
+// starts playing them at as close to the same instant as possible.
+//
+// A device plays an animation when it gets the last frame. So all frames but
+// the last are uploaded to every device first, and then the last frames are
+// sent to all devices at once. If upload to any device fails,
+// no device switches to the new animation.
+func (g *Group) SyncSendAnimations(id int, anims []Animation) error {
+	if len(anims) != len(g.clients) {
+		return fmt.Errorf("want %d animations for the group but got %d", len(g.clients), len(anims))
+	}
+
+	widths := make([]int, len(anims))
+	picDatas := make([][][]byte, len(anims))
+	for i, a := range anims {
+		if len(a.Imgs) < 1 {
+			return fmt.Errorf("want more than one image")
+		}
+		if len(a.Imgs) > 60 {
+			return ErrInvalidPicNum
+		}
+		if len(a.SpeedMSecs) != len(a.Imgs) {
+			return fmt.Errorf("want %d speeds but got %d", len(a.Imgs), len(a.SpeedMSecs))
+		}
+		w, h := a.Imgs[0].Bounds().Dx(), a.Imgs[0].Bounds().Dy()
+		if w != h {
+			return fmt.Errorf("want rectangle image")
+		}
+		if w != 64 && w != 32 && w != 16 {
+			return ErrInvalidPicWidth
+		}
+
+		widths[i] = w
+		picDatas[i] = make([][]byte, len(a.Imgs))
+		for j := range a.Imgs {
+			picDatas[i][j] = imgToRGB24Bytes(a.Imgs[j])
+		}
+	}
+
+	// upload all but the last frames
+	err := g.do(func(i int, c *Client) error {
+		picNum := len(picDatas[i])
+		for offset := 0; offset < picNum-1; offset++ {
+			err := c.sendAnimationFrame(widths[i], id, picNum, offset, anims[i].SpeedMSecs[offset], picDatas[i][offset])
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "fail to upload animations")
+	}
+
+	// then send the last frames together
+	start := make(chan struct{})
+	var ready sync.WaitGroup
+	ready.Add(len(g.clients))
+	go func() {
+		ready.Wait()
+		close(start)
+	}()
+	err = g.do(func(i int, c *Client) error {
+		ready.Done()
+		<-start
+		last := len(picDatas[i]) - 1
+		return c.sendAnimationFrame(widths[i], id, last+1, last, anims[i].SpeedMSecs[last], picDatas[i][last])
+	})
+	if err != nil {
+		return errors.Wrap(err, "fail to start animations")
+	}
+	return nil
+}
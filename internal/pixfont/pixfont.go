@@ -0,0 +1,126 @@
+// Package pixfont draws text with 3x5 pixel font, readable on 16~64 pixel panels.
+package pixfont
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"unicode"
+)
+
+const (
+	// GlyphWidth and GlyphHeight are size of a glyph in scale 1.
+	GlyphWidth  = 3
+	GlyphHeight = 5
+	// Advance is width of a glyph including the space after it.
+	Advance = GlyphWidth + 1
+)
+
+// Measure returns size of s drawn in scale.
+func Measure(s string, scale int) image.Point {
+	n := len([]rune(s))
+	if n == 0 {
+		return image.Point{}
+	}
+	return image.Pt((n*Advance-1)*scale, GlyphHeight*scale)
+}
+
+// Draw draws s to dst with top left at p. Each dot of glyph is scale×scale pixels.
+// Lower case letters are drawn as upper case and unknown ones as '?'.
+func Draw(dst draw.Image, p image.Point, s string, c color.Color, scale int) {
+	if scale < 1 {
+		scale = 1
+	}
+	src := image.NewUniform(c)
+
+	x := p.X
+	for _, r := range s {
+		g, ok := glyphs[unicode.ToUpper(r)]
+		if !ok {
+			g = glyphs['?']
+		}
+		for gy, row := range g {
+			for gx, dot := range row {
+				if dot != '#' {
+					continue
+				}
+				rect := image.Rect(x+gx*scale, p.Y+gy*scale, x+(gx+1)*scale, p.Y+(gy+1)*scale)
+				draw.Draw(dst, rect, src, image.Point{}, draw.Over)
+			}
+		}
+		x += Advance * scale
+	}
+}
+
+var glyphs = map[rune][GlyphHeight]string{
+	' ':  {"...", "...", "...", "...", "..."},
+	'!':  {".#.", ".#.", ".#.", "...", ".#."},
+	'"':  {"#.#", "#.#", "...", "...", "..."},
+	'#':  {"#.#", "###", "#.#", "###", "#.#"},
+	'$':  {".##", "##.", ".#.", ".##", "##."},
+	'%':  {"#.#", "..#", ".#.", "#..", "#.#"},
+	'&':  {".#.", "#.#", ".#.", "#.#", ".##"},
+	'\'': {".#.", ".#.", "...", "...", "..."},
+	'(':  {"..#", ".#.", ".#.", ".#.", "..#"},
+	')':  {"#..", ".#.", ".#.", ".#.", "#.."},
+	'*':  {"...", "#.#", ".#.", "#.#", "..."},
+	'+':  {"...", ".#.", "###", ".#.", "..."},
+	',':  {"...", "...", "...", ".#.", "#.."},
+	'-':  {"...", "...", "###", "...", "..."},
+	'.':  {"...", "...", "...", "...", ".#."},
+	'/':  {"..#", "..#", ".#.", "#..", "#.."},
+	'0':  {"###", "#.#", "#.#", "#.#", "###"},
+	'1':  {".#.", "##.", ".#.", ".#.", "###"},
+	'2':  {"###", "..#", "###", "#..", "###"},
+	'3':  {"###", "..#", "###", "..#", "###"},
+	'4':  {"#.#", "#.#", "###", "..#", "..#"},
+	'5':  {"###", "#..", "###", "..#", "###"},
+	'6':  {"###", "#..", "###", "#.#", "###"},
+	'7':  {"###", "..#", "..#", ".#.", ".#."},
+	'8':  {"###", "#.#", "###", "#.#", "###"},
+	'9':  {"###", "#.#", "###", "..#", "###"},
+	':':  {"...", ".#.", "...", ".#.", "..."},
+	';':  {"...", ".#.", "...", ".#.", "#.."},
+	'<':  {"..#", ".#.", "#..", ".#.", "..#"},
+	'=':  {"...", "###", "...", "###", "..."},
+	'>':  {"#..", ".#.", "..#", ".#.", "#.."},
+	'?':  {"###", "..#", ".#.", "...", ".#."},
+	'@':  {"###", "#.#", "###", "#..", "###"},
+	'A':  {".#.", "#.#", "###", "#.#", "#.#"},
+	'B':  {"##.", "#.#", "##.", "#.#", "##."},
+	'C':  {".##", "#..", "#..", "#..", ".##"},
+	'D':  {"##.", "#.#", "#.#", "#.#", "##."},
+	'E':  {"###", "#..", "##.", "#..", "###"},
+	'F':  {"###", "#..", "##.", "#..", "#.."},
+	'G':  {".##", "#..", "#.#", "#.#", ".##"},
+	'H':  {"#.#", "#.#", "###", "#.#", "#.#"},
+	'I':  {"###", ".#.", ".#.", ".#.", "###"},
+	'J':  {"..#", "..#", "..#", "#.#", ".#."},
+	'K':  {"#.#", "#.#", "##.", "#.#", "#.#"},
+	'L':  {"#..", "#..", "#..", "#..", "###"},
+	'M':  {"#.#", "###", "###", "#.#", "#.#"},
+	'N':  {"##.", "#.#", "#.#", "#.#", "#.#"},
+	'O':  {".#.", "#.#", "#.#", "#.#", ".#."},
+	'P':  {"##.", "#.#", "##.", "#..", "#.."},
+	'Q':  {".#.", "#.#", "#.#", "##.", ".##"},
+	'R':  {"##.", "#.#", "##.", "#.#", "#.#"},
+	'S':  {".##", "#..", ".#.", "..#", "##."},
+	'T':  {"###", ".#.", ".#.", ".#.", ".#."},
+	'U':  {"#.#", "#.#", "#.#", "#.#", "###"},
+	'V':  {"#.#", "#.#", "#.#", "#.#", ".#."},
+	'W':  {"#.#", "#.#", "###", "###", "#.#"},
+	'X':  {"#.#", "#.#", ".#.", "#.#", "#.#"},
+	'Y':  {"#.#", "#.#", ".#.", ".#.", ".#."},
+	'Z':  {"###", "..#", ".#.", "#..", "###"},
+	'[':  {".##", ".#.", ".#.", ".#.", ".##"},
+	'\\': {"#..", "#..", ".#.", "..#", "..#"},
+	']':  {"##.", ".#.", ".#.", ".#.", "##."},
+	'^':  {".#.", "#.#", "...", "...", "..."},
+	'_':  {"...", "...", "...", "...", "###"},
+	'`':  {"#..", ".#.", "...", "...", "..."},
+	'{':  {".##", ".#.", "##.", ".#.", ".##"},
+	'|':  {".#.", ".#.", ".#.", ".#.", ".#."},
+	'}':  {"##.", ".#.", ".##", ".#.", "##."},
+	'~':  {"...", ".##", "##.", "...", "..."},
+	'°':  {".#.", "#.#", ".#.", "...", "..."},
+}
@@ -0,0 +1,75 @@
+package pixfont
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestMeasure(t *testing.T) {
+	tcs := []struct {
+		s     string
+		scale int
+		want  image.Point
+	}{
+		{"", 1, image.Point{}},
+		{"A", 1, image.Pt(3, 5)},
+		{"AB", 2, image.Pt(14, 10)},
+		{"°C", 1, image.Pt(7, 5)}, // counted in runes, not bytes
+	}
+	for _, tc := range tcs {
+		if got := Measure(tc.s, tc.scale); got != tc.want {
+			t.Errorf("Measure(%q, %d) = %v, want %v", tc.s, tc.scale, got, tc.want)
+		}
+	}
+}
+
+// dots returns glyph rows of img drawn in scale from p.
+func dots(img *image.Gray, p image.Point, scale int) [GlyphHeight]string {
+	var rows [GlyphHeight]string
+	for gy := range rows {
+		for gx := 0; gx < GlyphWidth; gx++ {
+			// every pixel of the dot should be the same
+			c := img.GrayAt(p.X+gx*scale, p.Y+gy*scale).Y
+			for y := 0; y < scale; y++ {
+				for x := 0; x < scale; x++ {
+					if img.GrayAt(p.X+gx*scale+x, p.Y+gy*scale+y).Y != c {
+						c = 0x80
+					}
+				}
+			}
+			switch c {
+			case 0:
+				rows[gy] += "."
+			case 0xff:
+				rows[gy] += "#"
+			default:
+				rows[gy] += "?"
+			}
+		}
+	}
+	return rows
+}
+
+func TestDraw(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 32, 16))
+	// lower case is upper case, unknown is ?
+	Draw(img, image.Pt(1, 2), "a€", color.White, 2)
+
+	if got, want := dots(img, image.Pt(1, 2), 2), glyphs['A']; got != want {
+		t.Errorf("a is drawn as %q, want %q", got, want)
+	}
+	if got, want := dots(img, image.Pt(1+Advance*2, 2), 2), glyphs['?']; got != want {
+		t.Errorf("€ is drawn as %q, want %q", got, want)
+	}
+
+	// nothing out of the measured box
+	box := image.Rectangle{Max: Measure("a€", 2)}.Add(image.Pt(1, 2))
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 32; x++ {
+			if !image.Pt(x, y).In(box) && img.GrayAt(x, y).Y != 0 {
+				t.Fatalf("pixel at %d,%d is out of %v", x, y, box)
+			}
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package divoom
+
+import "time"
+
+// Metrics observes requests of Client. Set it with WithMetrics.
+type Metrics interface {
+	// ObserveCommand is called after each command to the device with its latency.
+	// errorCode is error_code of the reply, or -1 if the device didn't reply.
+	ObserveCommand(dev *Device, command string, latency time.Duration, errorCode int)
+	// ObserveAnimationFrames is called with number of animation frames sent.
+	ObserveAnimationFrames(dev *Device, n int)
+}
+
+// WithMetrics makes Client report its requests to m.
+func WithMetrics(m Metrics) ClientOption {
+	return func(c *Client) {
+		c.metrics = m
+		c.middlewares = append(c.middlewares, metricsMiddleware(m))
+	}
+}
+
+func metricsMiddleware(m Metrics) Middleware {
+	return func(next Handler) Handler {
+		return func(req *Request) (*Reply, error) {
+			start := time.Now()
+			reply, err := next(req)
+			if err != nil {
+				m.ObserveCommand(req.Device, req.Command, time.Since(start), -1)
+				return nil, err
+			}
+			m.ObserveCommand(req.Device, req.Command, time.Since(start), reply.ErrorCode)
+			return reply, nil
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package divoom
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Request is a command to the device.
+type Request struct {
+	Device  *Device
+	Command string                 // like "Channel/SetBrightness"
+	Data    map[string]interface{} // JSON body, including Command
+}
+
+// Reply is reply of the device.
+type Reply struct {
+	Body      []byte // JSON body
+	ErrorCode int    // error_code in the body
+}
+
+// Handler sends a request to the device.
+type Handler func(req *Request) (*Reply, error)
+
+// Middleware wraps Handler to do something around requests, like logging.
+type Middleware func(next Handler) Handler
+
+// WithMiddleware adds middlewares to request path of Client.
+// The first one is the outermost.
+func WithMiddleware(mws ...Middleware) ClientOption {
+	return func(c *Client) {
+		c.middlewares = append(c.middlewares, mws...)
+	}
+}
+
+// WithLogger logs every request of Client to l.
+func WithLogger(l *slog.Logger) ClientOption {
+	return WithMiddleware(LoggingMiddleware(l))
+}
+
+// LoggingMiddleware logs command, payload, latency and error_code of requests.
+// Successful requests are logged in debug level and failed ones in warn or error.
+// PicData of animation is logged by its length only.
+func LoggingMiddleware(l *slog.Logger) Middleware {
+	return func(next Handler) Handler {
+		return func(req *Request) (*Reply, error) {
+			start := time.Now()
+			reply, err := next(req)
+
+			attrs := []any{
+				slog.String("device", req.Device.DevicePrivateIP),
+				slog.String("command", req.Command),
+				slog.Any("payload", logPayload(req.Data)),
+				slog.Duration("latency", time.Since(start)),
+			}
+			switch {
+			case err != nil:
+				l.Error("divoom request failed", append(attrs, slog.Any("error", err))...)
+			case reply.ErrorCode != 0:
+				l.Warn("divoom request failed", append(attrs, slog.Int("error_code", reply.ErrorCode))...)
+			default:
+				l.Debug("divoom request", append(attrs, slog.Int("error_code", reply.ErrorCode))...)
+			}
+
+			return reply, err
+		}
+	}
+}
+
+// logPayload returns data without Command and with large values shortened.
+func logPayload(data map[string]interface{}) map[string]interface{} {
+	p := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		switch k {
+		case "Command":
+			continue
+		case "PicData":
+			if s, ok := v.(string); ok {
+				v = fmt.Sprintf("%d bytes", len(s))
+			}
+		}
+		p[k] = v
+	}
+	return p
+}
+
+// Tracer starts span of a request, like Tracer of OpenTelemetry.
+type Tracer interface {
+	StartSpan(req *Request) Span
+}
+
+// Span is a traced request.
+//
+// Adapter for OpenTelemetry can be like:
+//
+//	type otelSpan struct{ trace.Span }
+//
+//	func (s otelSpan) SetAttribute(k string, v interface{}) {
+//		s.Span.SetAttributes(attribute.String(k, fmt.Sprint(v)))
+//	}
+//	func (s otelSpan) RecordError(err error) {
+//		s.Span.RecordError(err)
+//		s.Span.SetStatus(codes.Error, err.Error())
+//	}
+//	func (s otelSpan) End() { s.Span.End() }
+type Span interface {
+	SetAttribute(key string, value interface{})
+	RecordError(err error)
+	End()
+}
+
+// WithTracer traces every request of Client with t.
+func WithTracer(t Tracer) ClientOption {
+	return WithMiddleware(TracingMiddleware(t))
+}
+
+// TracingMiddleware makes a span for each request with attributes of
+// device, command and error_code.
+func TracingMiddleware(t Tracer) Middleware {
+	return func(next Handler) Handler {
+		return func(req *Request) (*Reply, error) {
+			span := t.StartSpan(req)
+			defer span.End()
+
+			span.SetAttribute("divoom.device", req.Device.DevicePrivateIP)
+			span.SetAttribute("divoom.command", req.Command)
+
+			reply, err := next(req)
+			if err != nil {
+				span.RecordError(err)
+				return nil, err
+			}
+
+			span.SetAttribute("divoom.error_code", reply.ErrorCode)
+			if reply.ErrorCode != 0 {
+				span.RecordError(&DeviceError{Command: req.Command, Code: reply.ErrorCode})
+			}
+			return reply, nil
+		}
+	}
+}
+
+// DeviceError is error_code replied by the device.
+type DeviceError struct {
+	Command string
+	Code    int
+}
+
+func (e *DeviceError) Error() string {
+	return fmt.Sprintf("%s failed with error_code %d", e.Command, e.Code)
+}
@@ -0,0 +1,138 @@
+package divoom
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/suapapa/go_divoom/cassette"
+)
+
+func replayClient(t *testing.T, its []*cassette.Interaction, opts ...ClientOption) *Client {
+	t.Helper()
+	c := &cassette.Cassette{Interactions: its}
+	opts = append([]ClientOption{WithHTTPClient(&http.Client{Transport: c.Replayer()})}, opts...)
+	return NewClient(&Device{DevicePrivateIP: "pixoo"}, opts...)
+}
+
+func brightnessReply(status int, resp string) *cassette.Interaction {
+	return &cassette.Interaction{
+		Command:  "Channel/SetBrightness",
+		Status:   status,
+		Response: json.RawMessage(resp),
+	}
+}
+
+func TestSendError(t *testing.T) {
+	tcs := []struct {
+		name    string
+		it      *cassette.Interaction
+		wantErr string
+	}{
+		{"ok", brightnessReply(200, `{"error_code": 0}`), ""},
+		{"error code", brightnessReply(200, `{"error_code": 1}`), "fail to set brightness: 1"},
+		{"status", brightnessReply(500, `{"error_code": 0}`), "500 Internal Server Error"},
+		{"invalid json", brightnessReply(200, `"<html>busy</html>"`), "invalid reply"},
+	}
+	for _, tc := range tcs {
+		c := replayClient(t, []*cassette.Interaction{tc.it})
+		err := c.SetBrightness(50)
+		switch {
+		case tc.wantErr == "" && err != nil:
+			t.Errorf("%s: %v", tc.name, err)
+		case tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)):
+			t.Errorf("%s: error = %v, want %q", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c := replayClient(t, []*cassette.Interaction{
+		brightnessReply(200, `{"error_code": 0}`),
+		brightnessReply(200, `{"error_code": 1}`),
+		brightnessReply(503, `{"error_code": 0}`),
+		{Command: "Draw/SendHttpGif", Response: json.RawMessage(`{"error_code": 0}`)},
+	}, WithLogger(l))
+
+	c.SetBrightness(50)
+	c.SetBrightness(50)
+	c.SetBrightness(50)
+	c.sendAnimationFrame(16, 1, 1, 0, 100, make([]byte, 16*16*3))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("%d lines logged, want 4:\n%s", len(lines), buf.String())
+	}
+	wants := [][]string{
+		{"level=DEBUG", "command=Channel/SetBrightness", "payload=map[Brightness:50]", "error_code=0"},
+		{"level=WARN", "error_code=1"},
+		{"level=ERROR", "503 Service Unavailable"},
+		{"level=DEBUG", "PicData:1024 bytes"},
+	}
+	for i, want := range wants {
+		for _, w := range want {
+			if !strings.Contains(lines[i], w) {
+				t.Errorf("line %d doesn't have %q: %s", i, w, lines[i])
+			}
+		}
+	}
+}
+
+type fakeSpan struct {
+	attrs map[string]interface{}
+	errs  []error
+	ended bool
+}
+
+func (s *fakeSpan) SetAttribute(k string, v interface{}) { s.attrs[k] = v }
+func (s *fakeSpan) RecordError(err error)                { s.errs = append(s.errs, err) }
+func (s *fakeSpan) End()                                 { s.ended = true }
+
+type fakeTracer struct{ spans []*fakeSpan }
+
+func (t *fakeTracer) StartSpan(req *Request) Span {
+	s := &fakeSpan{attrs: make(map[string]interface{})}
+	t.spans = append(t.spans, s)
+	return s
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	tr := &fakeTracer{}
+	c := replayClient(t, []*cassette.Interaction{
+		brightnessReply(200, `{"error_code": 0}`),
+		brightnessReply(200, `{"error_code": 1}`),
+		brightnessReply(500, `{"error_code": 0}`),
+	}, WithTracer(tr))
+
+	c.SetBrightness(50)
+	c.SetBrightness(50)
+	c.SetBrightness(50)
+
+	if len(tr.spans) != 3 {
+		t.Fatalf("%d spans, want 3", len(tr.spans))
+	}
+	for i, s := range tr.spans {
+		if !s.ended {
+			t.Errorf("span %d is not ended", i)
+		}
+		if s.attrs["divoom.command"] != "Channel/SetBrightness" || s.attrs["divoom.device"] != "pixoo" {
+			t.Errorf("span %d attributes = %v", i, s.attrs)
+		}
+	}
+	if len(tr.spans[0].errs) != 0 || tr.spans[0].attrs["divoom.error_code"] != 0 {
+		t.Errorf("span 0 = %+v, want no error", tr.spans[0])
+	}
+	if errs := tr.spans[1].errs; len(errs) != 1 {
+		t.Errorf("span 1 errors = %v, want DeviceError of 1", errs)
+	} else if de, ok := errs[0].(*DeviceError); !ok || de.Code != 1 {
+		t.Errorf("span 1 errors = %v, want DeviceError of 1", errs)
+	}
+	if len(tr.spans[2].errs) != 1 {
+		t.Errorf("span 2 errors = %v, want status error", tr.spans[2].errs)
+	}
+}
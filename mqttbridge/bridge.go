@@ -0,0 +1,528 @@
+// Package mqttbridge bridges Divoom devices to MQTT, with Home Assistant MQTT discovery.
+//
+// For each device of name, it subscribes command topics
+//
+//	<prefix>/<name>/light/set        ON or OFF
+//	<prefix>/<name>/brightness/set   0~100
+//	<prefix>/<name>/channel/set      faces, cloud, visualizer or custom
+//	<prefix>/<name>/face/set         name of the face in the options
+//	<prefix>/<name>/text/set         text to draw
+//	<prefix>/<name>/image/set        url of gif or image
+//
+// and publishes retained state to the same topics with /state instead of /set,
+// all settings as JSON to <prefix>/<name>/settings and online or offline to
+// <prefix>/<name>/availability. The bridge itself is online or offline in
+// <prefix>/bridge/availability, which should be the will of the mqtt client:
+//
+//	opts.SetWill(mqttbridge.AvailabilityTopic(prefix), "offline", 1, true)
+//	opts.SetOnConnectHandler(func(mc mqtt.Client) { b.OnConnect(mc) })
+//
+// Commands to a device are sent in the order they are received, one at a time.
+//
+// A device appears in Home Assistant as a light with brightness,
+// select entities for channel and face and a text entity.
+package mqttbridge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	_ "image/jpeg"
+	_ "image/png"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/pkg/errors"
+	divoom "github.com/suapapa/go_divoom"
+)
+
+type Config struct {
+	// Prefix of the topics. Default is "divoom".
+	Prefix string
+	// DiscoveryPrefix of Home Assistant. Default is "homeassistant".
+	DiscoveryPrefix string
+	// PollInterval is how often state of the devices are published. Default is 30 seconds.
+	PollInterval time.Duration
+	// Faces are options of the face select, name to ClockId.
+	// If nil, faces in the first page of each dial type are used.
+	Faces map[string]int
+	// OnError is called with errors while running. Errors are ignored if nil.
+	OnError func(error)
+	// ClientOptions are options of clients of the devices.
+	ClientOptions []divoom.ClientOption
+}
+
+// maxImageSize is the biggest image image/set downloads.
+const maxImageSize = 10 << 20
+
+// maxPending is the most commands waiting for a device. More are dropped.
+const maxPending = 32
+
+// imageClient downloads images of image/set.
+var imageClient = &http.Client{Timeout: 30 * time.Second}
+
+type Bridge struct {
+	mc  mqtt.Client
+	cfg Config
+
+	mu      sync.Mutex
+	devices map[string]*device
+	faces   map[string]int
+}
+
+type device struct {
+	name  string
+	id    int
+	model string
+	c     *divoom.Client
+
+	mu      sync.Mutex
+	pending []func()
+	working bool
+}
+
+// enqueue runs f after commands received before, one at a time,
+// so the device gets the commands in order. It doesn't block.
+func (d *device) enqueue(f func()) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.pending) >= maxPending {
+		return false
+	}
+	d.pending = append(d.pending, f)
+	if !d.working {
+		d.working = true
+		go d.work()
+	}
+	return true
+}
+
+// work runs pending commands until none is left.
+func (d *device) work() {
+	for {
+		d.mu.Lock()
+		if len(d.pending) == 0 {
+			d.working = false
+			d.mu.Unlock()
+			return
+		}
+		f := d.pending[0]
+		d.pending = d.pending[1:]
+		d.mu.Unlock()
+
+		f()
+	}
+}
+
+var channelNames = map[divoom.Channel]string{
+	divoom.ChannelFaces:      "faces",
+	divoom.ChannelCloud:      "cloud",
+	divoom.ChannelVisualizer: "visualizer",
+	divoom.ChannelCustom:     "custom",
+}
+
+// New returns Bridge over connected mqtt client mc.
+func New(mc mqtt.Client, cfg Config) *Bridge {
+	if cfg.Prefix == "" {
+		cfg.Prefix = "divoom"
+	}
+	if cfg.DiscoveryPrefix == "" {
+		cfg.DiscoveryPrefix = "homeassistant"
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 30 * time.Second
+	}
+
+	return &Bridge{
+		mc:      mc,
+		cfg:     cfg,
+		devices: make(map[string]*device),
+	}
+}
+
+// AddDevice adds device of name to the bridge. name is used in the topics.
+// Add devices before Run.
+func (b *Bridge) AddDevice(name string, rd *divoom.RegisteredDevice) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.devices[name] = &device{
+		name:  name,
+		id:    rd.ID,
+		model: rd.Model,
+		c:     divoom.NewClient(rd.Device(name), b.cfg.ClientOptions...),
+	}
+}
+
+// AvailabilityTopic returns topic of availability of the bridge of prefix.
+// Set will of the mqtt client to "offline" to this topic.
+func AvailabilityTopic(prefix string) string {
+	if prefix == "" {
+		prefix = "divoom"
+	}
+	return prefix + "/bridge/availability"
+}
+
+// OnConnect subscribes command topics of the devices and publishes the bridge is online.
+// Set it as OnConnectHandler of the mqtt client, so the subscriptions are
+// made again when the client reconnects to the broker with clean session.
+func (b *Bridge) OnConnect(mc mqtt.Client) {
+	for _, d := range b.deviceList() {
+		err := b.subscribe(d)
+		if err != nil {
+			b.onError(errors.Wrapf(err, "fail to subscribe topics of %s", d.name))
+		}
+	}
+	err := b.publish(AvailabilityTopic(b.cfg.Prefix), "online")
+	if err != nil {
+		b.onError(errors.Wrap(err, "fail to publish availability"))
+	}
+}
+
+func (b *Bridge) deviceList() []*device {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	devs := make([]*device, 0, len(b.devices))
+	for _, d := range b.devices {
+		devs = append(devs, d)
+	}
+	return devs
+}
+
+// Run publishes discovery config and publishes state of the devices
+// every PollInterval until ctx is done. Command topics are subscribed by OnConnect.
+func (b *Bridge) Run(ctx context.Context) error {
+	b.loadFaces()
+
+	devs := b.deviceList()
+	for _, d := range devs {
+		err := b.publishDiscovery(d)
+		if err != nil {
+			return errors.Wrap(err, "fail to run bridge")
+		}
+	}
+
+	tk := time.NewTicker(b.cfg.PollInterval)
+	defer tk.Stop()
+	for {
+		for _, d := range devs {
+			b.publishState(d)
+		}
+
+		select {
+		case <-ctx.Done():
+			for _, d := range devs {
+				b.publish(b.topic(d, "availability"), "offline")
+			}
+			b.publish(AvailabilityTopic(b.cfg.Prefix), "offline")
+			return ctx.Err()
+		case <-tk.C:
+		}
+	}
+}
+
+func (b *Bridge) topic(d *device, sub string) string {
+	return fmt.Sprintf("%s/%s/%s", b.cfg.Prefix, d.name, sub)
+}
+
+func (b *Bridge) publish(topic string, payload interface{}) error {
+	t := b.mc.Publish(topic, 1, true, payload)
+	t.Wait()
+	return t.Error()
+}
+
+func (b *Bridge) onError(err error) {
+	if b.cfg.OnError != nil {
+		b.cfg.OnError(err)
+	}
+}
+
+func (b *Bridge) loadFaces() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.cfg.Faces != nil {
+		b.faces = b.cfg.Faces
+		return
+	}
+
+	b.faces = make(map[string]int)
+	types, err := divoom.DialType()
+	if err != nil {
+		b.onError(errors.Wrap(err, "fail to load faces"))
+		return
+	}
+	for _, t := range types {
+		dials, _, err := divoom.DialList(t, 1)
+		if err != nil {
+			b.onError(errors.Wrap(err, "fail to load faces"))
+			continue
+		}
+		for _, d := range dials {
+			b.faces[faceOption(d.Name, d.ID)] = d.ID
+		}
+	}
+}
+
+func faceOption(name string, id int) string {
+	return fmt.Sprintf("%s (%d)", name, id)
+}
+
+func (b *Bridge) faceName(id int) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for n, fid := range b.faces {
+		if fid == id {
+			return n
+		}
+	}
+	return strconv.Itoa(id)
+}
+
+func (b *Bridge) faceOptions() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	opts := make([]string, 0, len(b.faces))
+	for n := range b.faces {
+		opts = append(opts, n)
+	}
+	sort.Strings(opts)
+	return opts
+}
+
+func (b *Bridge) publishDiscovery(d *device) error {
+	uid := fmt.Sprintf("divoom_%d", d.id)
+	if d.id == 0 {
+		uid = "divoom_" + d.name
+	}
+	model := d.model
+	if model == "" {
+		model = "Pixoo"
+	}
+	haDev := map[string]interface{}{
+		"identifiers":  []string{uid},
+		"name":         d.name,
+		"manufacturer": "Divoom",
+		"model":        model,
+	}
+	// the device is available only if both the bridge and the device are online
+	avail := []map[string]string{
+		{"topic": AvailabilityTopic(b.cfg.Prefix)},
+		{"topic": b.topic(d, "availability")},
+	}
+
+	configs := map[string]map[string]interface{}{
+		"light/" + uid: {
+			"name":                     nil, // use device name
+			"unique_id":                uid + "_light",
+			"command_topic":            b.topic(d, "light/set"),
+			"state_topic":              b.topic(d, "light/state"),
+			"brightness_command_topic": b.topic(d, "brightness/set"),
+			"brightness_state_topic":   b.topic(d, "brightness/state"),
+			"brightness_scale":         100,
+			"availability":             avail,
+			"availability_mode":        "all",
+			"device":                   haDev,
+		},
+		"select/" + uid + "_channel": {
+			"name":              "Channel",
+			"unique_id":         uid + "_channel",
+			"command_topic":     b.topic(d, "channel/set"),
+			"state_topic":       b.topic(d, "channel/state"),
+			"options":           []string{"faces", "cloud", "visualizer", "custom"},
+			"availability":      avail,
+			"availability_mode": "all",
+			"device":            haDev,
+		},
+		"text/" + uid + "_text": {
+			"name":              "Text",
+			"unique_id":         uid + "_text",
+			"command_topic":     b.topic(d, "text/set"),
+			"availability":      avail,
+			"availability_mode": "all",
+			"device":            haDev,
+		},
+	}
+	if opts := b.faceOptions(); len(opts) > 0 {
+		configs["select/"+uid+"_face"] = map[string]interface{}{
+			"name":              "Face",
+			"unique_id":         uid + "_face",
+			"command_topic":     b.topic(d, "face/set"),
+			"state_topic":       b.topic(d, "face/state"),
+			"options":           opts,
+			"availability":      avail,
+			"availability_mode": "all",
+			"device":            haDev,
+		}
+	}
+
+	for obj, cfg := range configs {
+		payload, err := json.Marshal(cfg)
+		if err != nil {
+			return errors.Wrap(err, "fail to publish discovery")
+		}
+		err = b.publish(fmt.Sprintf("%s/%s/config", b.cfg.DiscoveryPrefix, obj), payload)
+		if err != nil {
+			return errors.Wrap(err, "fail to publish discovery")
+		}
+	}
+
+	return nil
+}
+
+func (b *Bridge) subscribe(d *device) error {
+	handlers := map[string]func(string) error{
+		"light/set": func(p string) error {
+			switch strings.ToUpper(p) {
+			case "ON":
+				return d.c.ScreenSwitch(true)
+			case "OFF":
+				return d.c.ScreenSwitch(false)
+			}
+			return fmt.Errorf("unknown light state %q", p)
+		},
+		"brightness/set": func(p string) error {
+			v, err := strconv.Atoi(p)
+			if err != nil {
+				return err
+			}
+			return d.c.SetBrightness(v)
+		},
+		"channel/set": func(p string) error {
+			for ch, n := range channelNames {
+				if n == p {
+					return d.c.SelectChannel(ch)
+				}
+			}
+			return fmt.Errorf("unknown channel %q", p)
+		},
+		"face/set": func(p string) error {
+			b.mu.Lock()
+			id, ok := b.faces[p]
+			b.mu.Unlock()
+			if !ok {
+				var err error
+				id, err = strconv.Atoi(p)
+				if err != nil {
+					return fmt.Errorf("unknown face %q", p)
+				}
+			}
+			return d.c.SelectFacesChannel(id)
+		},
+		"text/set": func(p string) error {
+			err := d.c.ClearAllTextArea()
+			if err != nil || p == "" {
+				return err
+			}
+			return d.c.SendText(1, 0, 24, divoom.TextDirLeft, divoom.TextFont0, 64, p, 10, "#FFFFFF", divoom.TextAlignMiddle)
+		},
+		"image/set": func(p string) error {
+			img, err := getImage(p)
+			if err != nil {
+				return err
+			}
+			err = d.c.ResetSendingAnimationPicID()
+			if err != nil {
+				return err
+			}
+			return d.c.SendImage(1, bytes.NewReader(img), 1000)
+		},
+	}
+
+	for sub, h := range handlers {
+		sub, h := sub, h
+		t := b.mc.Subscribe(b.topic(d, sub), 1, func(_ mqtt.Client, m mqtt.Message) {
+			// don't block the mqtt client
+			p := string(m.Payload())
+			ok := d.enqueue(func() {
+				err := h(p)
+				if err != nil {
+					b.onError(errors.Wrapf(err, "fail to handle %s of %s", sub, d.name))
+				}
+				b.publishState(d)
+			})
+			if !ok {
+				b.onError(fmt.Errorf("fail to handle %s of %s: too many pending commands", sub, d.name))
+			}
+		})
+		t.Wait()
+		if err := t.Error(); err != nil {
+			return errors.Wrap(err, "fail to subscribe")
+		}
+	}
+
+	return nil
+}
+
+func (b *Bridge) publishState(d *device) {
+	s, err := d.c.GetAllSetting()
+	if err != nil {
+		b.onError(errors.Wrapf(err, "fail to get state of %s", d.name))
+		b.publish(b.topic(d, "availability"), "offline")
+		return
+	}
+	ch, err := d.c.GetCurrentChannel()
+	if err != nil {
+		b.onError(errors.Wrapf(err, "fail to get state of %s", d.name))
+		b.publish(b.topic(d, "availability"), "offline")
+		return
+	}
+
+	light := "OFF"
+	if s["LightSwitch"] == float64(1) {
+		light = "ON"
+	}
+	settings, _ := json.Marshal(s)
+
+	states := [][2]string{
+		{"availability", "online"},
+		{"light/state", light},
+		{"brightness/state", fmt.Sprint(s["Brightness"])},
+		{"channel/state", channelNames[ch]},
+		{"settings", string(settings)},
+	}
+	if id, ok := s["CurClockId"].(float64); ok {
+		states = append(states, [2]string{"face/state", b.faceName(int(id))})
+	}
+
+	for _, st := range states {
+		err := b.publish(b.topic(d, st[0]), st[1])
+		if err != nil {
+			b.onError(errors.Wrapf(err, "fail to publish state of %s", d.name))
+			return
+		}
+	}
+}
+
+// getImage downloads image of url up to maxImageSize.
+func getImage(url string) ([]byte, error) {
+	resp, err := imageClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fail to get %s: %s", url, resp.Status)
+	}
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxImageSize+1))
+	if err != nil {
+		return nil, errors.Wrapf(err, "fail to get %s", url)
+	}
+	if len(b) > maxImageSize {
+		return nil, fmt.Errorf("fail to get %s: bigger than %d bytes", url, maxImageSize)
+	}
+	return b, nil
+}
@@ -0,0 +1,336 @@
+package mqttbridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	divoom "github.com/suapapa/go_divoom"
+)
+
+// fakeMQTT is mqtt.Client keeping subscriptions and retained messages.
+// Methods other than Subscribe and Publish panic.
+type fakeMQTT struct {
+	mqtt.Client
+
+	mu        sync.Mutex
+	subs      map[string]mqtt.MessageHandler
+	published map[string]string
+	pubCh     chan string
+}
+
+func newFakeMQTT() *fakeMQTT {
+	return &fakeMQTT{
+		subs:      make(map[string]mqtt.MessageHandler),
+		published: make(map[string]string),
+		pubCh:     make(chan string, 100),
+	}
+}
+
+func (f *fakeMQTT) Subscribe(topic string, qos byte, cb mqtt.MessageHandler) mqtt.Token {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.subs[topic] = cb
+	return doneToken{}
+}
+
+func (f *fakeMQTT) Publish(topic string, qos byte, retained bool, payload interface{}) mqtt.Token {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch p := payload.(type) {
+	case string:
+		f.published[topic] = p
+	case []byte:
+		f.published[topic] = string(p)
+	}
+	f.pubCh <- topic
+	return doneToken{}
+}
+
+// deliver calls handler of topic and waits the state to be published.
+func (f *fakeMQTT) deliver(t *testing.T, topic, payload, stateTopic string) {
+	t.Helper()
+	f.mu.Lock()
+	cb, ok := f.subs[topic]
+	f.mu.Unlock()
+	if !ok {
+		t.Fatalf("%s is not subscribed", topic)
+	}
+
+	cb(f, &fakeMessage{topic: topic, payload: []byte(payload)})
+	for {
+		select {
+		case tp := <-f.pubCh:
+			if tp == stateTopic {
+				return
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s is not published", stateTopic)
+		}
+	}
+}
+
+type doneToken struct{}
+
+func (doneToken) Wait() bool                     { return true }
+func (doneToken) WaitTimeout(time.Duration) bool { return true }
+func (doneToken) Done() <-chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}
+func (doneToken) Error() error { return nil }
+
+type fakeMessage struct {
+	mqtt.Message
+	topic   string
+	payload []byte
+}
+
+func (m *fakeMessage) Topic() string   { return m.topic }
+func (m *fakeMessage) Payload() []byte { return m.payload }
+
+// fakeDevice is http.RoundTripper replying to commands like a device.
+type fakeDevice struct {
+	delay time.Duration // to reply
+
+	mu        sync.Mutex
+	reqs      []map[string]interface{}
+	active    int
+	maxActive int // most requests handled at the same time
+}
+
+func (d *fakeDevice) RoundTrip(req *http.Request) (*http.Response, error) {
+	var data map[string]interface{}
+	err := json.NewDecoder(req.Body).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	d.reqs = append(d.reqs, data)
+	d.active++
+	if d.active > d.maxActive {
+		d.maxActive = d.active
+	}
+	d.mu.Unlock()
+
+	time.Sleep(d.delay)
+	d.mu.Lock()
+	d.active--
+	d.mu.Unlock()
+
+	body := `{"error_code":0,"Brightness":50,"LightSwitch":1,"CurClockId":12,"SelectIndex":1}`
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+		Request:    req,
+	}, nil
+}
+
+// commands returns requests of the device except polling of state, and forgets them.
+func (d *fakeDevice) commands() []map[string]interface{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var cmds []map[string]interface{}
+	for _, r := range d.reqs {
+		switch r["Command"] {
+		case "Channel/GetAllConf", "Channel/GetIndex":
+			continue
+		}
+		cmds = append(cmds, r)
+	}
+	d.reqs = nil
+	return cmds
+}
+
+func TestBridgeCommands(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	var pngBuf bytes.Buffer
+	png.Encode(&pngBuf, img)
+	imgSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(pngBuf.Bytes())
+	}))
+	defer imgSrv.Close()
+
+	fm := newFakeMQTT()
+	dev := &fakeDevice{}
+	var errs []error
+	var errMu sync.Mutex
+	b := New(fm, Config{
+		OnError: func(err error) {
+			errMu.Lock()
+			errs = append(errs, err)
+			errMu.Unlock()
+		},
+		ClientOptions: []divoom.ClientOption{
+			divoom.WithHTTPClient(&http.Client{Transport: dev}),
+		},
+	})
+	b.AddDevice("office", &divoom.RegisteredDevice{ID: 1, IP: "192.168.0.2"})
+	b.OnConnect(fm)
+
+	if got := fm.published["divoom/bridge/availability"]; got != "online" {
+		t.Errorf("bridge availability = %q, want online", got)
+	}
+	if n := len(fm.subs); n != 6 {
+		t.Errorf("%d topics are subscribed, want 6", n)
+	}
+
+	tcs := []struct {
+		sub     string
+		payload string
+		want    []map[string]interface{}
+	}{
+		{"light/set", "OFF", []map[string]interface{}{
+			{"Command": "Channel/OnOffScreen", "OnOff": float64(0)},
+		}},
+		{"brightness/set", "30", []map[string]interface{}{
+			{"Command": "Channel/SetBrightness", "Brightness": float64(30)},
+		}},
+		{"channel/set", "visualizer", []map[string]interface{}{
+			{"Command": "Channel/SetIndex", "SelectIndex": float64(divoom.ChannelVisualizer)},
+		}},
+		{"face/set", "12", []map[string]interface{}{
+			{"Command": "Channel/SetIndex", "SelectIndex": float64(divoom.ChannelFaces)},
+			{"Command": "Channel/SetClockSelectId", "ClockId": float64(12)},
+		}},
+		{"text/set", "hello", []map[string]interface{}{
+			{"Command": "Draw/ClearHttpText"},
+			{"Command": "Draw/SendHttpText", "TextString": "hello"},
+		}},
+		{"image/set", imgSrv.URL, []map[string]interface{}{
+			{"Command": "Draw/ResetHttpGifId"},
+			{"Command": "Draw/SendHttpGif", "PicNum": float64(1), "PicWidth": float64(16)},
+		}},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.sub, func(t *testing.T) {
+			fm.deliver(t, "divoom/office/"+tc.sub, tc.payload, "divoom/office/settings")
+
+			got := dev.commands()
+			if len(got) != len(tc.want) {
+				t.Fatalf("got commands %v, want %v", got, tc.want)
+			}
+			for i, w := range tc.want {
+				for k, v := range w {
+					if got[i][k] != v {
+						t.Errorf("%s of command %d = %v, want %v", k, i, got[i][k], v)
+					}
+				}
+			}
+		})
+	}
+
+	errMu.Lock()
+	defer errMu.Unlock()
+	for _, err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestBridgeCommandError(t *testing.T) {
+	fm := newFakeMQTT()
+	dev := &fakeDevice{}
+	errCh := make(chan error, 1)
+	b := New(fm, Config{
+		OnError: func(err error) { errCh <- err },
+		ClientOptions: []divoom.ClientOption{
+			divoom.WithHTTPClient(&http.Client{Transport: dev}),
+		},
+	})
+	b.AddDevice("office", &divoom.RegisteredDevice{IP: "192.168.0.2"})
+	b.OnConnect(fm)
+
+	for _, tc := range []struct{ sub, payload string }{
+		{"brightness/set", "bright"},
+		{"light/set", "DIM"},
+	} {
+		fm.deliver(t, "divoom/office/"+tc.sub, tc.payload, "divoom/office/settings")
+		select {
+		case err := <-errCh:
+			if want := "fail to handle " + tc.sub + " of office"; !strings.HasPrefix(err.Error(), want) {
+				t.Errorf("error = %v, want %s...", err, want)
+			}
+		default:
+			t.Errorf("no error for %s of %q", tc.sub, tc.payload)
+		}
+		if cmds := dev.commands(); len(cmds) != 0 {
+			t.Errorf("got commands %v, want none", cmds)
+		}
+	}
+}
+
+func TestBridgeCommandOrder(t *testing.T) {
+	fm := newFakeMQTT()
+	dev := &fakeDevice{delay: 5 * time.Millisecond}
+	b := New(fm, Config{
+		ClientOptions: []divoom.ClientOption{
+			divoom.WithHTTPClient(&http.Client{Transport: dev}),
+		},
+	})
+	b.AddDevice("office", &divoom.RegisteredDevice{IP: "192.168.0.2"})
+	b.OnConnect(fm)
+
+	// messages arrive faster than the device replies
+	const n = 5
+	cb := fm.subs["divoom/office/brightness/set"]
+	for i := 1; i <= n; i++ {
+		cb(fm, &fakeMessage{payload: []byte(fmt.Sprint(i * 10))})
+	}
+	for settings := 0; settings < n; {
+		select {
+		case tp := <-fm.pubCh:
+			if tp == "divoom/office/settings" {
+				settings++
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("commands are not handled")
+		}
+	}
+
+	cmds := dev.commands()
+	if len(cmds) != n {
+		t.Fatalf("got commands %v, want %d", cmds, n)
+	}
+	for i, cmd := range cmds {
+		if want := float64((i + 1) * 10); cmd["Brightness"] != want {
+			t.Errorf("brightness of command %d = %v, want %v", i, cmd["Brightness"], want)
+		}
+	}
+	dev.mu.Lock()
+	defer dev.mu.Unlock()
+	if dev.maxActive != 1 {
+		t.Errorf("device got %d requests at once, want 1", dev.maxActive)
+	}
+}
+
+func TestBridgeResubscribe(t *testing.T) {
+	fm := newFakeMQTT()
+	b := New(fm, Config{})
+	b.AddDevice("office", &divoom.RegisteredDevice{IP: "192.168.0.2"})
+
+	for i := 0; i < 2; i++ {
+		// broker lost the subscriptions
+		fm.subs = make(map[string]mqtt.MessageHandler)
+		b.OnConnect(fm)
+		for _, sub := range []string{"light/set", "brightness/set", "channel/set", "face/set", "text/set", "image/set"} {
+			topic := fmt.Sprintf("divoom/office/%s", sub)
+			if _, ok := fm.subs[topic]; !ok {
+				t.Errorf("connection %d: %s is not subscribed", i, topic)
+			}
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package divoom
+
+import (
+	"container/heap"
+	"context"
+	"image"
+	"image/draw"
+	"sync"
+	"time"
+
+	"github.com/nfnt/resize"
+	"github.com/pkg/errors"
+)
+
+// DisplayState is what the device shows, to be restored later.
+type DisplayState struct {
+	Channel Channel
+	ClockID int // face of ChannelFaces
+}
+
+// CaptureDisplay returns current channel and face of the device.
+func (c *Client) CaptureDisplay() (*DisplayState, error) {
+	ch, err := c.GetCurrentChannel()
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to capture display")
+	}
+	s := &DisplayState{Channel: ch}
+
+	if ch == ChannelFaces {
+		f, err := c.GetSelectFaceID()
+		if err != nil {
+			return nil, errors.Wrap(err, "fail to capture display")
+		}
+		s.ClockID = f.ClockID
+	}
+	return s, nil
+}
+
+// RestoreDisplay shows s again. Cloud, visualizer and custom channels
+// come back to the item which the device remembers for the channel.
+// Texts of SendText are cleared first, as they stay over any channel.
+func (c *Client) RestoreDisplay(s *DisplayState) error {
+	err := c.ClearAllTextArea()
+	if err != nil {
+		return errors.Wrap(err, "fail to restore display")
+	}
+
+	if s.Channel == ChannelFaces {
+		return c.SelectFacesChannel(s.ClockID)
+	}
+	return c.SelectChannel(s.Channel)
+}
+
+// Notification is an icon with scrolling text shown for a while.
+type Notification struct {
+	Icon     image.Image // shown on the top; optional
+	Text     string
+	Color    string // text color like "#FF0000"; white if empty
+	Font     TextFont
+	Speed    int // msec per scroll step; 10 if 0
+	Duration time.Duration
+	// Priority decides which notification is shown first.
+	// Higher one interrupts lower one being shown.
+	Priority int
+}
+
+// NotificationImage returns background image of nt for panel of size;
+// the icon fitted in the top half. Text is drawn by the device over it.
+func NotificationImage(nt Notification, size int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	if nt.Icon == nil {
+		return img
+	}
+
+	box := uint(size / 2)
+	icon := resize.Thumbnail(box, box, nt.Icon, resize.Lanczos3)
+	ib := icon.Bounds()
+	dp := image.Pt((size-ib.Dx())/2, size/16+(int(box)-ib.Dy())/2)
+	draw.Draw(img, ib.Sub(ib.Min).Add(dp), icon, ib.Min, draw.Over)
+	return img
+}
+
+// ShowNotification shows nt on panel of size, without restoring.
+func (c *Client) ShowNotification(nt Notification, size int) error {
+	color := nt.Color
+	if color == "" {
+		color = "#FFFFFF"
+	}
+	speed := nt.Speed
+	if speed == 0 {
+		speed = 10
+	}
+
+	err := c.ResetSendingAnimationPicID()
+	if err != nil {
+		return errors.Wrap(err, "fail to show notification")
+	}
+	err = c.SendAnimationImgs(1, []int{1000}, []image.Image{NotificationImage(nt, size)})
+	if err != nil {
+		return errors.Wrap(err, "fail to show notification")
+	}
+	if nt.Text == "" {
+		return nil
+	}
+	y := size * 11 / 16
+	if nt.Icon == nil {
+		y = size / 2
+	}
+	err = c.SendText(1, 0, y, TextDirLeft, nt.Font, size, nt.Text, speed, color, TextAlignMiddle)
+	if err != nil {
+		return errors.Wrap(err, "fail to show notification")
+	}
+	return nil
+}
+
+// Notify shows nt on 64 pixel panel for nt.Duration and then restores
+// what was shown before. Use Notifier for overlapping notifications.
+func (c *Client) Notify(ctx context.Context, nt Notification) error {
+	s, err := c.CaptureDisplay()
+	if err != nil {
+		return errors.Wrap(err, "fail to notify")
+	}
+
+	err = c.ShowNotification(nt, 64)
+	if err == nil {
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-time.After(nt.Duration):
+		}
+	}
+
+	if rErr := c.RestoreDisplay(s); rErr != nil {
+		return errors.Wrap(rErr, "fail to notify")
+	}
+	return err
+}
+
+// Notifier shows notifications in order of priority and
+// restores the display when all of them are shown.
+type Notifier struct {
+	c *Client
+
+	// PanelSize is size of the device panel; 64 by default.
+	PanelSize int
+	// OnError is called with errors while running. Errors are ignored if nil.
+	OnError func(error)
+
+	mu    sync.Mutex
+	queue notificationQueue
+	seq   int
+	wake  chan struct{}
+}
+
+// NewNotifier returns Notifier of c.
+func NewNotifier(c *Client) *Notifier {
+	return &Notifier{
+		c:         c,
+		PanelSize: 64,
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// Notify queues nt. If nt has higher priority than the notification
+// being shown, it's shown right away and the other one resumes after.
+func (n *Notifier) Notify(nt Notification) {
+	n.mu.Lock()
+	heap.Push(&n.queue, &queuedNotification{Notification: nt, remain: nt.Duration, seq: n.seq})
+	n.seq++
+	n.mu.Unlock()
+
+	select {
+	case n.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Pending returns number of notifications waiting to be shown.
+func (n *Notifier) Pending() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.queue.Len()
+}
+
+// Run shows notifications queued until ctx is done.
+func (n *Notifier) Run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-n.wake:
+		}
+
+		if n.Pending() == 0 {
+			continue
+		}
+		if err := n.showAll(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// showAll captures the display, shows queued notifications and restores it.
+func (n *Notifier) showAll(ctx context.Context) error {
+	s, err := n.c.CaptureDisplay()
+	if err != nil {
+		n.onError(err)
+	}
+	defer func() {
+		if s == nil {
+			return
+		}
+		if err := n.c.RestoreDisplay(s); err != nil {
+			n.onError(errors.Wrap(err, "fail to restore display"))
+		}
+	}()
+
+	for {
+		n.mu.Lock()
+		if n.queue.Len() == 0 {
+			n.mu.Unlock()
+			return nil
+		}
+		cur := heap.Pop(&n.queue).(*queuedNotification)
+		n.mu.Unlock()
+
+		if err := n.c.ShowNotification(cur.Notification, n.PanelSize); err != nil {
+			n.onError(err)
+		}
+
+		if err := n.wait(ctx, cur); err != nil {
+			return err
+		}
+	}
+}
+
+// wait waits cur to end, or puts it back to the queue
+// if higher one comes.
+func (n *Notifier) wait(ctx context.Context, cur *queuedNotification) error {
+	for {
+		start := time.Now()
+		tm := time.NewTimer(cur.remain)
+
+		select {
+		case <-ctx.Done():
+			tm.Stop()
+			return ctx.Err()
+		case <-tm.C:
+			return nil
+		case <-n.wake:
+			tm.Stop()
+		}
+
+		cur.remain -= time.Since(start)
+		n.mu.Lock()
+		preempted := n.queue.Len() > 0 && n.queue[0].Priority > cur.Priority
+		if preempted && cur.remain > 0 {
+			heap.Push(&n.queue, cur)
+		}
+		n.mu.Unlock()
+		if preempted || cur.remain <= 0 {
+			return nil
+		}
+	}
+}
+
+func (n *Notifier) onError(err error) {
+	if n.OnError != nil {
+		n.OnError(err)
+	}
+}
+
+type queuedNotification struct {
+	Notification
+	remain time.Duration
+	seq    int
+}
+
+// notificationQueue is heap of higher priority, then earlier one first.
+type notificationQueue []*queuedNotification
+
+func (q notificationQueue) Len() int { return len(q) }
+
+func (q notificationQueue) Less(i, j int) bool {
+	if q[i].Priority != q[j].Priority {
+		return q[i].Priority > q[j].Priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q notificationQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *notificationQueue) Push(x any) { *q = append(*q, x.(*queuedNotification)) }
+
+func (q *notificationQueue) Pop() any {
+	old := *q
+	x := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return x
+}
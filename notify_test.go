@@ -0,0 +1,103 @@
+package divoom
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNotifyRestore(t *testing.T) {
+	c, dev := newFakeClient()
+	err := c.Notify(context.Background(), Notification{Text: "hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cmds []string
+	for _, cmd := range dev.cmds {
+		cmds = append(cmds, cmd["Command"].(string))
+	}
+	// reply of the fake device is error_code only, so faces channel of clock 0 is captured
+	want := []string{
+		"Channel/GetIndex",
+		"Channel/GetClockInfo",
+		"Draw/ResetHttpGifId",
+		"Draw/SendHttpGif",
+		"Draw/SendHttpText",
+		"Draw/ClearHttpText",
+		"Channel/SetIndex",
+		"Channel/SetClockSelectId",
+	}
+	if !reflect.DeepEqual(cmds, want) {
+		t.Errorf("commands = %v, want %v", cmds, want)
+	}
+}
+
+// shownTexts returns texts of notifications sent to d and count of captures of the display.
+func shownTexts(d *fakeDevice) (texts []string, captures int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, cmd := range d.cmds {
+		switch cmd["Command"] {
+		case "Draw/SendHttpText":
+			texts = append(texts, cmd["TextString"].(string))
+		case "Channel/GetIndex":
+			captures++
+		}
+	}
+	return texts, captures
+}
+
+// waitShown waits until n texts are shown on d.
+func waitShown(t *testing.T, d *fakeDevice, n int) []string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if texts, _ := shownTexts(d); len(texts) >= n {
+			return texts
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	texts, _ := shownTexts(d)
+	t.Fatalf("shown %v, want %d notifications", texts, n)
+	return nil
+}
+
+func TestNotifierPreempt(t *testing.T) {
+	c, dev := newFakeClient()
+	n := NewNotifier(c)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go n.Run(ctx)
+
+	n.Notify(Notification{Text: "low", Duration: 300 * time.Millisecond})
+	waitShown(t, dev, 1)
+
+	// same priority waits, higher one interrupts
+	n.Notify(Notification{Text: "next", Duration: 10 * time.Millisecond})
+	n.Notify(Notification{Text: "high", Duration: 10 * time.Millisecond, Priority: 1})
+	texts := waitShown(t, dev, 4)
+
+	// low resumes before the later one of the same priority
+	want := []string{"low", "high", "low", "next"}
+	if !reflect.DeepEqual(texts, want) {
+		t.Errorf("shown %v, want %v", texts, want)
+	}
+
+	// display is captured once and restored after all
+	deadline := time.Now().Add(5 * time.Second)
+	for dev.last()["Command"] != "Channel/SetClockSelectId" {
+		if time.Now().After(deadline) {
+			t.Fatalf("display is not restored; last sent %v", dev.last())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if _, captures := shownTexts(dev); captures != 1 {
+		t.Errorf("display captured %d times, want 1", captures)
+	}
+	if n.Pending() != 0 {
+		t.Errorf("%d notifications are pending", n.Pending())
+	}
+}
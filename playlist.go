@@ -0,0 +1,297 @@
+package divoom
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Step is what Playlist shows on the device.
+type Step interface {
+	Show(c *Client) error
+}
+
+// FaceStep shows clock face of ClockID.
+type FaceStep struct {
+	ClockID int
+}
+
+func (s FaceStep) Show(c *Client) error {
+	return c.SelectFacesChannel(s.ClockID)
+}
+
+// CloudStep shows cloud channel of Index.
+type CloudStep struct {
+	Index CloudChannelIdx
+}
+
+func (s CloudStep) Show(c *Client) error {
+	return c.CloudChannel(s.Index)
+}
+
+// VisualizerStep shows visualizer at Position.
+type VisualizerStep struct {
+	Position int
+}
+
+func (s VisualizerStep) Show(c *Client) error {
+	return c.VisualizerChannel(s.Position)
+}
+
+// CustomStep shows custom page of Page.
+type CustomStep struct {
+	Page CustomIdx
+}
+
+func (s CustomStep) Show(c *Client) error {
+	return c.CustomChannel(s.Page)
+}
+
+// AnimationStep uploads the animation and shows it.
+type AnimationStep struct {
+	Animation
+}
+
+func (s AnimationStep) Show(c *Client) error {
+	err := c.ResetSendingAnimationPicID()
+	if err != nil {
+		return err
+	}
+	return c.SendAnimationImgs(1, s.SpeedMSecs, s.Imgs)
+}
+
+// TextStep shows scrolling text over Background.
+// Background is black 64x64 image if nil.
+type TextStep struct {
+	Text       string
+	X, Y       int
+	Dir        TextDir
+	Font       TextFont
+	Width      int // text area width, 16~64
+	Speed      int // msec per scroll step
+	Color      string
+	Align      TextAlign
+	Background image.Image
+}
+
+func (s TextStep) Show(c *Client) error {
+	bg := s.Background
+	if bg == nil {
+		bg = image.NewRGBA(image.Rect(0, 0, 64, 64))
+	}
+
+	err := c.ResetSendingAnimationPicID()
+	if err != nil {
+		return err
+	}
+	err = c.SendAnimationImgs(1, []int{1000}, []image.Image{bg})
+	if err != nil {
+		return err
+	}
+	return c.SendText(1, s.X, s.Y, s.Dir, s.Font, s.Width, s.Text, s.Speed, s.Color, s.Align)
+}
+
+// PlaylistItem is a step of Playlist and how long it is shown.
+// Duration of 0 shows the step until Skip.
+type PlaylistItem struct {
+	Step     Step
+	Duration time.Duration
+}
+
+// Playlist shows its steps one after another.
+type Playlist struct {
+	c     *Client
+	items []PlaylistItem
+
+	// Loop restarts the playlist after the last step.
+	Loop bool
+	// Shuffle plays the steps in random order, shuffled again on each loop.
+	Shuffle bool
+	// OnStep is called when a step is shown. Interrupts are not reported.
+	OnStep func(i int, item PlaylistItem)
+	// OnError is called with errors while running. Errors are ignored if nil.
+	OnError func(error)
+
+	mu         sync.Mutex
+	interrupts []PlaylistItem
+	skip       bool
+	wake       chan struct{}
+}
+
+// NewPlaylist returns Playlist of items.
+func NewPlaylist(c *Client, items ...PlaylistItem) *Playlist {
+	return &Playlist{
+		c:     c,
+		items: items,
+		wake:  make(chan struct{}, 1),
+	}
+}
+
+// Interrupt shows item right away. After item.Duration the step playing
+// is shown again for rest of its duration. Like a step, Duration of 0
+// shows item until Skip. Interrupts coming together are shown in order.
+func (p *Playlist) Interrupt(item PlaylistItem) {
+	p.mu.Lock()
+	p.interrupts = append(p.interrupts, item)
+	p.mu.Unlock()
+	p.notify()
+}
+
+// Skip ends the current step, or interrupt, and goes to the next.
+func (p *Playlist) Skip() {
+	p.mu.Lock()
+	p.skip = true
+	p.mu.Unlock()
+	p.notify()
+}
+
+func (p *Playlist) notify() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run plays the playlist until its end, or until ctx is done if Loop.
+func (p *Playlist) Run(ctx context.Context) error {
+	if len(p.items) == 0 {
+		return fmt.Errorf("fail to run playlist: no step")
+	}
+
+	for {
+		for _, i := range p.order() {
+			if err := p.play(ctx, i); err != nil {
+				return err
+			}
+		}
+		if !p.Loop {
+			return nil
+		}
+	}
+}
+
+func (p *Playlist) order() []int {
+	order := make([]int, len(p.items))
+	for i := range order {
+		order[i] = i
+	}
+	if p.Shuffle {
+		rand.Shuffle(len(order), func(i, j int) {
+			order[i], order[j] = order[j], order[i]
+		})
+	}
+	return order
+}
+
+// play shows i-th item for its duration, handling interrupts and skip.
+func (p *Playlist) play(ctx context.Context, i int) error {
+	item := p.items[i]
+	p.show(item.Step)
+	if p.OnStep != nil {
+		p.OnStep(i, item)
+	}
+
+	remain := item.Duration
+	for {
+		start := time.Now()
+		var tm *time.Timer
+		var timeout <-chan time.Time
+		if remain > 0 {
+			tm = time.NewTimer(remain)
+			timeout = tm.C
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timeout:
+			return nil
+		case <-p.wake:
+			if tm != nil {
+				tm.Stop()
+			}
+		}
+
+		if p.takeSkip() {
+			return nil
+		}
+		if remain > 0 {
+			remain -= time.Since(start)
+		}
+
+		interrupted, err := p.playInterrupts(ctx)
+		if err != nil {
+			return err
+		}
+		if interrupted {
+			p.show(item.Step)
+		}
+		if item.Duration > 0 && remain <= 0 {
+			return nil
+		}
+	}
+}
+
+// playInterrupts shows the pending interrupts one by one.
+func (p *Playlist) playInterrupts(ctx context.Context) (bool, error) {
+	played := false
+	for {
+		p.mu.Lock()
+		if len(p.interrupts) == 0 {
+			p.mu.Unlock()
+			return played, nil
+		}
+		in := p.interrupts[0]
+		p.interrupts = p.interrupts[1:]
+		p.mu.Unlock()
+
+		played = true
+		p.show(in.Step)
+
+		var tm *time.Timer
+		var timeout <-chan time.Time
+		if in.Duration > 0 {
+			tm = time.NewTimer(in.Duration)
+			timeout = tm.C
+		}
+		for waiting := true; waiting; {
+			select {
+			case <-ctx.Done():
+				if tm != nil {
+					tm.Stop()
+				}
+				return played, ctx.Err()
+			case <-timeout:
+				waiting = false
+			case <-p.wake:
+				// skip ends this interrupt; new ones are queued
+				if p.takeSkip() {
+					if tm != nil {
+						tm.Stop()
+					}
+					waiting = false
+				}
+			}
+		}
+	}
+}
+
+func (p *Playlist) takeSkip() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	skip := p.skip
+	p.skip = false
+	return skip
+}
+
+func (p *Playlist) show(s Step) {
+	if err := s.Show(p.c); err != nil && p.OnError != nil {
+		p.OnError(errors.Wrap(err, "fail to show step"))
+	}
+}
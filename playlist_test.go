@@ -0,0 +1,92 @@
+package divoom
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// nameStep reports its name to shown on Show.
+type nameStep struct {
+	name  string
+	shown chan string
+}
+
+func (s nameStep) Show(c *Client) error {
+	s.shown <- s.name
+	return nil
+}
+
+func expectShown(t *testing.T, shown chan string, want string) {
+	t.Helper()
+	select {
+	case got := <-shown:
+		if got != want {
+			t.Fatalf("shown %q, want %q", got, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("%q is not shown", want)
+	}
+}
+
+func expectNothing(t *testing.T, shown chan string, d time.Duration) {
+	t.Helper()
+	select {
+	case got := <-shown:
+		t.Fatalf("shown %q, want nothing", got)
+	case <-time.After(d):
+	}
+}
+
+func TestPlaylistInterrupt(t *testing.T) {
+	shown := make(chan string, 10)
+	c, _ := newFakeClient()
+	p := NewPlaylist(c, PlaylistItem{Step: nameStep{"main", shown}})
+
+	done := make(chan error, 1)
+	go func() { done <- p.Run(context.Background()) }()
+	expectShown(t, shown, "main")
+
+	// timed interrupt goes back to the step
+	p.Interrupt(PlaylistItem{Step: nameStep{"timed", shown}, Duration: 20 * time.Millisecond})
+	expectShown(t, shown, "timed")
+	expectShown(t, shown, "main")
+
+	// interrupt of 0 duration stays until Skip, like a step
+	p.Interrupt(PlaylistItem{Step: nameStep{"sticky", shown}})
+	expectShown(t, shown, "sticky")
+	expectNothing(t, shown, 50*time.Millisecond)
+	p.Skip()
+	expectShown(t, shown, "main")
+
+	p.Skip()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("playlist doesn't end on Skip")
+	}
+}
+
+func TestPlaylistLoop(t *testing.T) {
+	shown := make(chan string, 10)
+	c, _ := newFakeClient()
+	p := NewPlaylist(c,
+		PlaylistItem{Step: nameStep{"a", shown}, Duration: 10 * time.Millisecond},
+		PlaylistItem{Step: nameStep{"b", shown}, Duration: 10 * time.Millisecond},
+	)
+	p.Loop = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- p.Run(ctx) }()
+	for _, want := range []string{"a", "b", "a", "b"} {
+		expectShown(t, shown, want)
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("error = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,199 @@
+// Package promexporter exports metrics of Divoom devices and their clients to Prometheus.
+package promexporter
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	divoom "github.com/suapapa/go_divoom"
+)
+
+// Metrics implements divoom.Metrics with Prometheus collectors.
+// Use it with divoom.WithMetrics.
+type Metrics struct {
+	commands *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+	frames   *prometheus.CounterVec
+}
+
+// NewMetrics returns Metrics registered to reg.
+func NewMetrics(reg prometheus.Registerer) (*Metrics, error) {
+	m := &Metrics{
+		commands: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "divoom_commands_total",
+			Help: "Number of commands sent to the device.",
+		}, []string{"device", "command"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "divoom_command_errors_total",
+			Help: "Number of failed commands by error_code of the device. error_code is no_reply if the device didn't reply.",
+		}, []string{"device", "command", "error_code"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "divoom_command_duration_seconds",
+			Help:    "Latency of commands.",
+			Buckets: []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		}, []string{"device", "command"}),
+		frames: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "divoom_animation_frames_total",
+			Help: "Number of animation frames sent to the device.",
+		}, []string{"device"}),
+	}
+
+	for _, c := range []prometheus.Collector{m.commands, m.errors, m.latency, m.frames} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (m *Metrics) ObserveCommand(dev *divoom.Device, command string, latency time.Duration, errorCode int) {
+	name := deviceName(dev)
+	m.commands.WithLabelValues(name, command).Inc()
+	m.latency.WithLabelValues(name, command).Observe(latency.Seconds())
+
+	switch {
+	case errorCode < 0:
+		m.errors.WithLabelValues(name, command, "no_reply").Inc()
+	case errorCode > 0:
+		m.errors.WithLabelValues(name, command, strconv.Itoa(errorCode)).Inc()
+	}
+}
+
+func (m *Metrics) ObserveAnimationFrames(dev *divoom.Device, n int) {
+	m.frames.WithLabelValues(deviceName(dev)).Add(float64(n))
+}
+
+func deviceName(dev *divoom.Device) string {
+	if dev.DeviceName != "" {
+		return dev.DeviceName
+	}
+	return dev.DevicePrivateIP
+}
+
+// Exporter polls state of the devices and exports it as gauges.
+type Exporter struct {
+	up         *prometheus.GaugeVec
+	brightness *prometheus.GaugeVec
+	channel    *prometheus.GaugeVec
+	screen     *prometheus.GaugeVec
+	drift      *prometheus.GaugeVec
+	lastPoll   *prometheus.GaugeVec
+
+	mu      sync.Mutex
+	clients []*divoom.Client
+}
+
+// NewExporter returns Exporter registered to reg.
+func NewExporter(reg prometheus.Registerer) (*Exporter, error) {
+	e := &Exporter{
+		up: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "divoom_up",
+			Help: "1 if the device replied to the last poll.",
+		}, []string{"device"}),
+		brightness: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "divoom_brightness",
+			Help: "Brightness of the device, 0~100.",
+		}, []string{"device"}),
+		channel: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "divoom_channel",
+			Help: "Current channel index; 0 faces, 1 cloud, 2 visualizer, 3 custom.",
+		}, []string{"device"}),
+		screen: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "divoom_screen_on",
+			Help: "1 if screen is on.",
+		}, []string{"device"}),
+		drift: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "divoom_clock_drift_seconds",
+			Help: "How far device clock is ahead of the exporter.",
+		}, []string{"device"}),
+		lastPoll: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "divoom_last_poll_timestamp_seconds",
+			Help: "Unix time of the last successful poll.",
+		}, []string{"device"}),
+	}
+
+	for _, c := range []prometheus.Collector{e.up, e.brightness, e.channel, e.screen, e.drift, e.lastPoll} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// AddClient adds client of a device to poll.
+func (e *Exporter) AddClient(c *divoom.Client) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.clients = append(e.clients, c)
+}
+
+// Run polls the devices every interval until ctx is done.
+func (e *Exporter) Run(ctx context.Context, interval time.Duration) error {
+	tk := time.NewTicker(interval)
+	defer tk.Stop()
+
+	for {
+		e.Poll()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tk.C:
+		}
+	}
+}
+
+// Poll polls all devices concurrently once.
+func (e *Exporter) Poll() {
+	e.mu.Lock()
+	clients := append([]*divoom.Client(nil), e.clients...)
+	e.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, c := range clients {
+		wg.Add(1)
+		go func(c *divoom.Client) {
+			defer wg.Done()
+			e.poll(c)
+		}(c)
+	}
+	wg.Wait()
+}
+
+func (e *Exporter) poll(c *divoom.Client) {
+	name := deviceName(c.Device())
+
+	s, err := c.GetAllSetting()
+	if err != nil {
+		e.up.WithLabelValues(name).Set(0)
+		return
+	}
+	if v, ok := s["Brightness"].(float64); ok {
+		e.brightness.WithLabelValues(name).Set(v)
+	}
+	if v, ok := s["LightSwitch"].(float64); ok {
+		e.screen.WithLabelValues(name).Set(v)
+	}
+
+	ch, err := c.GetCurrentChannel()
+	if err != nil {
+		e.up.WithLabelValues(name).Set(0)
+		return
+	}
+	e.channel.WithLabelValues(name).Set(float64(ch))
+
+	drift, err := c.ClockDrift()
+	if err != nil {
+		e.up.WithLabelValues(name).Set(0)
+		return
+	}
+	e.drift.WithLabelValues(name).Set(drift.Seconds())
+
+	e.up.WithLabelValues(name).Set(1)
+	e.lastPoll.WithLabelValues(name).SetToCurrentTime()
+}
@@ -0,0 +1,164 @@
+package promexporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	divoom "github.com/suapapa/go_divoom"
+)
+
+// fakeDevice replies to commands with replies, by command. Other commands get error_code 0.
+// If down, it doesn't reply.
+type fakeDevice struct {
+	replies map[string]string
+	down    bool
+}
+
+func (d *fakeDevice) RoundTrip(req *http.Request) (*http.Response, error) {
+	if d.down {
+		return nil, fmt.Errorf("connection refused")
+	}
+	var cmd struct{ Command string }
+	if err := json.NewDecoder(req.Body).Decode(&cmd); err != nil {
+		return nil, err
+	}
+
+	reply, ok := d.replies[cmd.Command]
+	if !ok {
+		reply = `{"error_code": 0}`
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(reply)),
+		Request:    req,
+	}, nil
+}
+
+func newClient(name string, d *fakeDevice, opts ...divoom.ClientOption) *divoom.Client {
+	opts = append(opts, divoom.WithHTTPClient(&http.Client{Transport: d}))
+	return divoom.NewClient(&divoom.Device{DeviceName: name, DevicePrivateIP: "192.168.0.2"}, opts...)
+}
+
+func TestMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m, err := NewMetrics(reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := &fakeDevice{replies: map[string]string{
+		"Channel/OnOffScreen": `{"error_code": 1}`,
+	}}
+	c := newClient("office", d, divoom.WithMetrics(m))
+	if err := c.SetBrightness(30); err != nil {
+		t.Fatal(err)
+	}
+	c.ScreenSwitch(false)
+	imgs := []image.Image{image.NewRGBA(image.Rect(0, 0, 16, 16)), image.NewRGBA(image.Rect(0, 0, 16, 16))}
+	if err := c.SendAnimationImgs(1, []int{100, 100}, imgs); err != nil {
+		t.Fatal(err)
+	}
+	d.down = true
+	c.SetBrightness(30)
+
+	tcs := []struct {
+		c    prometheus.Collector
+		want string
+	}{
+		{m.frames, `
+# HELP divoom_animation_frames_total Number of animation frames sent to the device.
+# TYPE divoom_animation_frames_total counter
+divoom_animation_frames_total{device="office"} 2
+`},
+		{m.errors, `
+# HELP divoom_command_errors_total Number of failed commands by error_code of the device. error_code is no_reply if the device didn't reply.
+# TYPE divoom_command_errors_total counter
+divoom_command_errors_total{command="Channel/OnOffScreen",device="office",error_code="1"} 1
+divoom_command_errors_total{command="Channel/SetBrightness",device="office",error_code="no_reply"} 1
+`},
+		{m.commands, `
+# HELP divoom_commands_total Number of commands sent to the device.
+# TYPE divoom_commands_total counter
+divoom_commands_total{command="Channel/OnOffScreen",device="office"} 1
+divoom_commands_total{command="Channel/SetBrightness",device="office"} 2
+divoom_commands_total{command="Draw/SendHttpGif",device="office"} 2
+`},
+	}
+	for _, tc := range tcs {
+		if err := testutil.CollectAndCompare(tc.c, strings.NewReader(tc.want)); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// a histogram per command
+	if n := testutil.CollectAndCount(m.latency); n != 3 {
+		t.Errorf("latency of %d commands, want 3", n)
+	}
+}
+
+func TestExporterPoll(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	e, err := NewExporter(reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	office := &fakeDevice{replies: map[string]string{
+		"Channel/GetAllConf":   `{"error_code": 0, "Brightness": 50, "LightSwitch": 1}`,
+		"Channel/GetIndex":     `{"error_code": 0, "SelectIndex": 2}`,
+		"Device/GetDeviceTime": fmt.Sprintf(`{"error_code": 0, "UTCTime": %d, "LocalTime": %q}`, now.Unix(), now.UTC().Format("2006-01-02 15:04:05")),
+	}}
+	e.AddClient(newClient("office", office))
+	e.AddClient(newClient("kitchen", &fakeDevice{down: true}))
+	e.Poll()
+
+	tcs := []struct {
+		c    prometheus.Collector
+		want string
+	}{
+		{e.brightness, `
+# HELP divoom_brightness Brightness of the device, 0~100.
+# TYPE divoom_brightness gauge
+divoom_brightness{device="office"} 50
+`},
+		{e.channel, `
+# HELP divoom_channel Current channel index; 0 faces, 1 cloud, 2 visualizer, 3 custom.
+# TYPE divoom_channel gauge
+divoom_channel{device="office"} 2
+`},
+		{e.screen, `
+# HELP divoom_screen_on 1 if screen is on.
+# TYPE divoom_screen_on gauge
+divoom_screen_on{device="office"} 1
+`},
+		{e.up, `
+# HELP divoom_up 1 if the device replied to the last poll.
+# TYPE divoom_up gauge
+divoom_up{device="kitchen"} 0
+divoom_up{device="office"} 1
+`},
+	}
+	for _, tc := range tcs {
+		if err := testutil.CollectAndCompare(tc.c, strings.NewReader(tc.want)); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// drift and time of poll vary, but only of the device replied
+	if n := testutil.CollectAndCount(e.lastPoll); n != 1 {
+		t.Errorf("last poll of %d devices, want 1", n)
+	}
+	if n := testutil.CollectAndCount(e.drift); n != 1 {
+		t.Errorf("clock drift of %d devices, want 1", n)
+	}
+}
@@ -0,0 +1,95 @@
+package divoom
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+
+	"github.com/pkg/errors"
+	"rsc.io/qr"
+)
+
+// ErrQRTooLong is returned when the text can't be encoded in QR code fitting the panel.
+var ErrQRTooLong = fmt.Errorf("text is too long for QR code of the panel")
+
+// quiet zones around QR code in modules; 4 is the standard
+// and 2 is still read by most phone cameras.
+var qrQuietZones = []int{4, 2}
+
+// QRImage returns QR code of text fitting panel of size, 16, 32 or 64,
+// with quiet zone. The code is drawn in the biggest integer scale,
+// and the most error correction level of that scale.
+func QRImage(text string, size int) (image.Image, error) {
+	if size != 64 && size != 32 && size != 16 {
+		return nil, ErrInvalidPicWidth
+	}
+
+	var best *qr.Code
+	bestScale := 0
+	minModules := 0
+	for _, qz := range qrQuietZones {
+		for lv := qr.L; lv <= qr.H; lv++ {
+			code, err := qr.Encode(text, lv)
+			if err != nil {
+				continue
+			}
+			if minModules == 0 || code.Size < minModules {
+				minModules = code.Size
+			}
+			if scale := size / (code.Size + 2*qz); scale >= 1 && scale >= bestScale {
+				best, bestScale = code, scale
+			}
+		}
+		if best != nil {
+			break
+		}
+	}
+
+	if best == nil {
+		if minModules == 0 {
+			return nil, errors.Wrapf(ErrQRTooLong, "%d bytes can't be encoded", len(text))
+		}
+		return nil, errors.Wrapf(ErrQRTooLong, "%d bytes need at least %d pixels with quiet zone but panel is %d",
+			len(text), minModules+2*qrQuietZones[len(qrQuietZones)-1], size)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(img, img.Rect, image.NewUniform(color.White), image.Point{}, draw.Src)
+	off := (size - best.Size*bestScale) / 2
+	for y := 0; y < best.Size; y++ {
+		for x := 0; x < best.Size; x++ {
+			if !best.Black(x, y) {
+				continue
+			}
+			r := image.Rect(x*bestScale, y*bestScale, (x+1)*bestScale, (y+1)*bestScale).Add(image.Pt(off, off))
+			draw.Draw(img, r, image.NewUniform(color.Black), image.Point{}, draw.Src)
+		}
+	}
+	return img, nil
+}
+
+// SendQR shows QR code of text on panel of size.
+func (c *Client) SendQR(text string, size int) error {
+	img, err := QRImage(text, size)
+	if err != nil {
+		return errors.Wrap(err, "fail to send qr code")
+	}
+
+	err = c.ResetSendingAnimationPicID()
+	if err != nil {
+		return errors.Wrap(err, "fail to send qr code")
+	}
+	return c.SendAnimationImgs(1, []int{1000}, []image.Image{img})
+}
+
+// WiFiQRText returns text of QR code to join Wi-Fi network.
+// auth is "WPA", "WEP" or "nopass".
+func WiFiQRText(ssid, password, auth string) string {
+	esc := strings.NewReplacer(`\`, `\\`, `;`, `\;`, `,`, `\,`, `:`, `\:`, `"`, `\"`)
+	if auth == "" || auth == "nopass" {
+		return fmt.Sprintf("WIFI:T:nopass;S:%s;;", esc.Replace(ssid))
+	}
+	return fmt.Sprintf("WIFI:T:%s;S:%s;P:%s;;", auth, esc.Replace(ssid), esc.Replace(password))
+}
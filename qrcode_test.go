@@ -0,0 +1,91 @@
+package divoom
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"rsc.io/qr"
+)
+
+func TestQRImageTooLong(t *testing.T) {
+	tcs := []struct {
+		text string
+		size int
+	}{
+		// the smallest code, 21 modules, doesn't fit
+		{"hi", 16},
+		{strings.Repeat("a", 200), 32},
+		// longer than any code
+		{strings.Repeat("a", 8000), 64},
+	}
+	for _, tc := range tcs {
+		_, err := QRImage(tc.text, tc.size)
+		if errors.Cause(err) != ErrQRTooLong {
+			t.Errorf("%d bytes in %d: error = %v, want ErrQRTooLong", len(tc.text), tc.size, err)
+		}
+	}
+
+	if _, err := QRImage("hi", 48); err != ErrInvalidPicWidth {
+		t.Errorf("error = %v, want ErrInvalidPicWidth", err)
+	}
+}
+
+func TestQRImageScale(t *testing.T) {
+	tcs := []struct {
+		size      int
+		wantScale int
+	}{
+		// 21 modules and quiet zone of 4 modules at each side
+		{64, 2},
+		{32, 1},
+	}
+	// all levels of "hi" are 21 modules, so the most correction is used
+	code, err := qr.Encode("hi", qr.H)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range tcs {
+		img, err := QRImage("hi", tc.size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if img.Bounds() != image.Rect(0, 0, tc.size, tc.size) {
+			t.Fatalf("image of %v, want %dx%d", img.Bounds(), tc.size, tc.size)
+		}
+
+		off := (tc.size - code.Size*tc.wantScale) / 2
+		for y := 0; y < tc.size; y++ {
+			for x := 0; x < tc.size; x++ {
+				mx, my := (x-off)/tc.wantScale, (y-off)/tc.wantScale
+				want := color.Gray{Y: 0xff}
+				if x >= off && y >= off && code.Black(mx, my) {
+					want = color.Gray{}
+				}
+				if got := color.GrayModel.Convert(img.At(x, y)); got != want {
+					t.Fatalf("size %d: pixel at %d,%d = %v, want %v", tc.size, x, y, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestWiFiQRText(t *testing.T) {
+	tcs := []struct {
+		ssid, password, auth string
+		want                 string
+	}{
+		{"home", "secret", "WPA", "WIFI:T:WPA;S:home;P:secret;;"},
+		{"home", "", "", "WIFI:T:nopass;S:home;;"},
+		{"home", "ignored", "nopass", "WIFI:T:nopass;S:home;;"},
+		{`a;b,c`, `p\q"r:s`, "WEP", `WIFI:T:WEP;S:a\;b\,c;P:p\\q\"r\:s;;`},
+	}
+	for _, tc := range tcs {
+		if got := WiFiQRText(tc.ssid, tc.password, tc.auth); got != tc.want {
+			t.Errorf("WiFiQRText(%q, %q, %q) = %s, want %s", tc.ssid, tc.password, tc.auth, got, tc.want)
+		}
+	}
+}
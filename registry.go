@@ -0,0 +1,320 @@
+package divoom
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+var ErrDeviceNotRegistered = fmt.Errorf("device is not registered")
+
+// RegisteredDevice is a device in Registry.
+type RegisteredDevice struct {
+	ID        int    `yaml:"id" json:"id"`
+	IP        string `yaml:"ip" json:"ip"`
+	Model     string `yaml:"model,omitempty" json:"model,omitempty"`
+	PanelSize int    `yaml:"panel_size" json:"panel_size"`
+}
+
+// Device returns the registered device as Device for NewClient.
+func (rd *RegisteredDevice) Device(name string) *Device {
+	return &Device{
+		DeviceName:      name,
+		DeviceID:        rd.ID,
+		DevicePrivateIP: rd.IP,
+	}
+}
+
+// Registry maps friendly names to devices. It is saved as YAML like:
+//
+//	devices:
+//	  office:
+//	    id: 300000020
+//	    ip: 192.168.0.101
+//	    model: Pixoo64
+//	    panel_size: 64
+type Registry struct {
+	path string
+
+	mu      sync.Mutex
+	Devices map[string]*RegisteredDevice `yaml:"devices"`
+}
+
+// DefaultRegistryPath returns path of the registry, ~/.config/divoom/devices.yaml
+// on all platforms. $XDG_CONFIG_HOME is used instead of ~/.config if it is set.
+func DefaultRegistryPath() (string, error) {
+	path, err := configPath("devices.yaml")
+	if err != nil {
+		return "", errors.Wrap(err, "fail to get registry path")
+	}
+	return path, nil
+}
+
+// configPath returns path of file name in ~/.config/divoom, or in
+// $XDG_CONFIG_HOME/divoom if it is set.
+func configPath(name string) (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "divoom", name), nil
+}
+
+// LoadRegistry loads registry from path. Empty registry is returned if it doesn't exist.
+func LoadRegistry(path string) (*Registry, error) {
+	r := &Registry{
+		path:    path,
+		Devices: make(map[string]*RegisteredDevice),
+	}
+
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to load registry")
+	}
+
+	err = yaml.Unmarshal(b, r)
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to load registry")
+	}
+	if r.Devices == nil {
+		r.Devices = make(map[string]*RegisteredDevice)
+	}
+
+	return r, nil
+}
+
+// Save writes the registry to the path it was loaded from.
+func (r *Registry) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, err := yaml.Marshal(r)
+	if err != nil {
+		return errors.Wrap(err, "fail to save registry")
+	}
+	err = os.MkdirAll(filepath.Dir(r.path), 0755)
+	if err != nil {
+		return errors.Wrap(err, "fail to save registry")
+	}
+	err = os.WriteFile(r.path, b, 0644)
+	if err != nil {
+		return errors.Wrap(err, "fail to save registry")
+	}
+
+	return nil
+}
+
+// Names returns sorted names of the registered devices.
+func (r *Registry) Names() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(r.Devices))
+	for n := range r.Devices {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns copy of device of name, which isn't changed by Refresh.
+func (r *Registry) Get(name string) (*RegisteredDevice, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rd, ok := r.Devices[name]
+	if !ok {
+		return nil, ErrDeviceNotRegistered
+	}
+	cp := *rd
+	return &cp, nil
+}
+
+// Add registers d as name. Existing device of the name is replaced.
+func (r *Registry) Add(name string, d *Device) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Devices[name] = newRegisteredDevice(d)
+}
+
+func (r *Registry) Remove(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.Devices, name)
+}
+
+// Refresh finds devices in the LAN and updates the registry.
+// Registered devices are matched by DeviceID, and their IP is updated if it's moved.
+// New devices are added with their DeviceName. It doesn't save the registry.
+func (r *Registry) Refresh() (added, moved []string, err error) {
+	ds, err := findDevice()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "fail to refresh registry")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, d := range ds {
+		name, rd := r.findByIDLocked(d.DeviceID)
+		if rd != nil {
+			if rd.IP != d.DevicePrivateIP {
+				rd.IP = d.DevicePrivateIP
+				moved = append(moved, name)
+			}
+			continue
+		}
+
+		name = r.uniqueNameLocked(d.DeviceName)
+		r.Devices[name] = newRegisteredDevice(d)
+		added = append(added, name)
+	}
+
+	return added, moved, nil
+}
+
+// Resolve returns device of name, which is reachable if possible.
+// If the name isn't registered, or the device doesn't answer at its IP,
+// the registry is refreshed from the LAN, so a device moved by DHCP is found
+// again by its DeviceID. refreshed reports whether the registry should be saved.
+func (r *Registry) Resolve(name string) (rd *RegisteredDevice, refreshed bool, err error) {
+	rd, err = r.Get(name)
+	if err == nil && probeDevice(rd.IP) {
+		return rd, false, nil
+	}
+	if err != nil && err != ErrDeviceNotRegistered {
+		return nil, false, err
+	}
+
+	_, _, rerr := r.Refresh()
+	if rerr != nil {
+		if rd != nil {
+			// the device may be just slow; keep using the registered IP
+			return rd, false, nil
+		}
+		return nil, false, rerr
+	}
+
+	rd, err = r.Get(name)
+	if err != nil {
+		return nil, true, err
+	}
+	return rd, true, nil
+}
+
+// findDevice finds devices in the LAN. Tests replace it.
+var findDevice = FindDevice
+
+// probeDevice reports whether a device accepts connection at ip.
+var probeDevice = func(ip string) bool {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(ip, "80"), time.Second)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+func (r *Registry) findByIDLocked(id int) (string, *RegisteredDevice) {
+	for n, rd := range r.Devices {
+		if rd.ID == id {
+			return n, rd
+		}
+	}
+	return "", nil
+}
+
+func (r *Registry) uniqueNameLocked(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "divoom"
+	}
+	if _, ok := r.Devices[name]; !ok {
+		return name
+	}
+	for i := 2; ; i++ {
+		n := fmt.Sprintf("%s-%d", name, i)
+		if _, ok := r.Devices[n]; !ok {
+			return n
+		}
+	}
+}
+
+// hardwareModels are models of Hardware in the device list.
+// Only Pixoo64 is known yet; other models are guessed from DeviceName
+// by newRegisteredDevice.
+var hardwareModels = map[int]struct {
+	name      string
+	panelSize int
+}{
+	400: {"Pixoo64", 64},
+}
+
+func newRegisteredDevice(d *Device) *RegisteredDevice {
+	rd := &RegisteredDevice{
+		ID:        d.DeviceID,
+		IP:        d.DevicePrivateIP,
+		PanelSize: 64,
+	}
+
+	if m, ok := hardwareModels[d.Hardware]; ok {
+		rd.Model, rd.PanelSize = m.name, m.panelSize
+		return rd
+	}
+
+	// guess the model from the name for unknown hardware
+	n := strings.ToLower(strings.ReplaceAll(d.DeviceName, "-", ""))
+	switch {
+	case strings.Contains(n, "pixoo64"):
+		rd.Model = "Pixoo64"
+	case strings.Contains(n, "pixoo16"):
+		rd.Model, rd.PanelSize = "Pixoo16", 16
+	case strings.Contains(n, "pixoo"):
+		rd.Model = "Pixoo"
+	}
+
+	return rd
+}
+
+// ClientByName returns Client of the device registered as name in the default registry.
+// If the name isn't registered or the device moved, the registry is refreshed
+// from the LAN and saved. See Registry.Resolve.
+func ClientByName(name string) (*Client, error) {
+	path, err := DefaultRegistryPath()
+	if err != nil {
+		return nil, err
+	}
+	r, err := LoadRegistry(path)
+	if err != nil {
+		return nil, err
+	}
+
+	rd, refreshed, err := r.Resolve(name)
+	if refreshed {
+		if serr := r.Save(); serr != nil && err == nil {
+			err = serr
+		}
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "fail to get client of %s", name)
+	}
+
+	return NewClient(rd.Device(name)), nil
+}
@@ -0,0 +1,140 @@
+package divoom
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// fakeLAN replaces device discovery and probing of the registry during the test.
+func fakeLAN(t *testing.T, devices []*Device, findErr error, reachable map[string]bool) *int {
+	t.Helper()
+	finds := new(int)
+	oldFind, oldProbe := findDevice, probeDevice
+	findDevice = func() ([]*Device, error) {
+		*finds++
+		return devices, findErr
+	}
+	probeDevice = func(ip string) bool { return reachable[ip] }
+	t.Cleanup(func() { findDevice, probeDevice = oldFind, oldProbe })
+	return finds
+}
+
+func newTestRegistry(t *testing.T) *Registry {
+	t.Helper()
+	r, err := LoadRegistry(filepath.Join(t.TempDir(), "devices.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Add("office", &Device{DeviceID: 1, DevicePrivateIP: "10.0.0.1", Hardware: 400})
+	return r
+}
+
+func TestRegistryRefresh(t *testing.T) {
+	r := newTestRegistry(t)
+	fakeLAN(t, []*Device{
+		{DeviceName: "Pixoo64", DeviceID: 1, DevicePrivateIP: "10.0.0.5"}, // moved by DHCP
+		{DeviceName: "office", DeviceID: 2, DevicePrivateIP: "10.0.0.2"},
+		{DeviceName: "Pixoo-16", DeviceID: 3, DevicePrivateIP: "10.0.0.3"},
+	}, nil, nil)
+
+	added, moved, err := r.Refresh()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(added, []string{"office-2", "Pixoo-16"}) {
+		t.Errorf("added %v", added)
+	}
+	if !reflect.DeepEqual(moved, []string{"office"}) {
+		t.Errorf("moved %v", moved)
+	}
+
+	want := map[string]RegisteredDevice{
+		"office":   {ID: 1, IP: "10.0.0.5", Model: "Pixoo64", PanelSize: 64},
+		"office-2": {ID: 2, IP: "10.0.0.2", PanelSize: 64},
+		"Pixoo-16": {ID: 3, IP: "10.0.0.3", Model: "Pixoo16", PanelSize: 16},
+	}
+	for name, w := range want {
+		rd, err := r.Get(name)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if *rd != w {
+			t.Errorf("%s = %+v, want %+v", name, *rd, w)
+		}
+	}
+}
+
+func TestRegistryResolve(t *testing.T) {
+	lan := []*Device{{DeviceName: "Pixoo64", DeviceID: 1, DevicePrivateIP: "10.0.0.5", Hardware: 400}}
+	tcs := []struct {
+		name          string
+		device        string
+		findErr       error
+		reachable     map[string]bool
+		wantIP        string
+		wantRefreshed bool
+		wantErr       error
+		wantFinds     int
+	}{
+		{"reachable", "office", nil, map[string]bool{"10.0.0.1": true}, "10.0.0.1", false, nil, 0},
+		{"moved", "office", nil, nil, "10.0.0.5", true, nil, 1},
+		{"unknown device", "kitchen", nil, nil, "", true, ErrDeviceNotRegistered, 1},
+		// slow device keeps the registered IP
+		{"refresh fails", "office", fmt.Errorf("offline"), nil, "10.0.0.1", false, nil, 1},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newTestRegistry(t)
+			finds := fakeLAN(t, lan, tc.findErr, tc.reachable)
+
+			rd, refreshed, err := r.Resolve(tc.device)
+			if err != tc.wantErr {
+				t.Fatalf("error = %v, want %v", err, tc.wantErr)
+			}
+			if refreshed != tc.wantRefreshed {
+				t.Errorf("refreshed = %v, want %v", refreshed, tc.wantRefreshed)
+			}
+			if err == nil && rd.IP != tc.wantIP {
+				t.Errorf("IP = %s, want %s", rd.IP, tc.wantIP)
+			}
+			if *finds != tc.wantFinds {
+				t.Errorf("found devices %d times, want %d", *finds, tc.wantFinds)
+			}
+		})
+	}
+}
+
+func TestRegistrySaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "divoom", "devices.yaml")
+	r, err := LoadRegistry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Names()) != 0 {
+		t.Fatalf("registry of no file has %v", r.Names())
+	}
+
+	r.Add("office", &Device{DeviceID: 1, DevicePrivateIP: "10.0.0.1", Hardware: 400})
+	r.Add("desk", &Device{DeviceName: "Pixoo", DeviceID: 2, DevicePrivateIP: "10.0.0.2"})
+	if err := r.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadRegistry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded.Names(), []string{"desk", "office"}) {
+		t.Fatalf("loaded %v", loaded.Names())
+	}
+	for _, name := range loaded.Names() {
+		want, _ := r.Get(name)
+		got, _ := loaded.Get(name)
+		if *got != *want {
+			t.Errorf("%s = %+v, want %+v", name, *got, *want)
+		}
+	}
+}
@@ -0,0 +1,478 @@
+package divoom
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var ErrNoCoordinates = fmt.Errorf("coordinates are required for sunrise and sunset; call WeatherAreaSetting or SetCoordinates")
+
+type ScreenState int
+
+const (
+	ScreenKeep ScreenState = iota
+	ScreenOn
+	ScreenOff
+)
+
+// Percent is a helper to fill Brightness of ScheduleRule.
+func Percent(v int) *int {
+	return &v
+}
+
+// ScheduleRule is a rule of Scheduler. A rule is either a cron rule or a time range rule.
+//
+// Cron rule, Cron is set, applies its setting when the 5 field cron expression
+// (minute hour day month weekday) matches, and the setting stays until other cron rule fires.
+// As in standard cron, if both day and weekday are restricted, either of them can match.
+//
+// Time range rule applies its setting while the time is in From~To, on top of
+// the cron rules. From and To take "HH:MM", "sunrise" or "sunset" with
+// optional offset like "sunset-30m". If To is earlier than From, the range
+// spans midnight.
+type ScheduleRule struct {
+	Name     string
+	Cron     string
+	From, To string
+	Weekdays []time.Weekday // empty for every day. for range over midnight, weekday of From
+
+	Brightness *int // nil keeps brightness
+	Screen     ScreenState
+	Ramp       time.Duration // time to change brightness smoothly
+}
+
+type scheduleRule struct {
+	ScheduleRule
+	cron     *cronSpec
+	from, to timeOfDay
+}
+
+type timeOfDay struct {
+	sun    int // 0 for clock time, 1 for sunrise, 2 for sunset
+	offset time.Duration
+}
+
+func parseTimeOfDay(s string) (timeOfDay, error) {
+	var tod timeOfDay
+	s = strings.TrimSpace(strings.ToLower(s))
+	for i, name := range []string{"sunrise", "sunset"} {
+		if !strings.HasPrefix(s, name) {
+			continue
+		}
+		tod.sun = i + 1
+		if off := s[len(name):]; off != "" {
+			d, err := time.ParseDuration(off)
+			if err != nil {
+				return tod, fmt.Errorf("invalid offset of %q", s)
+			}
+			tod.offset = d
+		}
+		return tod, nil
+	}
+
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return tod, fmt.Errorf("invalid time of day %q", s)
+	}
+	tod.offset = time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	return tod, nil
+}
+
+// ParseTimeZone parses time zone of SetTimeZone, like "GMT+9", to time.Location.
+// IANA names, like "Asia/Seoul", are also accepted.
+func ParseTimeZone(tz string) (*time.Location, error) {
+	if !strings.HasPrefix(tz, "GMT") && !strings.HasPrefix(tz, "UTC") {
+		return time.LoadLocation(tz)
+	}
+
+	off := tz[3:]
+	if off == "" {
+		return time.UTC, nil
+	}
+	var h, m int
+	i := strings.Index(off, ":")
+	if i < 0 {
+		i = len(off)
+	} else {
+		var err error
+		m, err = strconv.Atoi(off[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid time zone %q", tz)
+		}
+	}
+	h, err := strconv.Atoi(off[:i])
+	if err != nil || h < -12 || h > 14 {
+		return nil, fmt.Errorf("invalid time zone %q", tz)
+	}
+	sec := h*3600 + m*60
+	if strings.HasPrefix(off, "-") {
+		sec = h*3600 - m*60
+	}
+	return time.FixedZone(tz, sec), nil
+}
+
+const (
+	defaultScheduleInterval = 10 * time.Second
+	// catchUpWindow is how far back catchUp looks for cron rules fired.
+	catchUpWindow = 7 * 24 * time.Hour
+)
+
+// Scheduler changes brightness and turns screen on and off following rules.
+type Scheduler struct {
+	c     *Client
+	rules []*scheduleRule
+
+	// Interval is how often rules are checked. Ramps are stepped in the interval.
+	// Default is 10 seconds.
+	Interval time.Duration
+	// OnError is called with errors while running. Errors are ignored if nil.
+	OnError func(error)
+
+	mu            sync.Mutex
+	loc           *time.Location
+	lat, long     float64
+	hasCoord      bool
+	base          ScheduleRule // setting from Default and cron rules
+	lastMinute    time.Time
+	overrideUntil time.Time
+
+	sentScreen     ScreenState
+	sentBrightness int // -1 for unknown
+	rampFrom       int
+	rampTo         int
+	rampStart      time.Time
+	rampDur        time.Duration
+}
+
+// NewScheduler returns Scheduler of rules. def is used when no rule is applied.
+// Time zone and coordinates are taken from SetTimeZone and WeatherAreaSetting
+// called on the client, if any. Otherwise local time zone is used.
+func NewScheduler(c *Client, def ScheduleRule, rules []ScheduleRule) (*Scheduler, error) {
+	s := &Scheduler{
+		c:              c,
+		Interval:       defaultScheduleInterval,
+		loc:            time.Local,
+		base:           def,
+		sentBrightness: -1,
+		rampTo:         -1,
+	}
+
+	for _, r := range rules {
+		sr := &scheduleRule{ScheduleRule: r}
+		var err error
+		if r.Cron != "" {
+			sr.cron, err = parseCron(r.Cron)
+			if err != nil {
+				return nil, errors.Wrapf(err, "fail to parse rule %q", r.Name)
+			}
+		} else {
+			sr.from, err = parseTimeOfDay(r.From)
+			if err != nil {
+				return nil, errors.Wrapf(err, "fail to parse rule %q", r.Name)
+			}
+			sr.to, err = parseTimeOfDay(r.To)
+			if err != nil {
+				return nil, errors.Wrapf(err, "fail to parse rule %q", r.Name)
+			}
+		}
+		if r.Brightness != nil && (*r.Brightness < 0 || *r.Brightness > 100) {
+			return nil, errors.Wrapf(ErrInvalidBrightness, "fail to parse rule %q", r.Name)
+		}
+		s.rules = append(s.rules, sr)
+	}
+
+	c.mu.Lock()
+	tz, long, lat := c.timeZone, c.long, c.lat
+	c.mu.Unlock()
+
+	if tz != "" {
+		loc, err := ParseTimeZone(tz)
+		if err != nil {
+			return nil, errors.Wrap(err, "fail to use device time zone")
+		}
+		s.loc = loc
+	}
+	if long != "" && lat != "" {
+		lo, err1 := strconv.ParseFloat(long, 64)
+		la, err2 := strconv.ParseFloat(lat, 64)
+		if err1 == nil && err2 == nil {
+			s.lat, s.long, s.hasCoord = la, lo, true
+		}
+	}
+
+	return s, nil
+}
+
+// SetLocation sets time zone for the rules.
+func (s *Scheduler) SetLocation(loc *time.Location) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.loc = loc
+}
+
+// SetCoordinates sets latitude and longitude for sunrise and sunset.
+func (s *Scheduler) SetCoordinates(lat, long float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lat, s.long, s.hasCoord = lat, long, true
+}
+
+// Override applies setting now and holds it for d, regardless of the rules.
+func (s *Scheduler) Override(setting ScheduleRule, d time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.overrideUntil = time.Now().Add(d)
+	if setting.Screen != ScreenKeep {
+		err := s.c.ScreenSwitch(setting.Screen == ScreenOn)
+		if err != nil {
+			return errors.Wrap(err, "fail to override")
+		}
+		s.sentScreen = setting.Screen
+	}
+	if setting.Brightness != nil {
+		err := s.c.SetBrightness(*setting.Brightness)
+		if err != nil {
+			return errors.Wrap(err, "fail to override")
+		}
+		s.sentBrightness = *setting.Brightness
+		s.rampTo = -1
+	}
+
+	return nil
+}
+
+// ClearOverride ends override window, if any, from next check.
+func (s *Scheduler) ClearOverride() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.overrideUntil = time.Time{}
+}
+
+// Run checks the rules every Interval until ctx is done.
+// Before the first check, settings of cron rules which fired most recently,
+// within a week, are applied. So the device gets the setting it would have now
+// if the scheduler was running.
+func (s *Scheduler) Run(ctx context.Context) error {
+	iv := s.Interval
+	if iv <= 0 {
+		iv = defaultScheduleInterval
+	}
+	tk := time.NewTicker(iv)
+	defer tk.Stop()
+
+	s.catchUp(time.Now())
+
+	for {
+		if err := s.Step(time.Now()); err != nil && s.OnError != nil {
+			s.OnError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tk.C:
+		}
+	}
+}
+
+// catchUp applies the last brightness and screen set by cron rules before now.
+// Rules are fixed after NewScheduler, so they are scanned without the lock.
+func (s *Scheduler) catchUp(now time.Time) {
+	s.mu.Lock()
+	loc := s.loc
+	s.mu.Unlock()
+
+	var last ScheduleRule
+	var hasBrightness, hasScreen bool
+	minute := now.In(loc).Truncate(time.Minute)
+	end := minute.Add(-catchUpWindow)
+	for t := minute.Add(-time.Minute); t.After(end) && !(hasBrightness && hasScreen); t = t.Add(-time.Minute) {
+		// later rules win in the same minute, as in Step
+		for i := len(s.rules) - 1; i >= 0; i-- {
+			r := s.rules[i]
+			if r.cron == nil || !r.cron.match(t) {
+				continue
+			}
+			if !hasBrightness && r.Brightness != nil {
+				last.Brightness, last.Ramp = r.Brightness, r.Ramp
+				hasBrightness = true
+			}
+			if !hasScreen && r.Screen != ScreenKeep {
+				last.Screen = r.Screen
+				hasScreen = true
+			}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.base = mergeSetting(s.base, last)
+}
+
+// Step checks the rules at now and sends changes to the device.
+// Run calls it periodically.
+func (s *Scheduler) Step(now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now = now.In(s.loc)
+
+	// cron rules fire once in a minute, even if overridden
+	minute := now.Truncate(time.Minute)
+	if !minute.Equal(s.lastMinute) {
+		s.lastMinute = minute
+		for _, r := range s.rules {
+			if r.cron != nil && r.cron.match(minute) {
+				s.base = mergeSetting(s.base, r.ScheduleRule)
+			}
+		}
+	}
+
+	if now.Before(s.overrideUntil) {
+		return nil
+	}
+
+	want, err := s.desiredLocked(now)
+	if err != nil {
+		return errors.Wrap(err, "fail to run schedule")
+	}
+
+	if want.Screen != ScreenKeep && want.Screen != s.sentScreen {
+		err := s.c.ScreenSwitch(want.Screen == ScreenOn)
+		if err != nil {
+			return errors.Wrap(err, "fail to run schedule")
+		}
+		s.sentScreen = want.Screen
+	}
+
+	if want.Brightness == nil {
+		return nil
+	}
+	if *want.Brightness != s.rampTo {
+		s.rampFrom = s.sentBrightness
+		if s.rampFrom < 0 {
+			s.rampFrom = *want.Brightness
+		}
+		s.rampTo = *want.Brightness
+		s.rampStart = now
+		s.rampDur = want.Ramp
+	}
+
+	b := s.rampTo
+	if elapsed := now.Sub(s.rampStart); elapsed < s.rampDur {
+		b = s.rampFrom + int(float64(s.rampTo-s.rampFrom)*float64(elapsed)/float64(s.rampDur))
+	}
+	if b != s.sentBrightness {
+		err := s.c.SetBrightness(b)
+		if err != nil {
+			return errors.Wrap(err, "fail to run schedule")
+		}
+		s.sentBrightness = b
+	}
+
+	return nil
+}
+
+// Desired returns setting the rules want at t.
+func (s *Scheduler) Desired(t time.Time) (ScheduleRule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.desiredLocked(t.In(s.loc))
+}
+
+func (s *Scheduler) desiredLocked(t time.Time) (ScheduleRule, error) {
+	want := s.base
+	for _, r := range s.rules {
+		if r.cron != nil {
+			continue
+		}
+		in, err := s.inRange(r, t)
+		if err != nil {
+			return want, err
+		}
+		if in {
+			want = mergeSetting(want, r.ScheduleRule)
+		}
+	}
+	return want, nil
+}
+
+func (s *Scheduler) inRange(r *scheduleRule, t time.Time) (bool, error) {
+	// range may start yesterday and span midnight
+	for _, dayOff := range []int{0, -1} {
+		day := t.AddDate(0, 0, dayOff)
+		if len(r.Weekdays) > 0 && !hasWeekday(r.Weekdays, day.Weekday()) {
+			continue
+		}
+
+		from, err := s.resolve(r.from, day)
+		if err != nil {
+			return false, err
+		}
+		to, err := s.resolve(r.to, day)
+		if err != nil {
+			return false, err
+		}
+		if !to.After(from) {
+			to = to.AddDate(0, 0, 1)
+		}
+
+		if !t.Before(from) && t.Before(to) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *Scheduler) resolve(tod timeOfDay, day time.Time) (time.Time, error) {
+	y, m, d := day.Date()
+	midnight := time.Date(y, m, d, 0, 0, 0, 0, s.loc)
+	if tod.sun == 0 {
+		return midnight.Add(tod.offset), nil
+	}
+
+	if !s.hasCoord {
+		return time.Time{}, ErrNoCoordinates
+	}
+	rise, set, ok := SunTimes(midnight.Add(12*time.Hour), s.lat, s.long, s.loc)
+	if !ok {
+		// polar day or night; treat the sun event as midnight
+		return midnight.Add(tod.offset), nil
+	}
+	if tod.sun == 1 {
+		return rise.Add(tod.offset), nil
+	}
+	return set.Add(tod.offset), nil
+}
+
+func mergeSetting(base, r ScheduleRule) ScheduleRule {
+	if r.Brightness != nil {
+		base.Brightness = r.Brightness
+		base.Ramp = r.Ramp
+	}
+	if r.Screen != ScreenKeep {
+		base.Screen = r.Screen
+	}
+	return base
+}
+
+func hasWeekday(wds []time.Weekday, wd time.Weekday) bool {
+	for _, w := range wds {
+		if w == wd {
+			return true
+		}
+	}
+	return false
+}
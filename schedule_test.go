@@ -0,0 +1,198 @@
+package divoom
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseTimeZone(t *testing.T) {
+	tcs := []struct {
+		tz      string
+		wantOff int
+	}{
+		{"GMT", 0},
+		{"GMT+9", 9 * 3600},
+		{"UTC+9", 9 * 3600},
+		{"GMT-5", -5 * 3600},
+		{"GMT+5:30", 5*3600 + 30*60},
+		{"GMT-3:30", -3*3600 - 30*60},
+		{"GMT+14", 14 * 3600},
+	}
+	at := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, tc := range tcs {
+		loc, err := ParseTimeZone(tc.tz)
+		if err != nil {
+			t.Errorf("%s: %v", tc.tz, err)
+			continue
+		}
+		if _, off := at.In(loc).Zone(); off != tc.wantOff {
+			t.Errorf("%s: offset = %d, want %d", tc.tz, off, tc.wantOff)
+		}
+	}
+
+	if _, err := time.LoadLocation("Asia/Seoul"); err == nil {
+		loc, err := ParseTimeZone("Asia/Seoul")
+		if err != nil || loc.String() != "Asia/Seoul" {
+			t.Errorf("Asia/Seoul: %v, %v", loc, err)
+		}
+	}
+
+	for _, tz := range []string{"GMT+15", "GMT-13", "GMT+a", "GMT+9:xx", "Nowhere/City"} {
+		if _, err := ParseTimeZone(tz); err == nil {
+			t.Errorf("no error for %q", tz)
+		}
+	}
+}
+
+func TestParseTimeOfDay(t *testing.T) {
+	tcs := []struct {
+		s    string
+		want timeOfDay
+	}{
+		{"07:30", timeOfDay{offset: 7*time.Hour + 30*time.Minute}},
+		{"sunrise", timeOfDay{sun: 1}},
+		{"Sunset-30m", timeOfDay{sun: 2, offset: -30 * time.Minute}},
+		{"sunrise+1h", timeOfDay{sun: 1, offset: time.Hour}},
+	}
+	for _, tc := range tcs {
+		got, err := parseTimeOfDay(tc.s)
+		if err != nil || got != tc.want {
+			t.Errorf("%q: got %+v, %v, want %+v", tc.s, got, err, tc.want)
+		}
+	}
+
+	for _, s := range []string{"25:00", "7pm", "sunset-", "noon"} {
+		if _, err := parseTimeOfDay(s); err == nil {
+			t.Errorf("no error for %q", s)
+		}
+	}
+}
+
+func TestSchedulerDesired(t *testing.T) {
+	s, err := NewScheduler(NewClient(&Device{}), ScheduleRule{Brightness: Percent(80), Screen: ScreenOn}, []ScheduleRule{
+		{Name: "night", From: "22:00", To: "07:00", Brightness: Percent(10)},
+		{Name: "weekend off", From: "01:00", To: "06:00", Weekdays: []time.Weekday{time.Saturday}, Screen: ScreenOff},
+		{Name: "dusk", From: "sunset-1h", To: "sunset", Brightness: Percent(50)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetLocation(time.UTC)
+
+	// 2022-06-04 is Saturday
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2022, 6, day, hour, min, 0, 0, time.UTC)
+	}
+
+	if _, err := s.Desired(at(4, 12, 0)); err != ErrNoCoordinates {
+		t.Errorf("error = %v, want ErrNoCoordinates", err)
+	}
+	// London, sunset is about 20:20 UTC in June
+	s.SetCoordinates(51.5074, -0.1278)
+
+	tcs := []struct {
+		t              time.Time
+		wantBrightness int
+		wantScreen     ScreenState
+	}{
+		{at(4, 12, 0), 80, ScreenOn},
+		{at(4, 19, 45), 50, ScreenOn},
+		{at(4, 20, 45), 80, ScreenOn},
+		{at(4, 23, 0), 10, ScreenOn},
+		{at(4, 3, 0), 10, ScreenOff},
+		{at(5, 3, 0), 10, ScreenOn},
+		{at(5, 7, 0), 80, ScreenOn},
+	}
+	for _, tc := range tcs {
+		got, err := s.Desired(tc.t)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *got.Brightness != tc.wantBrightness || got.Screen != tc.wantScreen {
+			t.Errorf("at %v: brightness %d, screen %d, want %d, %d",
+				tc.t, *got.Brightness, got.Screen, tc.wantBrightness, tc.wantScreen)
+		}
+	}
+}
+
+func TestSchedulerCatchUp(t *testing.T) {
+	s, err := NewScheduler(NewClient(&Device{}), ScheduleRule{Brightness: Percent(80), Screen: ScreenOn}, []ScheduleRule{
+		{Name: "morning", Cron: "0 7 * * *", Brightness: Percent(100)},
+		{Name: "evening", Cron: "30 21 * * *", Brightness: Percent(20)},
+		{Name: "sleep", Cron: "0 23 * * 1-5", Screen: ScreenOff},
+		{Name: "wake", Cron: "0 6 * * *", Screen: ScreenOn},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetLocation(time.UTC)
+
+	// 2022-06-06 is Monday
+	tcs := []struct {
+		now            time.Time
+		wantBrightness int
+		wantScreen     ScreenState
+	}{
+		{time.Date(2022, 6, 6, 23, 30, 0, 0, time.UTC), 20, ScreenOff},
+		{time.Date(2022, 6, 7, 6, 30, 0, 0, time.UTC), 20, ScreenOn},
+		{time.Date(2022, 6, 7, 12, 0, 0, 0, time.UTC), 100, ScreenOn},
+		// not fired yet in the current minute; Step takes it
+		{time.Date(2022, 6, 7, 7, 0, 30, 0, time.UTC), 20, ScreenOn},
+		// sleep doesn't fire on Saturday
+		{time.Date(2022, 6, 4, 23, 30, 0, 0, time.UTC), 20, ScreenOn},
+	}
+	for _, tc := range tcs {
+		s.base = ScheduleRule{Brightness: Percent(80)}
+		s.catchUp(tc.now)
+		got, err := s.Desired(tc.now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *got.Brightness != tc.wantBrightness || got.Screen != tc.wantScreen {
+			t.Errorf("at %v: brightness %d, screen %d, want %d, %d",
+				tc.now, *got.Brightness, got.Screen, tc.wantBrightness, tc.wantScreen)
+		}
+	}
+}
+
+func TestSchedulerCatchUpWindow(t *testing.T) {
+	s, err := NewScheduler(NewClient(&Device{}), ScheduleRule{Brightness: Percent(80)}, []ScheduleRule{
+		{Name: "new year", Cron: "0 0 1 1 *", Brightness: Percent(10)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetLocation(time.UTC)
+
+	tcs := []struct {
+		now            time.Time
+		wantBrightness int
+	}{
+		{time.Date(2022, 1, 7, 12, 0, 0, 0, time.UTC), 10},
+		// fired longer ago than the window
+		{time.Date(2022, 1, 9, 12, 0, 0, 0, time.UTC), 80},
+	}
+	for _, tc := range tcs {
+		s.base = ScheduleRule{Brightness: Percent(80)}
+		s.catchUp(tc.now)
+		if got := *s.base.Brightness; got != tc.wantBrightness {
+			t.Errorf("at %v: brightness %d, want %d", tc.now, got, tc.wantBrightness)
+		}
+	}
+}
+
+func TestSchedulerRunZeroInterval(t *testing.T) {
+	c, _ := newFakeClient()
+	s, err := NewScheduler(c, ScheduleRule{Brightness: Percent(80)}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Interval = 0
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := s.Run(ctx); err != context.Canceled {
+		t.Errorf("Run returned %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,332 @@
+package divoom
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var ErrNothingToUndo = fmt.Errorf("nothing to undo")
+
+type Team string
+
+const (
+	TeamRed  Team = "red"
+	TeamBlue Team = "blue"
+)
+
+// ScoreEvent is a change of score in Scoreboard history.
+type ScoreEvent struct {
+	Time  time.Time `json:"time"`
+	Team  Team      `json:"team"`
+	Delta int       `json:"delta"`
+	Red   int       `json:"red"`
+	Blue  int       `json:"blue"`
+}
+
+type scoreboardState struct {
+	Red     int           `json:"red"`
+	Blue    int           `json:"blue"`
+	History []*ScoreEvent `json:"history"`
+}
+
+// Scoreboard keeps scores of scoreboard tool and sends them to the device
+// on every change. If path is given, scores and history are saved to it
+// as JSON so they survive restarts.
+type Scoreboard struct {
+	c    *Client
+	path string
+
+	mu    sync.Mutex
+	state scoreboardState
+}
+
+// NewScoreboard returns Scoreboard and loads saved scores from path, if exists.
+// Loaded scores are sent to the device. Empty path disables persistence.
+func NewScoreboard(c *Client, path string) (*Scoreboard, error) {
+	sb := &Scoreboard{
+		c:    c,
+		path: path,
+	}
+
+	if path == "" {
+		return sb, nil
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return sb, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to load scoreboard")
+	}
+	defer f.Close()
+
+	err = json.NewDecoder(f).Decode(&sb.state)
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to load scoreboard")
+	}
+
+	err = sb.c.SetScoreboardTool(sb.state.Red, sb.state.Blue)
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to load scoreboard")
+	}
+
+	return sb, nil
+}
+
+// Scores returns current red and blue scores.
+func (sb *Scoreboard) Scores() (red, blue int) {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+
+	return sb.state.Red, sb.state.Blue
+}
+
+// History returns the events since last reset, oldest first.
+func (sb *Scoreboard) History() []ScoreEvent {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+
+	h := make([]ScoreEvent, len(sb.state.History))
+	for i, e := range sb.state.History {
+		h[i] = *e
+	}
+	return h
+}
+
+// Sync sends current scores to the device.
+func (sb *Scoreboard) Sync() error {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+
+	return sb.c.SetScoreboardTool(sb.state.Red, sb.state.Blue)
+}
+
+func (sb *Scoreboard) AddRed(delta int) error {
+	return sb.add(TeamRed, delta)
+}
+
+func (sb *Scoreboard) AddBlue(delta int) error {
+	return sb.add(TeamBlue, delta)
+}
+
+func (sb *Scoreboard) add(team Team, delta int) error {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+
+	red, blue := sb.state.Red, sb.state.Blue
+	switch team {
+	case TeamRed:
+		red += delta
+	case TeamBlue:
+		blue += delta
+	default:
+		return fmt.Errorf("unknown team: %s", team)
+	}
+
+	err := sb.c.SetScoreboardTool(red, blue)
+	if err != nil {
+		return errors.Wrap(err, "fail to add score")
+	}
+
+	sb.state.Red, sb.state.Blue = red, blue
+	sb.state.History = append(sb.state.History, &ScoreEvent{
+		Time:  time.Now(),
+		Team:  team,
+		Delta: delta,
+		Red:   red,
+		Blue:  blue,
+	})
+
+	return sb.save()
+}
+
+// Undo reverts the last change of score.
+func (sb *Scoreboard) Undo() error {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+
+	n := len(sb.state.History)
+	if n == 0 {
+		return ErrNothingToUndo
+	}
+
+	var red, blue int
+	if n > 1 {
+		prev := sb.state.History[n-2]
+		red, blue = prev.Red, prev.Blue
+	}
+
+	err := sb.c.SetScoreboardTool(red, blue)
+	if err != nil {
+		return errors.Wrap(err, "fail to undo score")
+	}
+
+	sb.state.Red, sb.state.Blue = red, blue
+	sb.state.History = sb.state.History[:n-1]
+
+	return sb.save()
+}
+
+// Reset sets both scores to 0 and clears history.
+func (sb *Scoreboard) Reset() error {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+
+	err := sb.c.SetScoreboardTool(0, 0)
+	if err != nil {
+		return errors.Wrap(err, "fail to reset score")
+	}
+
+	sb.state = scoreboardState{}
+
+	return sb.save()
+}
+
+func (sb *Scoreboard) save() error {
+	if sb.path == "" {
+		return nil
+	}
+
+	b, err := json.MarshalIndent(&sb.state, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "fail to save scoreboard")
+	}
+
+	// write to temp file and rename not to break saved scores on crash
+	tmp := sb.path + ".tmp"
+	err = os.WriteFile(tmp, b, 0644)
+	if err != nil {
+		return errors.Wrap(err, "fail to save scoreboard")
+	}
+	err = os.Rename(tmp, sb.path)
+	if err != nil {
+		return errors.Wrap(err, "fail to save scoreboard")
+	}
+
+	return nil
+}
+
+// ServeHTTP serves scoreboard to the LAN.
+//
+//	GET  /              page with +/- buttons for phones
+//	GET  /api           current scores and history as JSON
+//	POST /api/red?d=1   add d (default 1) to red. d can be negative
+//	POST /api/blue?d=1  add d (default 1) to blue
+//	POST /api/undo      revert the last change
+//	POST /api/reset     reset scores
+//
+// POST requests also reply the scores as JSON.
+func (sb *Scoreboard) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(scoreboardPage))
+		return
+	}
+
+	var err error
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/api":
+		// show only
+	case r.Method != http.MethodPost:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	case r.URL.Path == "/api/red" || r.URL.Path == "/api/blue":
+		d := 1
+		if v := r.URL.Query().Get("d"); v != "" {
+			d, err = strconv.Atoi(v)
+			if err != nil {
+				http.Error(w, "invalid d", http.StatusBadRequest)
+				return
+			}
+		}
+		err = sb.add(Team(r.URL.Path[len("/api/"):]), d)
+	case r.URL.Path == "/api/undo":
+		err = sb.Undo()
+	case r.URL.Path == "/api/reset":
+		err = sb.Reset()
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	if err == ErrNothingToUndo || errors.Cause(err) == ErrInvalidScore {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&sb.state)
+}
+
+// scoreboardPage uses relative paths, so the handler can be mounted under
+// a prefix with http.StripPrefix.
+const scoreboardPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Scoreboard</title>
+<style>
+body { font-family: sans-serif; text-align: center; margin: 0; }
+.teams { display: flex; }
+.team { flex: 1; padding: 1em 0; color: white; }
+.red { background: #d33; }
+.blue { background: #33d; }
+.score { font-size: 5em; margin: 0.2em 0; }
+button { font-size: 1.5em; min-width: 3em; margin: 0.2em; }
+#err { color: #d33; }
+</style>
+</head>
+<body>
+<div class="teams">
+  <div class="team red">
+    <div class="score" id="red">0</div>
+    <button onclick="post('api/red?d=-1')">-</button>
+    <button onclick="post('api/red')">+</button>
+  </div>
+  <div class="team blue">
+    <div class="score" id="blue">0</div>
+    <button onclick="post('api/blue?d=-1')">-</button>
+    <button onclick="post('api/blue')">+</button>
+  </div>
+</div>
+<p>
+  <button onclick="post('api/undo')">Undo</button>
+  <button onclick="confirm('Reset scores?') && post('api/reset')">Reset</button>
+</p>
+<p id="err"></p>
+<script>
+function show(resp) {
+  if (!resp.ok) {
+    return resp.text().then(function (t) { document.getElementById('err').textContent = t; });
+  }
+  return resp.json().then(function (s) {
+    document.getElementById('red').textContent = s.red;
+    document.getElementById('blue').textContent = s.blue;
+    document.getElementById('err').textContent = '';
+  });
+}
+function post(path) { fetch(path, {method: 'POST'}).then(show); }
+fetch('api').then(show);
+</script>
+</body>
+</html>
+`
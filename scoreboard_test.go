@@ -0,0 +1,155 @@
+package divoom
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeDevice replies error_code 0 to every command and keeps them.
+type fakeDevice struct {
+	mu   sync.Mutex
+	cmds []map[string]interface{}
+}
+
+func (d *fakeDevice) RoundTrip(req *http.Request) (*http.Response, error) {
+	cmd := make(map[string]interface{})
+	err := json.NewDecoder(req.Body).Decode(&cmd)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	d.cmds = append(d.cmds, cmd)
+	d.mu.Unlock()
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`{"error_code": 0}`)),
+		Request:    req,
+	}, nil
+}
+
+func (d *fakeDevice) last() map[string]interface{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.cmds) == 0 {
+		return nil
+	}
+	return d.cmds[len(d.cmds)-1]
+}
+
+func newFakeClient() (*Client, *fakeDevice) {
+	d := &fakeDevice{}
+	return NewClient(&Device{DevicePrivateIP: "pixoo"}, WithHTTPClient(&http.Client{Transport: d})), d
+}
+
+func TestScoreboardLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "score.json")
+	err := os.WriteFile(path, []byte(`{"red": 3, "blue": 5, "history": []}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, dev := newFakeClient()
+	sb, err := NewScoreboard(c, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if red, blue := sb.Scores(); red != 3 || blue != 5 {
+		t.Errorf("scores = %d, %d, want 3, 5", red, blue)
+	}
+	cmd := dev.last()
+	if cmd["Command"] != "Tools/SetScoreBoard" || cmd["RedScore"] != float64(3) || cmd["BlueScore"] != float64(5) {
+		t.Errorf("loaded scores are not sent, last command %v", cmd)
+	}
+
+	// no saved file, nothing to send
+	c, dev = newFakeClient()
+	if _, err := NewScoreboard(c, filepath.Join(t.TempDir(), "none.json")); err != nil {
+		t.Fatal(err)
+	}
+	if cmd := dev.last(); cmd != nil {
+		t.Errorf("sent %v without saved scores", cmd)
+	}
+}
+
+func TestScoreboardHTTP(t *testing.T) {
+	c, dev := newFakeClient()
+	path := filepath.Join(t.TempDir(), "score.json")
+	sb, err := NewScoreboard(c, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(sb)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("content type of / = %q, want html", ct)
+	}
+
+	tcs := []struct {
+		method, path string
+		wantStatus   int
+		wantRed      int
+		wantBlue     int
+	}{
+		{http.MethodPost, "/api/red", 200, 1, 0},
+		{http.MethodPost, "/api/blue?d=3", 200, 1, 3},
+		{http.MethodPost, "/api/red?d=-1", 200, 0, 3},
+		{http.MethodPost, "/api/red?d=-1", 400, 0, 3},
+		{http.MethodPost, "/api/red?d=1x", 400, 0, 3},
+		{http.MethodPost, "/api/undo", 200, 1, 3},
+		{http.MethodGet, "/api", 200, 1, 3},
+		{http.MethodGet, "/api/red", 405, 1, 3},
+		{http.MethodPost, "/api/green", 404, 1, 3},
+		{http.MethodPost, "/api/reset", 200, 0, 0},
+		{http.MethodPost, "/api/undo", 400, 0, 0},
+	}
+	for _, tc := range tcs {
+		req, _ := http.NewRequest(tc.method, srv.URL+tc.path, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != tc.wantStatus {
+			t.Errorf("%s %s: status %d, want %d", tc.method, tc.path, resp.StatusCode, tc.wantStatus)
+		}
+		if resp.StatusCode == http.StatusOK {
+			var st scoreboardState
+			if err := json.NewDecoder(resp.Body).Decode(&st); err != nil {
+				t.Errorf("%s %s: %v", tc.method, tc.path, err)
+			}
+			if st.Red != tc.wantRed || st.Blue != tc.wantBlue {
+				t.Errorf("%s %s: scores %d, %d, want %d, %d", tc.method, tc.path, st.Red, st.Blue, tc.wantRed, tc.wantBlue)
+			}
+		}
+		resp.Body.Close()
+	}
+
+	if cmd := dev.last(); cmd["RedScore"] != float64(0) || cmd["BlueScore"] != float64(0) {
+		t.Errorf("last command %v, want reset", cmd)
+	}
+
+	// saved scores are loaded back
+	sb, err = NewScoreboard(c, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if red, blue := sb.Scores(); red != 0 || blue != 0 || len(sb.History()) != 0 {
+		t.Errorf("loaded %d, %d of %d events, want reset", red, blue, len(sb.History()))
+	}
+}
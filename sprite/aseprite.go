@@ -0,0 +1,514 @@
+package sprite
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+
+	"github.com/pkg/errors"
+	divoom "github.com/suapapa/go_divoom"
+)
+
+// Aseprite is sprite of .aseprite file.
+// Only normal blend mode of layers is supported and tilemap layers are ignored.
+type Aseprite struct {
+	Width  int
+	Height int
+	Layers []Layer
+	Tags   []Tag
+
+	frames       []asepriteFrame
+	frameCnt     int // frames in the header
+	depth        int
+	palette      color.Palette
+	transp       int  // transparent index of indexed sprite
+	layerOpacity bool // opacity of layers is valid
+}
+
+// Layer is a layer of Aseprite.
+type Layer struct {
+	Name    string
+	Visible bool
+	Opacity uint8
+	Group   bool
+	Level   int // child level; 0 is top level
+	Parent  int // index of parent group, or -1
+
+	background bool
+}
+
+type asepriteFrame struct {
+	duration int
+	cels     []*asepriteCel
+}
+
+type asepriteCel struct {
+	layer   int
+	x, y    int
+	opacity uint8
+	img     *image.NRGBA
+	link    int // frame of linked cel, or -1
+}
+
+const (
+	aseMagic   = 0xA5E0
+	frameMagic = 0xF1FA
+
+	chunkOldPalette = 0x0004
+	chunkLayer      = 0x2004
+	chunkCel        = 0x2005
+	chunkTags       = 0x2018
+	chunkPalette    = 0x2019
+
+	layerVisible    = 1
+	layerBackground = 8
+
+	// maxCelPixels limits size of a cel not to allocate too much for broken files.
+	maxCelPixels = 4096 * 4096
+)
+
+// aseReader reads little endian values of the file format,
+// keeping the first error.
+type aseReader struct {
+	r   io.Reader
+	err error
+}
+
+func (r *aseReader) read(v interface{}) {
+	if r.err == nil {
+		r.err = binary.Read(r.r, binary.LittleEndian, v)
+	}
+}
+
+func (r *aseReader) byte() uint8 {
+	var v uint8
+	r.read(&v)
+	return v
+}
+
+func (r *aseReader) word() uint16 {
+	var v uint16
+	r.read(&v)
+	return v
+}
+
+func (r *aseReader) short() int16 {
+	var v int16
+	r.read(&v)
+	return v
+}
+
+func (r *aseReader) dword() uint32 {
+	var v uint32
+	r.read(&v)
+	return v
+}
+
+func (r *aseReader) skip(n int) {
+	if r.err == nil {
+		_, r.err = io.CopyN(io.Discard, r.r, int64(n))
+	}
+}
+
+func (r *aseReader) string() string {
+	b := make([]byte, r.word())
+	r.read(b)
+	return string(b)
+}
+
+// DecodeAseprite reads .aseprite file from r.
+func DecodeAseprite(r io.Reader) (*Aseprite, error) {
+	ar := &aseReader{r: r}
+
+	ar.dword() // file size
+	if magic := ar.word(); ar.err == nil && magic != aseMagic {
+		return nil, fmt.Errorf("fail to decode aseprite: invalid magic %#x", magic)
+	}
+	a := &Aseprite{
+		frameCnt: int(ar.word()),
+		Width:    int(ar.word()),
+		Height:   int(ar.word()),
+		depth:    int(ar.word()),
+	}
+	flags := ar.dword()
+	ar.skip(2 + 4 + 4) // speed and reserved
+	a.layerOpacity = flags&1 != 0
+	a.transp = int(ar.byte())
+	ar.skip(3)
+	nColors := int(ar.word())
+	ar.skip(1 + 1 + 2 + 2 + 2 + 2 + 84) // pixel ratio, grid and reserved
+	if ar.err != nil {
+		return nil, errors.Wrap(ar.err, "fail to decode aseprite")
+	}
+	if a.depth != 32 && a.depth != 16 && a.depth != 8 {
+		return nil, fmt.Errorf("fail to decode aseprite: invalid color depth %d", a.depth)
+	}
+	if nColors == 0 {
+		nColors = 256
+	}
+	a.palette = make(color.Palette, nColors)
+	for i := range a.palette {
+		a.palette[i] = color.NRGBA{}
+	}
+
+	for i := 0; i < a.frameCnt; i++ {
+		f, err := a.readFrame(ar)
+		if err != nil {
+			return nil, errors.Wrapf(err, "fail to decode aseprite frame %d", i)
+		}
+		a.frames = append(a.frames, f)
+	}
+	return a, nil
+}
+
+func (a *Aseprite) readFrame(ar *aseReader) (asepriteFrame, error) {
+	size := ar.dword()
+	magic := ar.word()
+	oldChunks := int(ar.word())
+	f := asepriteFrame{duration: int(ar.word())}
+	ar.skip(2)
+	chunks := int(ar.dword())
+	if ar.err != nil {
+		return f, ar.err
+	}
+	if magic != frameMagic {
+		return f, fmt.Errorf("invalid magic %#x", magic)
+	}
+	if chunks == 0 {
+		chunks = oldChunks
+	}
+	if size < 16 {
+		return f, fmt.Errorf("invalid frame size %d", size)
+	}
+
+	body := make([]byte, int(size)-16)
+	ar.read(body)
+	if ar.err != nil {
+		return f, ar.err
+	}
+
+	br := bytes.NewReader(body)
+	for i := 0; i < chunks; i++ {
+		cr := &aseReader{r: br}
+		cSize := int(cr.dword())
+		cType := cr.word()
+		if cr.err != nil {
+			return f, cr.err
+		}
+		if cSize < 6 {
+			return f, fmt.Errorf("invalid chunk size %d", cSize)
+		}
+		data := make([]byte, cSize-6)
+		cr.read(data)
+		if cr.err != nil {
+			return f, cr.err
+		}
+
+		dr := &aseReader{r: bytes.NewReader(data)}
+		var err error
+		switch cType {
+		case chunkOldPalette:
+			a.readOldPalette(dr)
+		case chunkPalette:
+			a.readPalette(dr)
+		case chunkLayer:
+			a.readLayer(dr)
+		case chunkCel:
+			var cel *asepriteCel
+			cel, err = a.readCel(dr)
+			if cel != nil {
+				f.cels = append(f.cels, cel)
+			}
+		case chunkTags:
+			err = a.readTags(dr)
+		}
+		if err == nil {
+			err = dr.err
+		}
+		if err != nil {
+			return f, errors.Wrapf(err, "chunk %#x", cType)
+		}
+	}
+	return f, nil
+}
+
+func (a *Aseprite) setColor(i int, c color.NRGBA) {
+	for i >= len(a.palette) {
+		a.palette = append(a.palette, color.NRGBA{})
+	}
+	a.palette[i] = c
+}
+
+func (a *Aseprite) readOldPalette(r *aseReader) {
+	idx := 0
+	for n := int(r.word()); n > 0 && r.err == nil; n-- {
+		idx += int(r.byte())
+		cnt := int(r.byte())
+		if cnt == 0 {
+			cnt = 256
+		}
+		for j := 0; j < cnt; j++ {
+			rgb := make([]byte, 3)
+			r.read(rgb)
+			a.setColor(idx, color.NRGBA{rgb[0], rgb[1], rgb[2], 0xff})
+			idx++
+		}
+	}
+}
+
+func (a *Aseprite) readPalette(r *aseReader) {
+	r.dword() // new size
+	first := int(r.dword())
+	last := int(r.dword())
+	r.skip(8)
+	for i := first; i <= last && r.err == nil; i++ {
+		flags := r.word()
+		rgba := make([]byte, 4)
+		r.read(rgba)
+		a.setColor(i, color.NRGBA{rgba[0], rgba[1], rgba[2], rgba[3]})
+		if flags&1 != 0 {
+			r.string()
+		}
+	}
+}
+
+func (a *Aseprite) readLayer(r *aseReader) {
+	flags := r.word()
+	typ := r.word()
+	l := Layer{
+		Level:      int(r.word()),
+		Visible:    flags&layerVisible != 0,
+		Group:      typ == 1,
+		background: flags&layerBackground != 0,
+		Parent:     -1,
+	}
+	r.skip(2 + 2 + 2) // default size and blend mode
+	l.Opacity = r.byte()
+	if !a.layerOpacity {
+		l.Opacity = 0xff
+	}
+	r.skip(3)
+	l.Name = r.string()
+
+	// parent is the last group of one level up
+	for i := len(a.Layers) - 1; i >= 0 && l.Level > 0; i-- {
+		if a.Layers[i].Group && a.Layers[i].Level == l.Level-1 {
+			l.Parent = i
+			break
+		}
+	}
+	a.Layers = append(a.Layers, l)
+}
+
+func (a *Aseprite) readCel(r *aseReader) (*asepriteCel, error) {
+	cel := &asepriteCel{
+		layer:   int(r.word()),
+		x:       int(r.short()),
+		y:       int(r.short()),
+		opacity: r.byte(),
+		link:    -1,
+	}
+	typ := r.word()
+	r.skip(2 + 5) // z-index and reserved
+	if r.err != nil {
+		return nil, r.err
+	}
+	if cel.layer >= len(a.Layers) {
+		return nil, fmt.Errorf("cel of unknown layer %d", cel.layer)
+	}
+
+	switch typ {
+	case 0, 2:
+		w, h := int(r.word()), int(r.word())
+		if r.err != nil {
+			return nil, r.err
+		}
+		if w*h > maxCelPixels {
+			return nil, fmt.Errorf("cel of %dx%d is too large", w, h)
+		}
+		bpp := a.depth / 8
+		pix := make([]byte, w*h*bpp)
+		if typ == 0 {
+			r.read(pix)
+		} else {
+			zr, err := zlib.NewReader(r.r)
+			if err != nil {
+				return nil, err
+			}
+			_, err = io.ReadFull(zr, pix)
+			if err != nil {
+				return nil, err
+			}
+		}
+		cel.img = a.toNRGBA(pix, w, h, a.Layers[cel.layer].background)
+	case 1:
+		cel.link = int(r.word())
+		if r.err == nil && cel.link >= a.frameCnt {
+			return nil, fmt.Errorf("cel links to unknown frame %d", cel.link)
+		}
+	default:
+		// tilemap
+		return nil, nil
+	}
+	return cel, nil
+}
+
+func (a *Aseprite) toNRGBA(pix []byte, w, h int, background bool) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for i := 0; i < w*h; i++ {
+		var c color.NRGBA
+		switch a.depth {
+		case 32:
+			c = color.NRGBA{pix[i*4], pix[i*4+1], pix[i*4+2], pix[i*4+3]}
+		case 16:
+			c = color.NRGBA{pix[i*2], pix[i*2], pix[i*2], pix[i*2+1]}
+		case 8:
+			idx := int(pix[i])
+			if idx == a.transp && !background {
+				continue
+			}
+			if idx < len(a.palette) {
+				c = a.palette[idx].(color.NRGBA)
+			}
+		}
+		copy(img.Pix[i*4:], []byte{c.R, c.G, c.B, c.A})
+	}
+	return img
+}
+
+func (a *Aseprite) readTags(r *aseReader) error {
+	n := int(r.word())
+	r.skip(8)
+	for i := 0; i < n && r.err == nil; i++ {
+		t := Tag{
+			From: int(r.word()),
+			To:   int(r.word()),
+		}
+		t.Direction = Direction(r.byte())
+		r.skip(2 + 6 + 3 + 1) // repeat, reserved, color and extra
+		t.Name = r.string()
+		if t.Direction > PingPongReverse {
+			return fmt.Errorf("tag %q has unknown direction %d", t.Name, t.Direction)
+		}
+		if t.From > t.To || t.To >= a.frameCnt {
+			return fmt.Errorf("tag %q has invalid range %d~%d", t.Name, t.From, t.To)
+		}
+		a.Tags = append(a.Tags, t)
+	}
+	return nil
+}
+
+// Len returns number of frames.
+func (a *Aseprite) Len() int {
+	return len(a.frames)
+}
+
+// Duration returns duration of i-th frame in msec.
+func (a *Aseprite) Duration(i int) int {
+	return a.frames[i].duration
+}
+
+// Frame returns i-th frame of visible layers merged.
+// If layers are given, only the layers of the names are merged, visible or not.
+func (a *Aseprite) Frame(i int, layers ...string) image.Image {
+	want := make(map[int]bool)
+	for li, l := range a.Layers {
+		if len(layers) == 0 {
+			want[li] = a.visible(li)
+			continue
+		}
+		for _, name := range layers {
+			if l.Name == name {
+				want[li] = true
+			}
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, a.Width, a.Height))
+	// cels are drawn in order of layers, from bottom
+	for li := range a.Layers {
+		if !want[li] || a.Layers[li].Group {
+			continue
+		}
+		cel := a.cel(i, li)
+		if cel == nil {
+			continue
+		}
+
+		alpha := uint16(cel.opacity) * uint16(a.Layers[li].Opacity) / 255
+		r := cel.img.Rect.Add(image.Pt(cel.x, cel.y))
+		draw.DrawMask(dst, r, cel.img, image.Point{}, image.NewUniform(color.Alpha{uint8(alpha)}), image.Point{}, draw.Over)
+	}
+	return dst
+}
+
+// visible returns whether li-th layer and its parents are visible.
+func (a *Aseprite) visible(li int) bool {
+	for ; li >= 0; li = a.Layers[li].Parent {
+		if !a.Layers[li].Visible {
+			return false
+		}
+	}
+	return true
+}
+
+// cel returns cel of the layer in the frame, following links.
+// Links of a loop give no cel.
+func (a *Aseprite) cel(frame, layer int) *asepriteCel {
+	var first *asepriteCel
+	visited := make(map[int]bool)
+	for !visited[frame] {
+		visited[frame] = true
+		cel := a.layerCel(frame, layer)
+		if cel == nil {
+			return nil
+		}
+		if first == nil {
+			first = cel
+		}
+		if cel.link >= 0 {
+			frame = cel.link
+			continue
+		}
+
+		if cel.img == nil {
+			return nil
+		}
+		if cel == first {
+			return cel
+		}
+		// linked cel shares image and position of the one it links to
+		return &asepriteCel{layer: layer, x: cel.x, y: cel.y, opacity: first.opacity, img: cel.img, link: -1}
+	}
+	return nil
+}
+
+func (a *Aseprite) layerCel(frame, layer int) *asepriteCel {
+	for _, cel := range a.frames[frame].cels {
+		if cel.layer == layer {
+			return cel
+		}
+	}
+	return nil
+}
+
+// Animation returns frames of the tag with their durations.
+// All frames are returned if tag is empty. Frames are merged like Frame.
+func (a *Aseprite) Animation(tag string, layers ...string) (divoom.Animation, error) {
+	t, err := findTag(a.Tags, tag, len(a.frames))
+	if err != nil {
+		return divoom.Animation{}, err
+	}
+
+	var anim divoom.Animation
+	for _, i := range t.frames() {
+		anim.Imgs = append(anim.Imgs, a.Frame(i, layers...))
+		anim.SpeedMSecs = append(anim.SpeedMSecs, a.frames[i].duration)
+	}
+	return anim, nil
+}
@@ -0,0 +1,240 @@
+package sprite
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var (
+	red   = color.NRGBA{255, 0, 0, 255}
+	green = color.NRGBA{0, 255, 0, 255}
+	blue  = color.NRGBA{0, 0, 255, 255}
+	white = color.NRGBA{255, 255, 255, 255}
+	// blue of opacity 128 over red
+	purple = color.NRGBA{127, 0, 128, 255}
+	none   = color.NRGBA{}
+)
+
+func loadAseprite(t *testing.T, path string) *Aseprite {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	a, err := DecodeAseprite(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+// checkImage compares img to rows of colors, allowing difference of 1 for rounding.
+func checkImage(t *testing.T, name string, img image.Image, rows [][]color.NRGBA) {
+	t.Helper()
+	b := img.Bounds()
+	if b.Dx() != len(rows[0]) || b.Dy() != len(rows) {
+		t.Fatalf("%s is %dx%d, want %dx%d", name, b.Dx(), b.Dy(), len(rows[0]), len(rows))
+	}
+	near := func(a, b uint8) bool { return int(a)-int(b) <= 1 && int(b)-int(a) <= 1 }
+	for y, row := range rows {
+		for x, want := range row {
+			got := color.NRGBAModel.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.NRGBA)
+			if got.A == 0 && want.A == 0 {
+				continue
+			}
+			if !near(got.R, want.R) || !near(got.G, want.G) || !near(got.B, want.B) || !near(got.A, want.A) {
+				t.Errorf("%s: pixel (%d,%d) = %v, want %v", name, x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestDecodeAseprite(t *testing.T) {
+	a := loadAseprite(t, "testdata/sprite.aseprite")
+
+	if a.Width != 4 || a.Height != 4 || a.Len() != 3 {
+		t.Fatalf("got %dx%d of %d frames, want 4x4 of 3 frames", a.Width, a.Height, a.Len())
+	}
+	for i, want := range []int{100, 200, 300} {
+		if got := a.Duration(i); got != want {
+			t.Errorf("duration of frame %d = %d, want %d", i, got, want)
+		}
+	}
+
+	wantLayers := []Layer{
+		{Name: "bg", Visible: true, Opacity: 255, Parent: -1, background: true},
+		{Name: "group", Opacity: 255, Group: true, Parent: -1},
+		{Name: "child", Visible: true, Opacity: 255, Level: 1, Parent: 1},
+		{Name: "fg", Visible: true, Opacity: 128, Parent: -1},
+	}
+	if !reflect.DeepEqual(a.Layers, wantLayers) {
+		t.Errorf("layers = %+v, want %+v", a.Layers, wantLayers)
+	}
+
+	wantTags := []Tag{
+		{Name: "walk", From: 0, To: 1, Direction: Forward},
+		{Name: "bounce", From: 0, To: 2, Direction: PingPong},
+	}
+	if !reflect.DeepEqual(a.Tags, wantTags) {
+		t.Errorf("tags = %+v, want %+v", a.Tags, wantTags)
+	}
+}
+
+func TestAsepriteFrame(t *testing.T) {
+	a := loadAseprite(t, "testdata/sprite.aseprite")
+
+	R, P := red, purple
+	tcs := []struct {
+		name   string
+		frame  int
+		layers []string
+		want   [][]color.NRGBA
+	}{
+		{
+			// child is hidden by its group and fg is half transparent
+			name: "visible layers", frame: 0,
+			want: [][]color.NRGBA{{R, R, R, R}, {R, P, P, R}, {R, P, P, R}, {R, R, R, R}},
+		},
+		{
+			// linked bg cel and moved fg cel
+			name: "linked cel", frame: 1,
+			want: [][]color.NRGBA{{R, R, R, R}, {R, R, R, R}, {R, R, P, P}, {R, R, P, P}},
+		},
+		{
+			name: "no fg cel", frame: 2,
+			want: [][]color.NRGBA{{R, R, R, R}, {R, R, R, R}, {R, R, R, R}, {R, R, R, R}},
+		},
+		{
+			name: "hidden layer by name", frame: 0, layers: []string{"child"},
+			want: [][]color.NRGBA{{green, none, none, none}, {none, none, none, none}, {none, none, none, none}, {none, none, none, none}},
+		},
+		{
+			name: "layers by name", frame: 0, layers: []string{"bg", "child"},
+			want: [][]color.NRGBA{{green, R, R, R}, {R, R, R, R}, {R, R, R, R}, {R, R, R, R}},
+		},
+	}
+
+	for _, tc := range tcs {
+		checkImage(t, tc.name, a.Frame(tc.frame, tc.layers...), tc.want)
+	}
+}
+
+func TestAsepriteAnimation(t *testing.T) {
+	a := loadAseprite(t, "testdata/sprite.aseprite")
+
+	tcs := []struct {
+		tag        string
+		wantDelays []int
+	}{
+		{"", []int{100, 200, 300}},
+		{"walk", []int{100, 200}},
+		{"bounce", []int{100, 200, 300, 200}},
+	}
+	for _, tc := range tcs {
+		anim, err := a.Animation(tc.tag)
+		if err != nil {
+			t.Errorf("tag %q: %v", tc.tag, err)
+			continue
+		}
+		if !reflect.DeepEqual(anim.SpeedMSecs, tc.wantDelays) {
+			t.Errorf("tag %q: delays = %v, want %v", tc.tag, anim.SpeedMSecs, tc.wantDelays)
+		}
+		if len(anim.Imgs) != len(tc.wantDelays) {
+			t.Errorf("tag %q: %d frames, want %d", tc.tag, len(anim.Imgs), len(tc.wantDelays))
+		}
+	}
+
+	if _, err := a.Animation("run"); err == nil {
+		t.Error("no error for unknown tag")
+	}
+}
+
+func TestAsepriteIndexed(t *testing.T) {
+	a := loadAseprite(t, "testdata/indexed.aseprite")
+
+	// the new palette overrides the old one, and the transparent index
+	// is opaque in the background layer
+	checkImage(t, "indexed", a.Frame(0), [][]color.NRGBA{
+		{white, red},
+		{green, red},
+	})
+}
+
+func TestAsepriteGray(t *testing.T) {
+	a := loadAseprite(t, "testdata/gray.aseprite")
+
+	// opacity of the layer is ignored without the header flag
+	if a.Layers[0].Opacity != 255 {
+		t.Errorf("opacity = %d, want 255", a.Layers[0].Opacity)
+	}
+	checkImage(t, "gray", a.Frame(0), [][]color.NRGBA{
+		{{128, 128, 128, 255}, none},
+	})
+}
+
+func TestDecodeAsepriteError(t *testing.T) {
+	b, err := os.ReadFile("testdata/sprite.aseprite")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bad := append([]byte(nil), b...)
+	bad[4] = 0 // magic
+
+	for name, data := range map[string][]byte{
+		"invalid magic": bad,
+		"truncated":     b[:len(b)-20],
+		"empty":         nil,
+	} {
+		if _, err := DecodeAseprite(bytes.NewReader(data)); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+func TestDecodeAsepriteMalformed(t *testing.T) {
+	tcs := []struct {
+		file    string
+		wantErr string
+	}{
+		{"testdata/bad-tag-end.aseprite", "invalid range 0~2"},
+		{"testdata/bad-tag-order.aseprite", "invalid range 1~0"},
+		{"testdata/big-cel.aseprite", "too large"},
+	}
+	for _, tc := range tcs {
+		b, err := os.ReadFile(tc.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = DecodeAseprite(bytes.NewReader(b))
+		if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("%s: error = %v, want %q", tc.file, err, tc.wantErr)
+		}
+	}
+}
+
+func TestAsepriteLinkLoop(t *testing.T) {
+	a := loadAseprite(t, "testdata/link-loop.aseprite")
+
+	// cels linking to each other have no image, instead of looping forever
+	for i := 0; i < a.Len(); i++ {
+		checkImage(t, "link loop", a.Frame(i), [][]color.NRGBA{{none}})
+	}
+}
+
+func TestAsepriteTagRange(t *testing.T) {
+	a := loadAseprite(t, "testdata/sprite.aseprite")
+
+	// tags changed after decoding are checked too
+	a.Tags = append(a.Tags, Tag{Name: "over", From: 1, To: 3})
+	if _, err := a.Animation("over"); err == nil {
+		t.Error("no error for tag out of frames")
+	}
+}
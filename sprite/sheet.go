@@ -0,0 +1,305 @@
+// Package sprite converts sprite sheets and Aseprite files to animations
+// for Client.SendAnimationImgs.
+//
+//	s, err := sprite.LoadSheet(f, 64, 64)
+//	anim, err := s.Animation(0, 7, 100)
+//	err = c.SendAnimationImgs(1, anim.SpeedMSecs, anim.Imgs)
+package sprite
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/png"
+	"io"
+
+	"github.com/pkg/errors"
+	divoom "github.com/suapapa/go_divoom"
+)
+
+// Sheet is sprite sheet of frames of the same size in grid,
+// numbered from top left to right and then down.
+type Sheet struct {
+	img    image.Image
+	frameW int
+	frameH int
+	cols   int
+	rows   int
+}
+
+// LoadSheet decodes sprite sheet image, usually PNG, of frames of frameW×frameH.
+func LoadSheet(r io.Reader, frameW, frameH int) (*Sheet, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to load sprite sheet")
+	}
+	return NewSheet(img, frameW, frameH)
+}
+
+// NewSheet returns Sheet of img with frames of frameW×frameH.
+func NewSheet(img image.Image, frameW, frameH int) (*Sheet, error) {
+	if frameW < 1 || frameH < 1 {
+		return nil, fmt.Errorf("invalid frame size %dx%d", frameW, frameH)
+	}
+	b := img.Bounds()
+	s := &Sheet{
+		img:    img,
+		frameW: frameW,
+		frameH: frameH,
+		cols:   b.Dx() / frameW,
+		rows:   b.Dy() / frameH,
+	}
+	if s.Len() == 0 {
+		return nil, fmt.Errorf("sheet of %dx%d is smaller than frame of %dx%d", b.Dx(), b.Dy(), frameW, frameH)
+	}
+	return s, nil
+}
+
+// Len returns number of frames in the sheet.
+func (s *Sheet) Len() int {
+	return s.cols * s.rows
+}
+
+// Frame returns i-th frame.
+func (s *Sheet) Frame(i int) image.Image {
+	p := s.img.Bounds().Min.Add(image.Pt(i%s.cols*s.frameW, i/s.cols*s.frameH))
+	return crop(s.img, image.Rectangle{p, p.Add(image.Pt(s.frameW, s.frameH))})
+}
+
+// Animation returns frames from-th to to-th, inclusive, each shown for delayMSec.
+func (s *Sheet) Animation(from, to, delayMSec int) (divoom.Animation, error) {
+	if from < 0 || to >= s.Len() || from > to {
+		return divoom.Animation{}, fmt.Errorf("invalid frame range %d~%d of %d frames", from, to, s.Len())
+	}
+
+	var anim divoom.Animation
+	for i := from; i <= to; i++ {
+		anim.Imgs = append(anim.Imgs, s.Frame(i))
+		anim.SpeedMSecs = append(anim.SpeedMSecs, delayMSec)
+	}
+	return anim, nil
+}
+
+// crop copies r of img to new image at origin.
+func crop(img image.Image, r image.Rectangle) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	draw.Draw(dst, dst.Rect, img, r.Min, draw.Src)
+	return dst
+}
+
+// Direction is how frames of a tag are played.
+type Direction int
+
+const (
+	Forward Direction = iota
+	Reverse
+	PingPong
+	PingPongReverse
+)
+
+// Tag is named range of frames, inclusive.
+type Tag struct {
+	Name      string
+	From      int
+	To        int
+	Direction Direction
+}
+
+// frames returns frame numbers of t in order to play, for a loop.
+func (t Tag) frames() []int {
+	var fwd []int
+	for i := t.From; i <= t.To; i++ {
+		fwd = append(fwd, i)
+	}
+	rev := make([]int, len(fwd))
+	for i := range fwd {
+		rev[i] = fwd[len(fwd)-1-i]
+	}
+
+	// ping-pong doesn't repeat the frames of both ends
+	switch t.Direction {
+	case Reverse:
+		return rev
+	case PingPong:
+		if len(fwd) > 2 {
+			return append(fwd, rev[1:len(rev)-1]...)
+		}
+	case PingPongReverse:
+		if len(rev) > 2 {
+			return append(rev, fwd[1:len(fwd)-1]...)
+		}
+		return rev
+	}
+	return fwd
+}
+
+func parseDirection(s string) (Direction, error) {
+	switch s {
+	case "", "forward":
+		return Forward, nil
+	case "reverse":
+		return Reverse, nil
+	case "pingpong":
+		return PingPong, nil
+	case "pingpong_reverse":
+		return PingPongReverse, nil
+	}
+	return Forward, fmt.Errorf("unknown direction %q", s)
+}
+
+// AsepriteSheet is sprite sheet exported from Aseprite with its JSON data.
+type AsepriteSheet struct {
+	img    image.Image
+	frames []asepriteJSONFrame
+	Tags   []Tag
+}
+
+type asepriteRect struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+type asepriteJSONFrame struct {
+	Filename         string       `json:"filename"`
+	Frame            asepriteRect `json:"frame"`
+	Rotated          bool         `json:"rotated"`
+	Trimmed          bool         `json:"trimmed"`
+	SpriteSourceSize asepriteRect `json:"spriteSourceSize"`
+	SourceSize       struct {
+		W int `json:"w"`
+		H int `json:"h"`
+	} `json:"sourceSize"`
+	Duration int `json:"duration"`
+}
+
+type asepriteJSON struct {
+	Frames json.RawMessage `json:"frames"`
+	Meta   struct {
+		FrameTags []struct {
+			Name      string `json:"name"`
+			From      int    `json:"from"`
+			To        int    `json:"to"`
+			Direction string `json:"direction"`
+		} `json:"frameTags"`
+	} `json:"meta"`
+}
+
+// LoadAsepriteSheet loads sprite sheet img with JSON data exported by Aseprite from r.
+// Both "Hash" and "Array" formats of the JSON are supported.
+func LoadAsepriteSheet(img image.Image, r io.Reader) (*AsepriteSheet, error) {
+	var aj asepriteJSON
+	err := json.NewDecoder(r).Decode(&aj)
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to load aseprite json")
+	}
+
+	s := &AsepriteSheet{img: img}
+	s.frames, err = decodeFrames(aj.Frames)
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to load aseprite json")
+	}
+	for _, f := range s.frames {
+		if f.Rotated {
+			return nil, fmt.Errorf("fail to load aseprite json: rotated frame %q is not supported", f.Filename)
+		}
+	}
+
+	for _, t := range aj.Meta.FrameTags {
+		dir, err := parseDirection(t.Direction)
+		if err != nil {
+			return nil, errors.Wrapf(err, "fail to load aseprite json: tag %q", t.Name)
+		}
+		if t.From < 0 || t.To >= len(s.frames) || t.From > t.To {
+			return nil, fmt.Errorf("fail to load aseprite json: tag %q has invalid range %d~%d", t.Name, t.From, t.To)
+		}
+		s.Tags = append(s.Tags, Tag{Name: t.Name, From: t.From, To: t.To, Direction: dir})
+	}
+	return s, nil
+}
+
+// decodeFrames decodes frames of array, or of object keeping order of its keys.
+func decodeFrames(raw json.RawMessage) ([]asepriteJSONFrame, error) {
+	var frames []asepriteJSONFrame
+	if err := json.Unmarshal(raw, &frames); err == nil {
+		return frames, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if _, err := dec.Token(); err != nil { // {
+		return nil, err
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		var f asepriteJSONFrame
+		if err := dec.Decode(&f); err != nil {
+			return nil, err
+		}
+		if f.Filename == "" {
+			f.Filename, _ = tok.(string)
+		}
+		frames = append(frames, f)
+	}
+	return frames, nil
+}
+
+// Len returns number of frames.
+func (s *AsepriteSheet) Len() int {
+	return len(s.frames)
+}
+
+// Frame returns i-th frame in its original size, restoring trimmed area.
+func (s *AsepriteSheet) Frame(i int) image.Image {
+	f := s.frames[i]
+	org := s.img.Bounds().Min
+	src := image.Rect(f.Frame.X, f.Frame.Y, f.Frame.X+f.Frame.W, f.Frame.Y+f.Frame.H).Add(org)
+	if !f.Trimmed {
+		return crop(s.img, src)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, f.SourceSize.W, f.SourceSize.H))
+	dp := image.Pt(f.SpriteSourceSize.X, f.SpriteSourceSize.Y)
+	draw.Draw(dst, image.Rectangle{dp, dp.Add(src.Size())}, s.img, src.Min, draw.Src)
+	return dst
+}
+
+// Animation returns frames of the tag with their durations.
+// All frames are returned if tag is empty.
+func (s *AsepriteSheet) Animation(tag string) (divoom.Animation, error) {
+	t, err := findTag(s.Tags, tag, len(s.frames))
+	if err != nil {
+		return divoom.Animation{}, err
+	}
+
+	var anim divoom.Animation
+	for _, i := range t.frames() {
+		anim.Imgs = append(anim.Imgs, s.Frame(i))
+		anim.SpeedMSecs = append(anim.SpeedMSecs, s.frames[i].Duration)
+	}
+	return anim, nil
+}
+
+func findTag(tags []Tag, name string, frameCnt int) (Tag, error) {
+	if name == "" {
+		if frameCnt == 0 {
+			return Tag{}, fmt.Errorf("no frame")
+		}
+		return Tag{From: 0, To: frameCnt - 1}, nil
+	}
+	for _, t := range tags {
+		if t.Name != name {
+			continue
+		}
+		if t.From < 0 || t.From > t.To || t.To >= frameCnt {
+			return Tag{}, fmt.Errorf("tag %q has invalid range %d~%d", t.Name, t.From, t.To)
+		}
+		return t, nil
+	}
+	return Tag{}, fmt.Errorf("no tag %q", name)
+}
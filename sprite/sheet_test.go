@@ -0,0 +1,161 @@
+package sprite
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// gridSheet returns sheet image of cols×rows frames of 2x2,
+// each filled with colors[i], placed at origin org.
+func gridSheet(org image.Point, cols, rows int, colors []color.NRGBA) image.Image {
+	img := image.NewNRGBA(image.Rectangle{org, org.Add(image.Pt(cols*2, rows*2))})
+	for i, c := range colors {
+		r := image.Rect(i%cols*2, i/cols*2, i%cols*2+2, i/cols*2+2).Add(org)
+		draw.Draw(img, r, image.NewUniform(c), image.Point{}, draw.Src)
+	}
+	return img
+}
+
+func TestSheet(t *testing.T) {
+	colors := []color.NRGBA{red, green, blue, white, purple, none}
+
+	for _, org := range []image.Point{{}, {3, 5}} {
+		s, err := NewSheet(gridSheet(org, 3, 2, colors), 2, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Len() != 6 {
+			t.Fatalf("origin %v: %d frames, want 6", org, s.Len())
+		}
+		for i, c := range colors {
+			checkImage(t, "frame", s.Frame(i), [][]color.NRGBA{{c, c}, {c, c}})
+		}
+
+		anim, err := s.Animation(1, 3, 50)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(anim.SpeedMSecs, []int{50, 50, 50}) {
+			t.Errorf("origin %v: delays = %v", org, anim.SpeedMSecs)
+		}
+		checkImage(t, "animation", anim.Imgs[0], [][]color.NRGBA{{green, green}, {green, green}})
+	}
+}
+
+func TestLoadSheet(t *testing.T) {
+	var buf bytes.Buffer
+	// decoders of the image, like image/png, are imported by the package
+	png.Encode(&buf, gridSheet(image.Point{}, 2, 1, []color.NRGBA{red, blue}))
+	s, err := LoadSheet(&buf, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkImage(t, "frame 1", s.Frame(1), [][]color.NRGBA{{blue, blue}, {blue, blue}})
+}
+
+func TestSheetError(t *testing.T) {
+	img := gridSheet(image.Point{}, 2, 1, []color.NRGBA{red, blue})
+	if _, err := NewSheet(img, 0, 2); err == nil {
+		t.Error("no error for 0 frame width")
+	}
+	if _, err := NewSheet(img, 8, 8); err == nil {
+		t.Error("no error for frame bigger than sheet")
+	}
+
+	s, err := NewSheet(img, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range [][2]int{{-1, 0}, {0, 2}, {1, 0}} {
+		if _, err := s.Animation(r[0], r[1], 100); err == nil {
+			t.Errorf("no error for range %d~%d", r[0], r[1])
+		}
+	}
+}
+
+func TestTagFrames(t *testing.T) {
+	tcs := []struct {
+		tag  Tag
+		want []int
+	}{
+		{Tag{From: 1, To: 3}, []int{1, 2, 3}},
+		{Tag{From: 1, To: 3, Direction: Reverse}, []int{3, 2, 1}},
+		{Tag{From: 1, To: 4, Direction: PingPong}, []int{1, 2, 3, 4, 3, 2}},
+		{Tag{From: 1, To: 4, Direction: PingPongReverse}, []int{4, 3, 2, 1, 2, 3}},
+		{Tag{From: 1, To: 2, Direction: PingPong}, []int{1, 2}},
+		{Tag{From: 1, To: 2, Direction: PingPongReverse}, []int{2, 1}},
+		{Tag{From: 2, To: 2, Direction: PingPong}, []int{2}},
+	}
+	for _, tc := range tcs {
+		if got := tc.tag.frames(); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("frames of %+v = %v, want %v", tc.tag, got, tc.want)
+		}
+	}
+}
+
+func TestAsepriteSheet(t *testing.T) {
+	// 3 frames of 2x2 in a row; the last one is trimmed to its right column
+	img := gridSheet(image.Point{}, 3, 1, []color.NRGBA{red, green, blue})
+
+	hash := `{
+		"frames": {
+			"b.png": {"frame": {"x": 2, "y": 0, "w": 2, "h": 2}, "sourceSize": {"w": 2, "h": 2}, "duration": 200},
+			"a.png": {"frame": {"x": 0, "y": 0, "w": 2, "h": 2}, "sourceSize": {"w": 2, "h": 2}, "duration": 100},
+			"c.png": {"frame": {"x": 4, "y": 0, "w": 1, "h": 2}, "trimmed": true,
+				"spriteSourceSize": {"x": 1, "y": 0, "w": 1, "h": 2}, "sourceSize": {"w": 2, "h": 2}, "duration": 300}
+		},
+		"meta": {"frameTags": [{"name": "back", "from": 0, "to": 2, "direction": "reverse"}]}
+	}`
+	array := `{
+		"frames": [
+			{"filename": "b.png", "frame": {"x": 2, "y": 0, "w": 2, "h": 2}, "sourceSize": {"w": 2, "h": 2}, "duration": 200},
+			{"filename": "a.png", "frame": {"x": 0, "y": 0, "w": 2, "h": 2}, "sourceSize": {"w": 2, "h": 2}, "duration": 100},
+			{"filename": "c.png", "frame": {"x": 4, "y": 0, "w": 1, "h": 2}, "trimmed": true,
+				"spriteSourceSize": {"x": 1, "y": 0, "w": 1, "h": 2}, "sourceSize": {"w": 2, "h": 2}, "duration": 300}
+		],
+		"meta": {"frameTags": [{"name": "back", "from": 0, "to": 2, "direction": "reverse"}]}
+	}`
+
+	for name, js := range map[string]string{"hash": hash, "array": array} {
+		s, err := LoadAsepriteSheet(img, strings.NewReader(js))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if s.Len() != 3 {
+			t.Fatalf("%s: %d frames, want 3", name, s.Len())
+		}
+
+		// order of the JSON is kept, not sorted by name
+		checkImage(t, name+" frame 0", s.Frame(0), [][]color.NRGBA{{green, green}, {green, green}})
+		checkImage(t, name+" trimmed", s.Frame(2), [][]color.NRGBA{{none, blue}, {none, blue}})
+
+		anim, err := s.Animation("back")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(anim.SpeedMSecs, []int{300, 100, 200}) {
+			t.Errorf("%s: delays = %v, want [300 100 200]", name, anim.SpeedMSecs)
+		}
+	}
+}
+
+func TestAsepriteSheetError(t *testing.T) {
+	img := gridSheet(image.Point{}, 1, 1, []color.NRGBA{red})
+	tcs := map[string]string{
+		"rotated":       `{"frames": [{"frame": {"w": 2, "h": 2}, "rotated": true}]}`,
+		"tag range":     `{"frames": [{"frame": {"w": 2, "h": 2}}], "meta": {"frameTags": [{"name": "x", "from": 0, "to": 1}]}}`,
+		"tag direction": `{"frames": [{"frame": {"w": 2, "h": 2}}], "meta": {"frameTags": [{"name": "x", "direction": "up"}]}}`,
+		"invalid json":  `{"frames": `,
+	}
+	for name, js := range tcs {
+		if _, err := LoadAsepriteSheet(img, strings.NewReader(js)); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+//go:build ignore
+
+// gen_aseprite generates .aseprite fixtures of the decoder tests,
+// following https://github.com/aseprite/aseprite/blob/main/docs/ase-file-specs.md
+//
+//	cd sprite && go run testdata/gen_aseprite.go
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"log"
+	"os"
+)
+
+func main() {
+	write("testdata/sprite.aseprite", genRGBA())
+	write("testdata/indexed.aseprite", genIndexed())
+	write("testdata/gray.aseprite", genGray())
+	write("testdata/bad-tag-end.aseprite", genBadTag(tag{0, 2, 0, "over"}))
+	write("testdata/bad-tag-order.aseprite", genBadTag(tag{1, 0, 0, "back"}))
+	write("testdata/link-loop.aseprite", genLinkLoop())
+	write("testdata/big-cel.aseprite", genBigCel())
+}
+
+func write(path string, b []byte) {
+	if err := os.WriteFile(path, b, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+type buf struct{ bytes.Buffer }
+
+func (b *buf) put(vs ...interface{}) *buf {
+	for _, v := range vs {
+		binary.Write(b, binary.LittleEndian, v)
+	}
+	return b
+}
+
+func (b *buf) str(s string) *buf {
+	b.put(uint16(len(s)))
+	b.WriteString(s)
+	return b
+}
+
+func chunk(typ uint16, data []byte) []byte {
+	var b buf
+	b.put(uint32(6+len(data)), typ)
+	b.Write(data)
+	return b.Bytes()
+}
+
+func frame(duration uint16, chunks ...[]byte) []byte {
+	var body []byte
+	for _, c := range chunks {
+		body = append(body, c...)
+	}
+	var b buf
+	b.put(uint32(16+len(body)), uint16(0xF1FA), uint16(len(chunks)), duration, [2]byte{}, uint32(len(chunks)))
+	b.Write(body)
+	return b.Bytes()
+}
+
+func file(w, h, depth int, flags uint32, transp uint8, nColors int, frames ...[]byte) []byte {
+	var body []byte
+	for _, f := range frames {
+		body = append(body, f...)
+	}
+	var b buf
+	b.put(uint32(128+len(body)), uint16(0xA5E0), uint16(len(frames)), uint16(w), uint16(h), uint16(depth))
+	b.put(flags, uint16(100), uint32(0), uint32(0))
+	b.put(transp, [3]byte{}, uint16(nColors))
+	b.put(uint8(1), uint8(1), int16(0), int16(0), uint16(16), uint16(16), [84]byte{})
+	b.Write(body)
+	return b.Bytes()
+}
+
+const (
+	flagVisible    = 1
+	flagBackground = 8
+)
+
+func layer(flags, typ, level uint16, opacity uint8, name string) []byte {
+	var b buf
+	b.put(flags, typ, level, uint16(0), uint16(0), uint16(0), opacity, [3]byte{})
+	b.str(name)
+	return chunk(0x2004, b.Bytes())
+}
+
+func celHeader(layer uint16, x, y int16, opacity uint8, typ uint16) *buf {
+	var b buf
+	b.put(layer, x, y, opacity, typ, int16(0), [5]byte{})
+	return &b
+}
+
+func rawCel(layer uint16, x, y int16, opacity uint8, w, h uint16, pix []byte) []byte {
+	b := celHeader(layer, x, y, opacity, 0)
+	b.put(w, h)
+	b.Write(pix)
+	return chunk(0x2005, b.Bytes())
+}
+
+func compressedCel(layer uint16, x, y int16, opacity uint8, w, h uint16, pix []byte) []byte {
+	b := celHeader(layer, x, y, opacity, 2)
+	b.put(w, h)
+	zw := zlib.NewWriter(b)
+	zw.Write(pix)
+	zw.Close()
+	return chunk(0x2005, b.Bytes())
+}
+
+func linkedCel(layer uint16, frame uint16) []byte {
+	b := celHeader(layer, 0, 0, 255, 1)
+	b.put(frame)
+	return chunk(0x2005, b.Bytes())
+}
+
+type tag struct {
+	from, to uint16
+	dir      uint8
+	name     string
+}
+
+func tags(ts ...tag) []byte {
+	var b buf
+	b.put(uint16(len(ts)), [8]byte{})
+	for _, t := range ts {
+		b.put(t.from, t.to, t.dir, uint16(0), [6]byte{}, [3]byte{}, uint8(0))
+		b.str(t.name)
+	}
+	return chunk(0x2018, b.Bytes())
+}
+
+func repeat(px []byte, n int) []byte {
+	return bytes.Repeat(px, n)
+}
+
+// genRGBA makes 4x4 RGBA sprite of 3 frames with layers
+//
+//	0 bg            background, red
+//	1 group         hidden group
+//	2   child       visible, green, but hidden by the group
+//	3 fg            opacity 128, blue
+//
+// and tags walk, 0~1 forward, and bounce, 0~2 ping-pong.
+func genRGBA() []byte {
+	red := []byte{255, 0, 0, 255}
+	green := []byte{0, 255, 0, 255}
+	blue := []byte{0, 0, 255, 255}
+
+	layers := [][]byte{
+		layer(flagVisible|flagBackground, 0, 0, 255, "bg"),
+		layer(0, 1, 0, 255, "group"),
+		layer(flagVisible, 0, 1, 255, "child"),
+		layer(flagVisible, 0, 0, 128, "fg"),
+	}
+	f0 := append(layers,
+		rawCel(0, 0, 0, 255, 4, 4, repeat(red, 16)),
+		rawCel(2, 0, 0, 255, 1, 1, green),
+		compressedCel(3, 1, 1, 255, 2, 2, repeat(blue, 4)),
+		tags(tag{0, 1, 0, "walk"}, tag{0, 2, 2, "bounce"}),
+	)
+	f1 := [][]byte{
+		linkedCel(0, 0),
+		// cel opacity is multiplied to layer opacity
+		compressedCel(3, 2, 2, 255, 2, 2, repeat(blue, 4)),
+	}
+	f2 := [][]byte{
+		linkedCel(0, 0),
+	}
+	return file(4, 4, 32, 1, 0, 0,
+		frame(100, f0...),
+		frame(200, f1...),
+		frame(300, f2...),
+	)
+}
+
+// genIndexed makes 2x2 indexed sprite with old and new palette chunks.
+// Index 0 is transparent but in the background layer.
+func genIndexed() []byte {
+	var oldPal buf
+	oldPal.put(uint16(1), uint8(0), uint8(3))
+	oldPal.Write([]byte{9, 9, 9, 9, 9, 9, 9, 9, 9}) // overridden by the new palette
+
+	var pal buf
+	pal.put(uint32(3), uint32(0), uint32(2), [8]byte{})
+	pal.put(uint16(0), [4]byte{255, 255, 255, 255})
+	pal.put(uint16(1), [4]byte{255, 0, 0, 255}).str("red")
+	pal.put(uint16(0), [4]byte{0, 255, 0, 255})
+
+	return file(2, 2, 8, 1, 0, 3,
+		frame(100,
+			chunk(0x0004, oldPal.Bytes()),
+			chunk(0x2019, pal.Bytes()),
+			layer(flagVisible|flagBackground, 0, 0, 255, "bg"),
+			layer(flagVisible, 0, 0, 255, "fg"),
+			rawCel(0, 0, 0, 255, 2, 2, []byte{0, 0, 0, 0}),
+			rawCel(1, 0, 0, 255, 2, 2, []byte{0, 1, 2, 1}),
+		),
+	)
+}
+
+// genGray makes 2x1 grayscale sprite without valid layer opacity.
+func genGray() []byte {
+	return file(2, 1, 16, 0, 0, 0,
+		frame(100,
+			layer(flagVisible, 0, 0, 10, "gray"),
+			rawCel(0, 0, 0, 255, 2, 1, []byte{128, 255, 255, 0}),
+		),
+	)
+}
+
+// genBadTag makes 1x1 sprite of 2 frames with tag t, which is out of the frames.
+func genBadTag(t tag) []byte {
+	return file(1, 1, 32, 1, 0, 0,
+		frame(100,
+			layer(flagVisible, 0, 0, 255, "l"),
+			rawCel(0, 0, 0, 255, 1, 1, []byte{255, 0, 0, 255}),
+			tags(t),
+		),
+		frame(100),
+	)
+}
+
+// genLinkLoop makes 1x1 sprite of 2 frames whose cels link to each other.
+func genLinkLoop() []byte {
+	return file(1, 1, 32, 1, 0, 0,
+		frame(100,
+			layer(flagVisible, 0, 0, 255, "l"),
+			linkedCel(0, 1),
+		),
+		frame(100,
+			linkedCel(0, 0),
+		),
+	)
+}
+
+// genBigCel makes 1x1 sprite with a compressed cel claiming 65535x65535 pixels.
+func genBigCel() []byte {
+	return file(1, 1, 32, 1, 0, 0,
+		frame(100,
+			layer(flagVisible, 0, 0, 255, "l"),
+			compressedCel(0, 0, 0, 255, 65535, 65535, []byte{255, 0, 0, 255}),
+		),
+	)
+}
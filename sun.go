@@ -0,0 +1,100 @@
+package divoom
+
+import (
+	"math"
+	"time"
+)
+
+// SunTimes returns sunrise and sunset of the date at given coordinates in loc.
+// ok is false when the sun doesn't rise or set on the date, like polar day or night.
+//
+// It uses the sunrise/sunset algorithm of Almanac for Computers, which is
+// accurate within a few minutes. That's enough for dimming a display.
+func SunTimes(date time.Time, lat, long float64, loc *time.Location) (sunrise, sunset time.Time, ok bool) {
+	date = date.In(loc)
+	y, m, d := date.Date()
+
+	sunrise, ok = sunTime(y, m, d, lat, long, true)
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+	sunset, ok = sunTime(y, m, d, lat, long, false)
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+
+	return sameLocalDate(sunrise, date, loc), sameLocalDate(sunset, date, loc), true
+}
+
+const (
+	deg2rad = math.Pi / 180
+	rad2deg = 180 / math.Pi
+
+	// official zenith for sunrise and sunset
+	sunZenith = 90.833
+)
+
+func sunTime(y int, m time.Month, d int, lat, long float64, rising bool) (time.Time, bool) {
+	day := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	n := float64(day.YearDay())
+
+	lngHour := long / 15
+	var t float64
+	if rising {
+		t = n + (6-lngHour)/24
+	} else {
+		t = n + (18-lngHour)/24
+	}
+
+	// sun's mean anomaly and true longitude
+	mAnomaly := 0.9856*t - 3.289
+	l := mAnomaly + 1.916*math.Sin(mAnomaly*deg2rad) + 0.020*math.Sin(2*mAnomaly*deg2rad) + 282.634
+	l = normDegree(l)
+
+	// sun's right ascension, in the same quadrant as l
+	ra := normDegree(rad2deg * math.Atan(0.91764*math.Tan(l*deg2rad)))
+	ra += math.Floor(l/90)*90 - math.Floor(ra/90)*90
+	ra /= 15
+
+	// sun's declination and local hour angle
+	sinDec := 0.39782 * math.Sin(l*deg2rad)
+	cosDec := math.Cos(math.Asin(sinDec))
+	cosH := (math.Cos(sunZenith*deg2rad) - sinDec*math.Sin(lat*deg2rad)) / (cosDec * math.Cos(lat*deg2rad))
+	if cosH > 1 || cosH < -1 {
+		return time.Time{}, false
+	}
+
+	var h float64
+	if rising {
+		h = 360 - rad2deg*math.Acos(cosH)
+	} else {
+		h = rad2deg * math.Acos(cosH)
+	}
+	h /= 15
+
+	localMean := h + ra - 0.06571*t - 6.622
+	ut := math.Mod(localMean-lngHour, 24)
+	if ut < 0 {
+		ut += 24
+	}
+
+	return day.Add(time.Duration(ut * float64(time.Hour))), true
+}
+
+func normDegree(v float64) float64 {
+	v = math.Mod(v, 360)
+	if v < 0 {
+		v += 360
+	}
+	return v
+}
+
+// sameLocalDate moves t by days to be on the same local date with date.
+func sameLocalDate(t, date time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	y, m, d := date.Date()
+	want := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	ty, tm, td := t.Date()
+	got := time.Date(ty, tm, td, 0, 0, 0, 0, time.UTC)
+	return t.AddDate(0, 0, int(want.Sub(got)/(24*time.Hour)))
+}
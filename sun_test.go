@@ -0,0 +1,59 @@
+package divoom
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSunTimes(t *testing.T) {
+	seoul := time.FixedZone("KST", 9*3600)
+	nyc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	tcs := []struct {
+		name              string
+		date              time.Time
+		lat, long         float64
+		loc               *time.Location
+		wantRise, wantSet string
+	}{
+		{"seoul summer", time.Date(2022, 6, 21, 12, 0, 0, 0, seoul), 37.5665, 126.978, seoul, "05:11", "19:57"},
+		{"london winter", time.Date(2022, 12, 21, 12, 0, 0, 0, time.UTC), 51.5074, -0.1278, time.UTC, "08:04", "15:53"},
+		{"new york dst", time.Date(2022, 7, 4, 12, 0, 0, 0, nyc), 40.7128, -74.006, nyc, "05:30", "20:31"},
+		// date is taken in loc, not in the time zone of date
+		{"date in loc", time.Date(2022, 6, 20, 20, 0, 0, 0, time.UTC), 37.5665, 126.978, seoul, "05:11", "19:57"},
+	}
+
+	for _, tc := range tcs {
+		rise, set, ok := SunTimes(tc.date, tc.lat, tc.long, tc.loc)
+		if !ok {
+			t.Errorf("%s: no sunrise", tc.name)
+			continue
+		}
+		for _, c := range []struct {
+			got  time.Time
+			want string
+		}{{rise, tc.wantRise}, {set, tc.wantSet}} {
+			w, _ := time.ParseInLocation("15:04", c.want, tc.loc)
+			y, m, d := tc.date.In(tc.loc).Date()
+			want := time.Date(y, m, d, w.Hour(), w.Minute(), 0, 0, tc.loc)
+			if diff := c.got.Sub(want); diff < -3*time.Minute || diff > 3*time.Minute {
+				t.Errorf("%s: got %v, want %v", tc.name, c.got, want)
+			}
+		}
+	}
+}
+
+func TestSunTimesPolar(t *testing.T) {
+	// Tromsø has polar night in December and midnight sun in June
+	for _, date := range []time.Time{
+		time.Date(2022, 12, 21, 12, 0, 0, 0, time.UTC),
+		time.Date(2022, 6, 21, 12, 0, 0, 0, time.UTC),
+	} {
+		if _, _, ok := SunTimes(date, 69.6492, 18.9553, time.UTC); ok {
+			t.Errorf("sun rises and sets at Tromsø on %v", date)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package divoom
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -11,6 +12,7 @@ var (
 	ErrInvalidBrightness   = fmt.Errorf("brightness should be in range of 0~100")
 	ErrInvalidWhiteBalance = fmt.Errorf("white balance should be in range of 0~100")
 	ErrInvalidScore        = fmt.Errorf("score should be in range of 0~999")
+	ErrInvalidCountdown    = fmt.Errorf("countdown should be in range of 0s~99m59s")
 	ErrInvalidPicNum       = fmt.Errorf("pic num should be smaller than 60")
 	ErrInvalidPicWidth     = fmt.Errorf("pic width should be 16, 32 or 64")
 	ErrNotImplemented      = fmt.Errorf("not implemented")
@@ -66,8 +68,8 @@ func (c *Client) GetAllSetting() (map[string]interface{}, error) {
 		return nil, errors.Wrap(err, "fail to get all setting")
 	}
 
-	if ret["error_code"] != 0 {
-		return nil, fmt.Errorf("fail to get all setting: %d", ret["error_code"])
+	if ret["error_code"] != float64(0) {
+		return nil, fmt.Errorf("fail to get all setting: %v", ret["error_code"])
 	}
 
 	return ret, nil
@@ -97,6 +99,10 @@ func (c *Client) WeatherAreaSetting(long, lat string) error {
 		return fmt.Errorf("fail to set weather area: %d", ret.ErrorCode)
 	}
 
+	c.mu.Lock()
+	c.long, c.lat = long, lat
+	c.mu.Unlock()
+
 	return nil
 }
 
@@ -123,9 +129,14 @@ func (c *Client) SetTimeZone(timezone string) error {
 		return fmt.Errorf("fail to set time zone: %d", ret.ErrorCode)
 	}
 
+	c.mu.Lock()
+	c.timeZone = timezone
+	c.mu.Unlock()
+
 	return nil
 }
 
+// Deprecated: Use SetSystemTime.
 func (c *Client) SystemTime(utcTime string) error {
 	cmd := "Device/SetUTC"
 	data := map[string]interface{}{
@@ -213,6 +224,57 @@ func (c *Client) GetDeviceTime() (*DeviceTimeResult, error) {
 	return &ret, nil
 }
 
+// SetSystemTime sets system time of the device to t.
+func (c *Client) SetSystemTime(t time.Time) error {
+	cmd := "Device/SetUTC"
+	data := map[string]interface{}{
+		"Command": cmd,
+		"Utc":     t.Unix(),
+	}
+
+	resp, err := c.do(data)
+	if err != nil {
+		return errors.Wrap(err, "fail to set system time")
+	}
+	defer resp.Body.Close()
+
+	var ret errorCode
+	err = json.NewDecoder(resp.Body).Decode(&ret)
+	if err != nil {
+		return errors.Wrap(err, "fail to set system time")
+	}
+
+	if ret.ErrorCode != 0 {
+		return fmt.Errorf("fail to set system time: %d", ret.ErrorCode)
+	}
+
+	return nil
+}
+
+// DeviceTime returns current time of the device in its time zone.
+// Device time has resolution of a second.
+func (c *Client) DeviceTime() (time.Time, error) {
+	ret, err := c.GetDeviceTime()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return ret.Time()
+}
+
+// Time returns UTCTime in time zone of LocalTime.
+func (r *DeviceTimeResult) Time() (time.Time, error) {
+	utc := time.Unix(int64(r.UTCTime), 0)
+	local, err := time.ParseInLocation("2006-01-02 15:04:05", r.LocalTime, time.UTC)
+	if err != nil {
+		return utc, errors.Wrap(err, "fail to parse device local time")
+	}
+
+	// round offset to minutes as the two values can be read in different seconds
+	off := local.Sub(utc).Round(time.Minute)
+	return utc.In(time.FixedZone("", int(off/time.Second))), nil
+}
+
 type TempMode int
 
 const (
@@ -382,7 +444,7 @@ func (c *Client) SetWhiteBalance(r, g, b int) error {
 		return ErrInvalidWhiteBalance
 	}
 
-	cmd := "Device/SetHighLightMode"
+	cmd := "Device/SetWhiteBalance"
 	data := map[string]interface{}{
 		"Command": cmd,
 		"RValue":  r,
@@ -0,0 +1,37 @@
+package divoom
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetAllSetting(t *testing.T) {
+	// error_code is decoded as float64, not int
+	tcs := []struct {
+		reply   string
+		wantErr bool
+	}{
+		{`{"error_code": 0, "Brightness": 100}`, false},
+		{`{"error_code": 1}`, true},
+		{`{"Brightness": 100}`, true},
+	}
+	for _, tc := range tcs {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(tc.reply))
+		}))
+		c := NewClient(&Device{DevicePrivateIP: "pixoo"})
+		c.url = srv.URL
+
+		set, err := c.GetAllSetting()
+		srv.Close()
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: error = %v, want error %v", tc.reply, err, tc.wantErr)
+			continue
+		}
+		if err == nil && set["Brightness"] != float64(100) {
+			t.Errorf("%s: brightness = %v", tc.reply, set["Brightness"])
+		}
+	}
+}
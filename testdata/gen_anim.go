@@ -0,0 +1,367 @@
+//go:build ignore
+
+// gen_anim generates APNG and animated WebP fixtures of decoder tests.
+//
+//	go run testdata/gen_anim.go
+//
+// WebP frames are encoded in a minimal VP8L bitstream, which uses simple prefix
+// codes only, so each channel of a frame can have at most two values.
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"log"
+	"os"
+)
+
+var (
+	red   = color.NRGBA{255, 0, 0, 255}
+	green = color.NRGBA{0, 255, 0, 255}
+	blue  = color.NRGBA{0, 0, 255, 255}
+	white = color.NRGBA{255, 255, 255, 255}
+	clear = color.NRGBA{}
+)
+
+func main() {
+	write("testdata/anim.png", genAPNG())
+	write("testdata/anim-default-image.png", genAPNGDefaultImage())
+	write("testdata/still.png", encodePNG(fill(4, 4, red)))
+	write("testdata/anim.webp", genWebP(true))
+	write("testdata/anim-no-vp8x.webp", genWebP(false))
+	write("testdata/still.webp", riff(chunk("VP8L", vp8l(fill(4, 4, blue)))))
+}
+
+func write(path string, b []byte) {
+	if err := os.WriteFile(path, b, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func fill(w, h int, c color.Color) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+// 2x2 of blue with transparent top left pixel
+func holed() *image.NRGBA {
+	img := fill(2, 2, blue)
+	img.Set(0, 0, clear)
+	return img
+}
+
+type pngChunk struct {
+	typ  string
+	data []byte
+}
+
+func encodePNG(img image.Image) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		log.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func pngChunks(b []byte) []pngChunk {
+	var cs []pngChunk
+	b = b[8:]
+	for len(b) >= 12 {
+		n := binary.BigEndian.Uint32(b)
+		cs = append(cs, pngChunk{string(b[4:8]), b[8 : 8+n]})
+		b = b[12+n:]
+	}
+	return cs
+}
+
+func writePNG(cs []pngChunk) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("\x89PNG\r\n\x1a\n")
+	for _, c := range cs {
+		binary.Write(&buf, binary.BigEndian, uint32(len(c.data)))
+		buf.WriteString(c.typ)
+		buf.Write(c.data)
+		crc := crc32.NewIEEE()
+		crc.Write([]byte(c.typ))
+		crc.Write(c.data)
+		binary.Write(&buf, binary.BigEndian, crc.Sum32())
+	}
+	return buf.Bytes()
+}
+
+type apngFrame struct {
+	img            image.Image
+	x, y           int
+	num, den       uint16
+	dispose, blend byte
+}
+
+// encodeRGBA encodes img as 8 bits RGBA PNG chunks, even if it is opaque.
+func encodeRGBA(img image.Image) []pngChunk {
+	b := img.Bounds()
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], uint32(b.Dx()))
+	binary.BigEndian.PutUint32(ihdr[4:], uint32(b.Dy()))
+	ihdr[8], ihdr[9] = 8, 6
+
+	var raw bytes.Buffer
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		raw.WriteByte(0) // no filter
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			raw.Write([]byte{c.R, c.G, c.B, c.A})
+		}
+	}
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	zw.Write(raw.Bytes())
+	zw.Close()
+
+	return []pngChunk{{"IHDR", ihdr}, {"IDAT", z.Bytes()}, {"IEND", nil}}
+}
+
+// encodeStd encodes img with image/png.
+func encodeStd(img image.Image) []pngChunk {
+	return pngChunks(encodePNG(img))
+}
+
+// apngChunks returns chunks of frames encoded by enc, with header and shared
+// chunks of the first frame. If def isn't nil, it is the default image which
+// isn't part of the animation.
+func apngChunks(enc func(image.Image) []pngChunk, def image.Image, frames []apngFrame) []pngChunk {
+	first := enc(frames[0].img)
+	var cs []pngChunk
+	for _, c := range first {
+		if c.typ != "IDAT" && c.typ != "IEND" {
+			cs = append(cs, c)
+		}
+	}
+
+	actl := make([]byte, 8)
+	binary.BigEndian.PutUint32(actl[0:], uint32(len(frames)))
+	cs = append(cs, pngChunk{"acTL", actl})
+
+	seq := uint32(0)
+	if def != nil {
+		for _, c := range enc(def) {
+			if c.typ == "IDAT" {
+				cs = append(cs, c)
+			}
+		}
+	}
+	for i, f := range frames {
+		b := f.img.Bounds()
+		fctl := make([]byte, 26)
+		binary.BigEndian.PutUint32(fctl[0:], seq)
+		binary.BigEndian.PutUint32(fctl[4:], uint32(b.Dx()))
+		binary.BigEndian.PutUint32(fctl[8:], uint32(b.Dy()))
+		binary.BigEndian.PutUint32(fctl[12:], uint32(f.x))
+		binary.BigEndian.PutUint32(fctl[16:], uint32(f.y))
+		binary.BigEndian.PutUint16(fctl[20:], f.num)
+		binary.BigEndian.PutUint16(fctl[22:], f.den)
+		fctl[24], fctl[25] = f.dispose, f.blend
+		cs = append(cs, pngChunk{"fcTL", fctl})
+		seq++
+
+		for _, c := range enc(f.img) {
+			if c.typ != "IDAT" {
+				continue
+			}
+			if i == 0 && def == nil {
+				cs = append(cs, c)
+				continue
+			}
+			d := make([]byte, 4, 4+len(c.data))
+			binary.BigEndian.PutUint32(d, seq)
+			cs = append(cs, pngChunk{"fdAT", append(d, c.data...)})
+			seq++
+		}
+	}
+	return append(cs, pngChunk{"IEND", nil})
+}
+
+// genAPNG makes APNG of which the first frame is the default image.
+func genAPNG() []byte {
+	return writePNG(apngChunks(encodeRGBA, nil, []apngFrame{
+		// red over all, delay 100ms
+		{img: fill(4, 4, red), num: 1, den: 10},
+		// holed blue at bottom right blended over, disposed to background
+		{img: holed(), x: 2, y: 2, num: 20, den: 100, dispose: 1, blend: 1},
+		// green at top left, disposed to previous
+		{img: fill(2, 2, green), num: 3, den: 100, dispose: 2},
+		// blue at top right pixel, delay of den 0 is 1/100 sec
+		{img: fill(1, 1, blue), x: 3, num: 1, blend: 1},
+	}))
+}
+
+// genAPNGDefaultImage makes paletted APNG of which the default image isn't a frame.
+func genAPNGDefaultImage() []byte {
+	pal := color.Palette{clear, red, green, blue, white}
+	paletted := func(w, h int, c color.Color) image.Image {
+		img := image.NewPaletted(image.Rect(0, 0, w, h), pal)
+		for i := range img.Pix {
+			img.Pix[i] = uint8(pal.Index(c))
+		}
+		return img
+	}
+	return writePNG(apngChunks(encodeStd, paletted(4, 4, white), []apngFrame{
+		{img: paletted(4, 4, green), num: 5, den: 100},
+		{img: paletted(2, 2, clear), x: 1, y: 1, num: 5, den: 100},
+	}))
+}
+
+func chunk(fourCC string, data []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(fourCC)
+	binary.Write(&buf, binary.LittleEndian, uint32(len(data)))
+	buf.Write(data)
+	if len(data)&1 == 1 {
+		buf.WriteByte(0)
+	}
+	return buf.Bytes()
+}
+
+func riff(chunks ...[]byte) []byte {
+	body := []byte("WEBP")
+	for _, c := range chunks {
+		body = append(body, c...)
+	}
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(len(body)))
+	buf.Write(body)
+	return buf.Bytes()
+}
+
+func uint24(v int) []byte {
+	return []byte{byte(v), byte(v >> 8), byte(v >> 16)}
+}
+
+type webpFrame struct {
+	img   *image.NRGBA
+	x, y  int
+	dur   int
+	flags byte // 0x02: do not blend, 0x01: dispose to background
+}
+
+func anmf(f webpFrame) []byte {
+	b := f.img.Bounds()
+	var d []byte
+	d = append(d, uint24(f.x/2)...)
+	d = append(d, uint24(f.y/2)...)
+	d = append(d, uint24(b.Dx()-1)...)
+	d = append(d, uint24(b.Dy()-1)...)
+	d = append(d, uint24(f.dur)...)
+	d = append(d, f.flags)
+	d = append(d, chunk("VP8L", vp8l(f.img))...)
+	return chunk("ANMF", d)
+}
+
+func genWebP(withVP8X bool) []byte {
+	frames := []webpFrame{
+		{img: fill(4, 4, red), dur: 100, flags: 0x02},
+		{img: holed(), x: 2, y: 2, dur: 200, flags: 0x01},
+		{img: fill(2, 2, green), dur: 300, flags: 0x02},
+	}
+
+	var chunks [][]byte
+	if withVP8X {
+		vp8x := make([]byte, 10)
+		vp8x[0] = 0x10 | 0x02 // alpha, animation
+		copy(vp8x[4:], uint24(4-1))
+		copy(vp8x[7:], uint24(4-1))
+		chunks = append(chunks, chunk("VP8X", vp8x))
+	}
+	chunks = append(chunks, chunk("ANIM", make([]byte, 6)))
+	// odd sized chunk, padded, before the frames
+	chunks = append(chunks, chunk("XMP ", []byte("<x>")))
+	for _, f := range frames {
+		chunks = append(chunks, anmf(f))
+	}
+	return riff(chunks...)
+}
+
+type bitWriter struct {
+	buf   []byte
+	acc   uint64
+	nbits uint
+}
+
+func (w *bitWriter) write(v uint64, n uint) {
+	w.acc |= v << w.nbits
+	w.nbits += n
+	for w.nbits >= 8 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc >>= 8
+		w.nbits -= 8
+	}
+}
+
+func (w *bitWriter) bytes() []byte {
+	if w.nbits > 0 {
+		w.buf = append(w.buf, byte(w.acc))
+	}
+	return w.buf
+}
+
+// vp8l encodes img without transforms and color cache, with simple prefix codes.
+func vp8l(img *image.NRGBA) []byte {
+	b := img.Bounds()
+	w := &bitWriter{buf: []byte{0x2f}}
+	w.write(uint64(b.Dx()-1), 14)
+	w.write(uint64(b.Dy()-1), 14)
+	w.write(1, 1) // alpha is used
+	w.write(0, 3) // version
+	w.write(0, 1) // no transform
+	w.write(0, 1) // no color cache
+	w.write(0, 1) // no meta prefix codes
+
+	// symbols of green, red, blue and alpha, in order of the prefix codes
+	chans := []int{1, 0, 2, 3}
+	syms := make([][]int, 4)
+	for i, ch := range chans {
+		seen := map[int]bool{}
+		for p := ch; p < len(img.Pix); p += 4 {
+			v := int(img.Pix[p])
+			if !seen[v] {
+				seen[v] = true
+				syms[i] = append(syms[i], v)
+			}
+		}
+		if len(syms[i]) > 2 {
+			log.Fatalf("channel %d has more than two values", ch)
+		}
+		if len(syms[i]) == 2 && syms[i][0] > syms[i][1] {
+			syms[i][0], syms[i][1] = syms[i][1], syms[i][0]
+		}
+	}
+	for _, s := range append(syms, []int{0}) { // distance code is unused
+		w.write(1, 1) // simple code
+		w.write(uint64(len(s)-1), 1)
+		w.write(1, 1) // 8 bits symbol
+		for _, v := range s {
+			w.write(uint64(v), 8)
+		}
+	}
+
+	for p := 0; p < len(img.Pix); p += 4 {
+		for i, ch := range chans {
+			if len(syms[i]) == 2 && int(img.Pix[p+ch]) == syms[i][1] {
+				w.write(1, 1)
+			} else if len(syms[i]) == 2 {
+				w.write(0, 1)
+			}
+		}
+	}
+	return w.bytes()
+}
@@ -9,8 +9,12 @@ import (
 )
 
 func (c *Client) SetCountdownTool(dur time.Duration, start bool) error {
-	m := int(dur.Minutes())
-	s := int(dur.Seconds()) / 60
+	if dur < 0 || dur > MaxCountdown {
+		return ErrInvalidCountdown
+	}
+
+	m := int(dur / time.Minute)
+	s := int(dur % time.Minute / time.Second)
 	var v int
 	if start {
 		v = 1
@@ -0,0 +1,42 @@
+package divoom
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetCountdownTool(t *testing.T) {
+	tcs := []struct {
+		dur        time.Duration
+		wantMinute float64
+		wantSecond float64
+		wantErr    error
+	}{
+		{0, 0, 0, nil},
+		{59 * time.Second, 0, 59, nil},
+		{60 * time.Second, 1, 0, nil},
+		{90*time.Second + 500*time.Millisecond, 1, 30, nil},
+		{MaxCountdown, 99, 59, nil},
+		{MaxCountdown + time.Second, 0, 0, ErrInvalidCountdown},
+		{-time.Second, 0, 0, ErrInvalidCountdown},
+	}
+	for _, tc := range tcs {
+		c, d := newFakeClient()
+		err := c.SetCountdownTool(tc.dur, true)
+		if err != tc.wantErr {
+			t.Errorf("%v: error = %v, want %v", tc.dur, err, tc.wantErr)
+			continue
+		}
+		if err != nil {
+			if cmd := d.last(); cmd != nil {
+				t.Errorf("%v: sent %v for invalid countdown", tc.dur, cmd)
+			}
+			continue
+		}
+		cmd := d.last()
+		if cmd["Command"] != "Tools/SetTimer" || cmd["Minute"] != tc.wantMinute ||
+			cmd["Second"] != tc.wantSecond || cmd["Status"] != float64(1) {
+			t.Errorf("%v: sent %v, want %v:%v", tc.dur, cmd, tc.wantMinute, tc.wantSecond)
+		}
+	}
+}
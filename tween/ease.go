@@ -0,0 +1,66 @@
+package tween
+
+import "math"
+
+// Easing maps progress t of 0~1 to eased progress, 0 at 0 and 1 at 1.
+type Easing func(t float64) float64
+
+// Easing functions. Nil Easing is Linear.
+var (
+	Linear Easing = func(t float64) float64 { return t }
+	// Step holds the previous value and jumps to the target at the end.
+	Step Easing = func(t float64) float64 {
+		if t < 1 {
+			return 0
+		}
+		return 1
+	}
+
+	EaseInQuad    Easing = func(t float64) float64 { return t * t }
+	EaseOutQuad   Easing = func(t float64) float64 { return t * (2 - t) }
+	EaseInOutQuad Easing = func(t float64) float64 {
+		if t < 0.5 {
+			return 2 * t * t
+		}
+		return -1 + (4-2*t)*t
+	}
+
+	EaseInCubic    Easing = func(t float64) float64 { return t * t * t }
+	EaseOutCubic   Easing = func(t float64) float64 { return 1 - math.Pow(1-t, 3) }
+	EaseInOutCubic Easing = func(t float64) float64 {
+		if t < 0.5 {
+			return 4 * t * t * t
+		}
+		return 1 - math.Pow(-2*t+2, 3)/2
+	}
+
+	EaseInSine    Easing = func(t float64) float64 { return 1 - math.Cos(t*math.Pi/2) }
+	EaseOutSine   Easing = func(t float64) float64 { return math.Sin(t * math.Pi / 2) }
+	EaseInOutSine Easing = func(t float64) float64 { return -(math.Cos(math.Pi*t) - 1) / 2 }
+
+	// EaseOutBack overshoots the target a bit and comes back.
+	EaseOutBack Easing = func(t float64) float64 {
+		const c1 = 1.70158
+		const c3 = c1 + 1
+		return 1 + c3*math.Pow(t-1, 3) + c1*math.Pow(t-1, 2)
+	}
+
+	// EaseOutBounce bounces at the target like a dropped ball.
+	EaseOutBounce Easing = func(t float64) float64 {
+		const n1 = 7.5625
+		const d1 = 2.75
+		switch {
+		case t < 1/d1:
+			return n1 * t * t
+		case t < 2/d1:
+			t -= 1.5 / d1
+			return n1*t*t + 0.75
+		case t < 2.5/d1:
+			t -= 2.25 / d1
+			return n1*t*t + 0.9375
+		default:
+			t -= 2.625 / d1
+			return n1*t*t + 0.984375
+		}
+	}
+)
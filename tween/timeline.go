@@ -0,0 +1,171 @@
+// Package tween renders keyframe animations, like sliding text or fading icons,
+// to frames for Client.SendAnimationImgs:
+//
+//	tl := tween.NewTimeline(64, 2*time.Second)
+//	tl.Add(tween.NewTextLayer("HELLO", 2)).
+//		X = tween.Track{{At: 0, Value: 64}, {At: time.Second, Value: 8, Ease: tween.EaseOutCubic}}
+//	anim, err := tl.Render(30)
+//	err = c.SendAnimationImgs(1, anim.SpeedMSecs, anim.Imgs)
+package tween
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"time"
+
+	divoom "github.com/suapapa/go_divoom"
+	"github.com/suapapa/go_divoom/internal/pixfont"
+	xdraw "golang.org/x/image/draw"
+)
+
+// MaxFrames is the most frames of an animation the device takes.
+const MaxFrames = 60
+
+// Layer is an image moving on the timeline. Properties without keys stay at
+// their defaults: position 0, opacity 1 and scale 1.
+type Layer struct {
+	Image image.Image
+
+	X, Y    Track // position of top left of the image
+	Opacity Track // 0~1
+	Scale   Track // scaled around the center of the image
+	// Color paints opaque part of the image in the color, like text of
+	// NewTextLayer. The image is drawn as it is if there is no key.
+	Color ColorTrack
+	// Smooth scales the image bilinear instead of nearest neighbor
+	// which keeps pixel art sharp.
+	Smooth bool
+}
+
+// NewImageLayer returns Layer of img.
+func NewImageLayer(img image.Image) *Layer {
+	return &Layer{Image: img}
+}
+
+// NewTextLayer returns Layer of s in the built-in small font, white by default.
+func NewTextLayer(s string, scale int) *Layer {
+	sz := pixfont.Measure(s, scale)
+	img := image.NewRGBA(image.Rectangle{Max: sz})
+	pixfont.Draw(img, image.Point{}, s, color.White, scale)
+	return &Layer{Image: img}
+}
+
+// draw draws l at t on dst.
+func (l *Layer) draw(dst draw.Image, t time.Duration) {
+	opacity := math.Max(0, math.Min(1, l.Opacity.Value(t, 1)))
+	scale := l.Scale.Value(t, 1)
+	if l.Image == nil || opacity == 0 || scale <= 0 {
+		return
+	}
+
+	b := l.Image.Bounds()
+	w, h := float64(b.Dx())*scale, float64(b.Dy())*scale
+	cx := l.X.Value(t, 0) + float64(b.Dx())/2
+	cy := l.Y.Value(t, 0) + float64(b.Dy())/2
+	r := image.Rect(
+		int(math.Round(cx-w/2)), int(math.Round(cy-h/2)),
+		int(math.Round(cx+w/2)), int(math.Round(cy+h/2)),
+	)
+	if r.Empty() {
+		return
+	}
+
+	src := image.NewRGBA(image.Rectangle{Max: r.Size()})
+	if r.Size() == b.Size() {
+		draw.Draw(src, src.Rect, l.Image, b.Min, draw.Src)
+	} else {
+		var s xdraw.Scaler = xdraw.NearestNeighbor
+		if l.Smooth {
+			s = xdraw.ApproxBiLinear
+		}
+		s.Scale(src, src.Rect, l.Image, b, draw.Src, nil)
+	}
+
+	if c := l.Color.Value(t); c != nil {
+		painted := image.NewRGBA(src.Rect)
+		draw.DrawMask(painted, painted.Rect, image.NewUniform(c), image.Point{}, src, image.Point{}, draw.Src)
+		src = painted
+	}
+
+	mask := image.NewUniform(color.Alpha16{uint16(opacity * 0xffff)})
+	draw.DrawMask(dst, r, src, image.Point{}, mask, image.Point{}, draw.Over)
+}
+
+// Timeline is layers animated for Duration on panel of Size.
+type Timeline struct {
+	Size       int
+	Duration   time.Duration
+	Background color.Color // black by default
+	// Hold adds the last frame, at Duration, shown for Hold.
+	// Set it to keep the final state, like slid in text, for a while.
+	Hold time.Duration
+
+	Layers []*Layer // drawn in order, the first at the bottom
+}
+
+// NewTimeline returns Timeline of panel of size, 16, 32 or 64, for d.
+func NewTimeline(size int, d time.Duration) *Timeline {
+	return &Timeline{
+		Size:     size,
+		Duration: d,
+	}
+}
+
+// Add adds l on top of the layers and returns it.
+func (tl *Timeline) Add(l *Layer) *Layer {
+	tl.Layers = append(tl.Layers, l)
+	return l
+}
+
+// Frame draws the layers at t.
+func (tl *Timeline) Frame(t time.Duration) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, tl.Size, tl.Size))
+	bg := tl.Background
+	if bg == nil {
+		bg = color.Black
+	}
+	draw.Draw(img, img.Rect, image.NewUniform(bg), image.Point{}, draw.Src)
+	for _, l := range tl.Layers {
+		l.draw(img, t)
+	}
+	return img
+}
+
+// Render renders the timeline at fps. If it needs more than MaxFrames,
+// including the frame of Hold, fps is lowered to fit.
+// Delays of frames sum to Duration, plus Hold.
+func (tl *Timeline) Render(fps int) (divoom.Animation, error) {
+	if tl.Size != 64 && tl.Size != 32 && tl.Size != 16 {
+		return divoom.Animation{}, divoom.ErrInvalidPicWidth
+	}
+	if fps < 1 {
+		return divoom.Animation{}, fmt.Errorf("invalid fps %d", fps)
+	}
+	if tl.Duration <= 0 {
+		return divoom.Animation{}, fmt.Errorf("invalid duration %v", tl.Duration)
+	}
+
+	maxFrames := MaxFrames
+	if tl.Hold > 0 {
+		maxFrames--
+	}
+	n := int(math.Round(tl.Duration.Seconds() * float64(fps)))
+	n = max(1, min(n, maxFrames))
+
+	var anim divoom.Animation
+	total := int(tl.Duration.Milliseconds())
+	for i := 0; i < n; i++ {
+		t := tl.Duration * time.Duration(i) / time.Duration(n)
+		anim.Imgs = append(anim.Imgs, tl.Frame(t))
+		// spread rounding of milliseconds over the frames
+		anim.SpeedMSecs = append(anim.SpeedMSecs, (i+1)*total/n-i*total/n)
+	}
+	if tl.Hold > 0 {
+		anim.Imgs = append(anim.Imgs, tl.Frame(tl.Duration))
+		anim.SpeedMSecs = append(anim.SpeedMSecs, int(tl.Hold.Milliseconds()))
+	}
+	return anim, nil
+}
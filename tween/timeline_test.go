@@ -0,0 +1,121 @@
+package tween
+
+import (
+	"image"
+	"image/color"
+	"testing"
+	"time"
+
+	divoom "github.com/suapapa/go_divoom"
+)
+
+func sum(vs []int) int {
+	s := 0
+	for _, v := range vs {
+		s += v
+	}
+	return s
+}
+
+func TestRenderFrames(t *testing.T) {
+	tcs := []struct {
+		name      string
+		dur, hold time.Duration
+		fps       int
+		wantN     int // frames without the frame of hold
+	}{
+		{"fits", time.Second, 0, 30, 30},
+		{"lowered", 4 * time.Second, 0, 30, MaxFrames},
+		{"lowered with hold", 4 * time.Second, time.Second, 30, MaxFrames - 1},
+		{"fits with hold", time.Second, 500 * ms, 10, 10},
+		{"shorter than a frame", 10 * ms, 0, 10, 1},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			tl := NewTimeline(16, tc.dur)
+			tl.Hold = tc.hold
+			anim, err := tl.Render(tc.fps)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			n := len(anim.Imgs)
+			if tc.hold > 0 {
+				n--
+				if got := anim.SpeedMSecs[n]; got != int(tc.hold.Milliseconds()) {
+					t.Errorf("hold frame of %dms, want %v", got, tc.hold)
+				}
+			}
+			if n != tc.wantN || len(anim.SpeedMSecs) != len(anim.Imgs) {
+				t.Errorf("%d frames and %d delays, want %d frames", n, len(anim.SpeedMSecs), tc.wantN)
+			}
+			if got := sum(anim.SpeedMSecs[:n]); got != int(tc.dur.Milliseconds()) {
+				t.Errorf("delays sum to %dms, want %v", got, tc.dur)
+			}
+		})
+	}
+}
+
+func TestRenderSpreadMilliseconds(t *testing.T) {
+	tl := NewTimeline(16, 1001*ms)
+	anim, err := tl.Render(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int{333, 334, 334}
+	for i, d := range anim.SpeedMSecs {
+		if d != want[i] {
+			t.Errorf("delays %v, want %v", anim.SpeedMSecs, want)
+			break
+		}
+	}
+}
+
+func TestRenderError(t *testing.T) {
+	tcs := []struct {
+		name string
+		size int
+		dur  time.Duration
+		fps  int
+	}{
+		{"size", 48, time.Second, 10},
+		{"fps", 16, time.Second, 0},
+		{"duration", 16, 0, 10},
+	}
+	for _, tc := range tcs {
+		if _, err := NewTimeline(tc.size, tc.dur).Render(tc.fps); err == nil {
+			t.Errorf("no error for invalid %s", tc.name)
+		}
+	}
+	if _, err := NewTimeline(48, time.Second).Render(10); err != divoom.ErrInvalidPicWidth {
+		t.Errorf("error = %v, want ErrInvalidPicWidth", err)
+	}
+}
+
+func TestFrame(t *testing.T) {
+	dot := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	dot.Set(0, 0, color.White)
+
+	tl := NewTimeline(16, time.Second)
+	l := tl.Add(NewImageLayer(dot))
+	l.X = Track{{At: 0, Value: 0}, {At: time.Second, Value: 10}}
+	l.Y = Track{{At: 0, Value: 3}}
+
+	white := color.RGBA{0xff, 0xff, 0xff, 0xff}
+	for _, tc := range []struct {
+		t    time.Duration
+		want image.Point
+	}{
+		{0, image.Pt(0, 3)},
+		{500 * ms, image.Pt(5, 3)},
+		{2 * time.Second, image.Pt(10, 3)},
+	} {
+		img := tl.Frame(tc.t)
+		if got := img.RGBAAt(tc.want.X, tc.want.Y); got != white {
+			t.Errorf("pixel at %v of %v = %v, want white", tc.want, tc.t, got)
+		}
+		if got := img.RGBAAt(tc.want.X+1, tc.want.Y); got != (color.RGBA{0, 0, 0, 0xff}) {
+			t.Errorf("pixel at right of the dot = %v, want black background", got)
+		}
+	}
+}
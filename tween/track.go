@@ -0,0 +1,81 @@
+package tween
+
+import (
+	"image/color"
+	"math"
+	"time"
+)
+
+// Key is value of a property at time At. Ease is how the property
+// changes from the previous key to this one.
+type Key struct {
+	At    time.Duration
+	Value float64
+	Ease  Easing
+}
+
+// Track is keys of a property, in order of At.
+type Track []Key
+
+// Value returns value of the track at t. It is the first or the last value
+// before or after the keys, and def if there is no key.
+func (tr Track) Value(t time.Duration, def float64) float64 {
+	if len(tr) == 0 {
+		return def
+	}
+	i, p := segment(len(tr), func(i int) (time.Duration, Easing) { return tr[i].At, tr[i].Ease }, t)
+	if p < 0 {
+		return tr[i].Value
+	}
+	return tr[i-1].Value + (tr[i].Value-tr[i-1].Value)*p
+}
+
+// ColorKey is color of a property at time At. Ease is how the color
+// changes from the previous key to this one.
+type ColorKey struct {
+	At    time.Duration
+	Color color.Color
+	Ease  Easing
+}
+
+// ColorTrack is keys of a color property, in order of At.
+type ColorTrack []ColorKey
+
+// Value returns color of the track at t, or nil if there is no key.
+func (tr ColorTrack) Value(t time.Duration) color.Color {
+	if len(tr) == 0 {
+		return nil
+	}
+	i, p := segment(len(tr), func(i int) (time.Duration, Easing) { return tr[i].At, tr[i].Ease }, t)
+	if p < 0 {
+		return tr[i].Color
+	}
+
+	r0, g0, b0, a0 := tr[i-1].Color.RGBA()
+	r1, g1, b1, a1 := tr[i].Color.RGBA()
+	lerp := func(v0, v1 uint32) uint16 {
+		return uint16(math.Round(float64(v0) + (float64(v1)-float64(v0))*p))
+	}
+	return color.RGBA64{lerp(r0, r1), lerp(g0, g1), lerp(b0, b1), lerp(a0, a1)}
+}
+
+// segment finds key i which t is before, and eased progress p from key i-1 to i.
+// p is -1 if t is at or out of the keys and key i should be used as it is.
+func segment(n int, key func(i int) (time.Duration, Easing), t time.Duration) (int, float64) {
+	if at, _ := key(0); t <= at {
+		return 0, -1
+	}
+	for i := 1; i < n; i++ {
+		at, ease := key(i)
+		if t >= at {
+			continue
+		}
+		prev, _ := key(i - 1)
+		p := float64(t-prev) / float64(at-prev)
+		if ease == nil {
+			ease = Linear
+		}
+		return i, ease(p)
+	}
+	return n - 1, -1
+}
@@ -0,0 +1,91 @@
+package tween
+
+import (
+	"image/color"
+	"math"
+	"testing"
+	"time"
+)
+
+const ms = time.Millisecond
+
+func TestTrackValue(t *testing.T) {
+	tr := Track{
+		{At: 100 * ms, Value: 10},
+		{At: 200 * ms, Value: 20},
+		{At: 400 * ms, Value: 0, Ease: EaseInQuad},
+	}
+	tcs := []struct {
+		t    time.Duration
+		want float64
+	}{
+		{0, 10}, // before the keys
+		{100 * ms, 10},
+		{150 * ms, 15},
+		{200 * ms, 20},
+		{300 * ms, 15}, // eased progress of 0.5 is 0.25
+		{400 * ms, 0},
+		{time.Second, 0}, // after the keys
+	}
+	for _, tc := range tcs {
+		if got := tr.Value(tc.t, -1); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("value at %v = %v, want %v", tc.t, got, tc.want)
+		}
+	}
+
+	if got := Track(nil).Value(time.Second, 7); got != 7 {
+		t.Errorf("value of no key = %v, want default 7", got)
+	}
+	if got := (Track{{At: time.Second, Value: 3}}).Value(0, 7); got != 3 {
+		t.Errorf("value of a key = %v, want 3", got)
+	}
+}
+
+func TestSegment(t *testing.T) {
+	ats := []time.Duration{0, 100 * ms, 100 * ms, 300 * ms}
+	key := func(i int) (time.Duration, Easing) { return ats[i], nil }
+	tcs := []struct {
+		t     time.Duration
+		wantI int
+		wantP float64
+	}{
+		{-ms, 0, -1},
+		{0, 0, -1},
+		{50 * ms, 1, 0.5},
+		// keys at the same time jump to the later one
+		{100 * ms, 3, 0},
+		{200 * ms, 3, 0.5},
+		{300 * ms, 3, -1},
+		{time.Second, 3, -1},
+	}
+	for _, tc := range tcs {
+		i, p := segment(len(ats), key, tc.t)
+		if i != tc.wantI || math.Abs(p-tc.wantP) > 1e-9 {
+			t.Errorf("segment at %v = %d, %v, want %d, %v", tc.t, i, p, tc.wantI, tc.wantP)
+		}
+	}
+}
+
+func TestColorTrackValue(t *testing.T) {
+	tr := ColorTrack{
+		{At: 0, Color: color.Black},
+		{At: 100 * ms, Color: color.White},
+	}
+	if c := (ColorTrack(nil)).Value(0); c != nil {
+		t.Errorf("color of no key = %v, want nil", c)
+	}
+
+	tcs := []struct {
+		t    time.Duration
+		want color.RGBA64
+	}{
+		{-ms, color.RGBA64{0, 0, 0, 0xffff}},
+		{50 * ms, color.RGBA64{0x8000, 0x8000, 0x8000, 0xffff}},
+		{time.Second, color.RGBA64{0xffff, 0xffff, 0xffff, 0xffff}},
+	}
+	for _, tc := range tcs {
+		if got := color.RGBA64Model.Convert(tr.Value(tc.t)); got != tc.want {
+			t.Errorf("color at %v = %v, want %v", tc.t, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package divoom
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+
+	"github.com/nfnt/resize"
+	"github.com/pkg/errors"
+)
+
+// Tile is a device in VideoWall.
+type Tile struct {
+	Client *Client
+	Row    int
+	Col    int
+	// Rotation rotates the tile image clockwise, as SetRotationAngle does to the screen,
+	// for panels mounted turned. Keep the device itself in RotationAngle0
+	// or the rotation is applied twice.
+	Rotation RotationAngle
+}
+
+// VideoWall shows one large image across devices arranged in a grid.
+type VideoWall struct {
+	Rows      int
+	Cols      int
+	PanelSize int // 16, 32 or 64
+	// Bezel is width of the gap between two panels in pixels of the panel.
+	// Pixels of the image under the gap are not shown, so lines continue straight across panels.
+	Bezel int
+
+	tiles []*Tile
+}
+
+// NewVideoWall returns VideoWall of rows×cols panels of panelSize.
+func NewVideoWall(rows, cols, panelSize int) *VideoWall {
+	return &VideoWall{
+		Rows:      rows,
+		Cols:      cols,
+		PanelSize: panelSize,
+	}
+}
+
+// SetTile places device of c at row and col, 0 based from top left.
+func (w *VideoWall) SetTile(row, col int, c *Client, rot RotationAngle) {
+	for _, t := range w.tiles {
+		if t.Row == row && t.Col == col {
+			t.Client, t.Rotation = c, rot
+			return
+		}
+	}
+	w.tiles = append(w.tiles, &Tile{Client: c, Row: row, Col: col, Rotation: rot})
+}
+
+// Tiles returns the tiles of the wall.
+func (w *VideoWall) Tiles() []*Tile {
+	return append([]*Tile(nil), w.tiles...)
+}
+
+// Size returns size of whole image of the wall, including bezels.
+func (w *VideoWall) Size() image.Point {
+	return image.Pt(
+		w.Cols*w.PanelSize+(w.Cols-1)*w.Bezel,
+		w.Rows*w.PanelSize+(w.Rows-1)*w.Bezel,
+	)
+}
+
+func (w *VideoWall) check() error {
+	if w.PanelSize != 64 && w.PanelSize != 32 && w.PanelSize != 16 {
+		return ErrInvalidPicWidth
+	}
+	if w.Rows < 1 || w.Cols < 1 {
+		return fmt.Errorf("want at least 1x1 wall but got %dx%d", w.Rows, w.Cols)
+	}
+
+	placed := make(map[image.Point]bool)
+	for _, t := range w.tiles {
+		if t.Row < 0 || t.Row >= w.Rows || t.Col < 0 || t.Col >= w.Cols {
+			return fmt.Errorf("tile %d,%d is out of %dx%d wall", t.Row, t.Col, w.Rows, w.Cols)
+		}
+		if t.Client == nil {
+			return fmt.Errorf("tile %d,%d has no device", t.Row, t.Col)
+		}
+		placed[image.Pt(t.Col, t.Row)] = true
+	}
+	if len(placed) != w.Rows*w.Cols {
+		return fmt.Errorf("want %d tiles but %d are set", w.Rows*w.Cols, len(placed))
+	}
+	return nil
+}
+
+// Fit crops center of img to aspect ratio of the wall and resizes it to Size.
+func (w *VideoWall) Fit(img image.Image) image.Image {
+	size := w.Size()
+	b := img.Bounds()
+	cw, ch := b.Dx(), b.Dy()
+	if cw*size.Y > ch*size.X {
+		cw = ch * size.X / size.Y
+	} else {
+		ch = cw * size.Y / size.X
+	}
+
+	// crop center rect
+	sp := b.Min.Add(image.Pt((b.Dx()-cw)/2, (b.Dy()-ch)/2))
+	cImg := image.NewRGBA(image.Rect(0, 0, cw, ch))
+	draw.Draw(cImg, cImg.Bounds(), img, sp, draw.Src)
+	if cw == size.X && ch == size.Y {
+		return cImg
+	}
+	return resize.Resize(uint(size.X), uint(size.Y), cImg, resize.Lanczos3)
+}
+
+// Slice fits img to the wall and slices it to images of the tiles, in order of Tiles.
+func (w *VideoWall) Slice(img image.Image) ([]image.Image, error) {
+	if err := w.check(); err != nil {
+		return nil, errors.Wrap(err, "fail to slice image")
+	}
+	img = w.Fit(img)
+
+	imgs := make([]image.Image, len(w.tiles))
+	for i, t := range w.tiles {
+		step := w.PanelSize + w.Bezel
+		sp := image.Pt(t.Col*step, t.Row*step)
+		tImg := image.NewRGBA(image.Rect(0, 0, w.PanelSize, w.PanelSize))
+		draw.Draw(tImg, tImg.Bounds(), img, sp, draw.Src)
+		imgs[i] = rotateImg(tImg, t.Rotation)
+	}
+	return imgs, nil
+}
+
+// rotateImg rotates square img clockwise by angle.
+func rotateImg(img *image.RGBA, angle RotationAngle) *image.RGBA {
+	n := img.Bounds().Dx()
+	for r := 0; r < int(angle)%4; r++ {
+		dst := image.NewRGBA(image.Rect(0, 0, n, n))
+		for y := 0; y < n; y++ {
+			for x := 0; x < n; x++ {
+				dst.SetRGBA(x, y, img.RGBAAt(y, n-1-x))
+			}
+		}
+		img = dst
+	}
+	return img
+}
+
+// animations slices frames of an animation to animations of the tiles.
+func (w *VideoWall) animations(speedMSecs []int, imgs []image.Image) ([]Animation, error) {
+	anims := make([]Animation, len(w.tiles))
+	for i := range anims {
+		anims[i] = Animation{
+			SpeedMSecs: speedMSecs,
+			Imgs:       make([]image.Image, len(imgs)),
+		}
+	}
+	for j, img := range imgs {
+		tImgs, err := w.Slice(img)
+		if err != nil {
+			return nil, err
+		}
+		for i := range anims {
+			anims[i].Imgs[j] = tImgs[i]
+		}
+	}
+	return anims, nil
+}
+
+// SendAnimationImgs slices each frame and sends the tiles to the devices concurrently.
+func (w *VideoWall) SendAnimationImgs(id int, speedMSecs []int, imgs []image.Image) error {
+	anims, err := w.animations(speedMSecs, imgs)
+	if err != nil {
+		return errors.Wrap(err, "fail to send animation to video wall")
+	}
+
+	return w.Group().do(func(i int, c *Client) error {
+		return c.SendAnimationImgs(id, anims[i].SpeedMSecs, anims[i].Imgs)
+	})
+}
+
+// SyncSendAnimationImgs is like SendAnimationImgs but
+// starts the animation on all devices at the same time, with Group.SyncSendAnimations.
+func (w *VideoWall) SyncSendAnimationImgs(id int, speedMSecs []int, imgs []image.Image) error {
+	anims, err := w.animations(speedMSecs, imgs)
+	if err != nil {
+		return errors.Wrap(err, "fail to send animation to video wall")
+	}
+
+	return w.Group().SyncSendAnimations(id, anims)
+}
+
+// SendAnimationGif sends the gif animation across the wall in sync.
+// Frames are composited on the canvas of the gif before slicing.
+func (w *VideoWall) SendAnimationGif(id int, gifImg *gif.GIF) error {
+	frames, delayMSecs := gifFrames(gifImg)
+	return w.SyncSendAnimationImgs(id, delayMSecs, frames)
+}
+
+// Group returns Group of the devices of the wall, in order of Tiles.
+func (w *VideoWall) Group() *Group {
+	clients := make([]*Client, len(w.tiles))
+	for i, t := range w.tiles {
+		clients[i] = t.Client
+	}
+	return NewGroup(clients...)
+}
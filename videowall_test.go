@@ -0,0 +1,130 @@
+package divoom
+
+import (
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+)
+
+var testPalette = color.Palette{
+	color.RGBA{},
+	color.RGBA{0xff, 0, 0, 0xff},
+	color.RGBA{0, 0, 0xff, 0xff},
+	color.RGBA{0, 0xff, 0, 0xff},
+}
+
+// paletted returns frame of r filled with the colors of testPalette, in columns of the same width.
+func paletted(r image.Rectangle, idxs ...uint8) *image.Paletted {
+	p := image.NewPaletted(r, testPalette)
+	cw := r.Dx() / len(idxs)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			p.SetColorIndex(x, y, idxs[(x-r.Min.X)/cw])
+		}
+	}
+	return p
+}
+
+// sentColors returns color of first pixel of each frame sent to d.
+func sentColors(t *testing.T, d *fakeDevice) []color.RGBA {
+	t.Helper()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var cs []color.RGBA
+	for _, cmd := range d.cmds {
+		if cmd["Command"] != "Draw/SendHttpGif" {
+			continue
+		}
+		b, err := base64.StdEncoding.DecodeString(cmd["PicData"].(string))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cs = append(cs, color.RGBA{b[0], b[1], b[2], 0xff})
+	}
+	return cs
+}
+
+func TestVideoWallSendAnimationGif(t *testing.T) {
+	w := NewVideoWall(1, 2, 16)
+	c0, d0 := newFakeClient()
+	c1, d1 := newFakeClient()
+	w.SetTile(0, 0, c0, RotationAngle0)
+	w.SetTile(0, 1, c1, RotationAngle0)
+
+	// second frame only updates the right half
+	g := &gif.GIF{
+		Image: []*image.Paletted{
+			paletted(image.Rect(0, 0, 32, 16), 1, 2),
+			paletted(image.Rect(16, 0, 32, 16), 3),
+		},
+		Delay:    []int{10, 10},
+		Disposal: []byte{gif.DisposalNone, gif.DisposalNone},
+		Config:   image.Config{Width: 32, Height: 16},
+	}
+	if err := w.SendAnimationGif(1, g); err != nil {
+		t.Fatal(err)
+	}
+
+	red, blue, green := testPalette[1].(color.RGBA), testPalette[2].(color.RGBA), testPalette[3].(color.RGBA)
+	for i, tc := range []struct {
+		d    *fakeDevice
+		want []color.RGBA
+	}{
+		{d0, []color.RGBA{red, red}},
+		{d1, []color.RGBA{blue, green}},
+	} {
+		got := sentColors(t, tc.d)
+		if len(got) != len(tc.want) {
+			t.Fatalf("tile %d got %d frames, want %d", i, len(got), len(tc.want))
+		}
+		for j := range got {
+			if got[j] != tc.want[j] {
+				t.Errorf("tile %d frame %d = %v, want %v", i, j, got[j], tc.want[j])
+			}
+		}
+	}
+}
+
+func TestVideoWallSlice(t *testing.T) {
+	w := NewVideoWall(1, 2, 16)
+	w.Bezel = 2
+	c0, _ := newFakeClient()
+	c1, _ := newFakeClient()
+	w.SetTile(0, 0, c0, RotationAngle0)
+	w.SetTile(0, 1, c1, RotationAngle90)
+
+	// red left half, blue right half. pixels under the bezel are not shown
+	img := paletted(image.Rect(0, 0, 34, 16), 1, 2)
+	imgs, err := w.Slice(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(imgs) != 2 {
+		t.Fatalf("got %d tiles", len(imgs))
+	}
+	red, blue := testPalette[1].(color.RGBA), testPalette[2].(color.RGBA)
+	if c := color.RGBAModel.Convert(imgs[0].At(15, 0)); c != red {
+		t.Errorf("right edge of tile 0 = %v, want red", c)
+	}
+	// rotated clockwise, top left came from bottom left
+	if c := color.RGBAModel.Convert(imgs[1].At(0, 0)); c != blue {
+		t.Errorf("left edge of tile 1 = %v, want blue", c)
+	}
+}
+
+func TestVideoWallCheck(t *testing.T) {
+	w := NewVideoWall(1, 2, 16)
+	c, _ := newFakeClient()
+	w.SetTile(0, 0, c, RotationAngle0)
+	if _, err := w.Slice(image.NewRGBA(image.Rect(0, 0, 32, 16))); err == nil {
+		t.Error("no error for missing tile")
+	}
+
+	w.SetTile(0, 2, c, RotationAngle0)
+	if _, err := w.Slice(image.NewRGBA(image.Rect(0, 0, 32, 16))); err == nil {
+		t.Error("no error for tile out of the wall")
+	}
+}
@@ -0,0 +1,175 @@
+package divoom
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+
+	"github.com/pkg/errors"
+	"golang.org/x/image/webp"
+)
+
+type riffChunk struct {
+	fourCC string
+	data   []byte
+}
+
+// IsWebP returns true if b is WebP, animated or not.
+func IsWebP(b []byte) bool {
+	return len(b) >= 12 && string(b[0:4]) == "RIFF" && string(b[8:12]) == "WEBP"
+}
+
+// DecodeWebP decodes frames of animated WebP, composed to the full size, with their durations.
+// WebP which isn't animated is decoded as a frame with 0 delay.
+func DecodeWebP(r io.Reader) (Animation, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return Animation{}, errors.Wrap(err, "fail to decode webp")
+	}
+	if !IsWebP(b) {
+		return Animation{}, fmt.Errorf("fail to decode webp: not webp")
+	}
+	chunks, err := readRIFFChunks(b[12:])
+	if err != nil {
+		return Animation{}, errors.Wrap(err, "fail to decode webp")
+	}
+
+	var w, h int
+	var anmfs [][]byte
+	for _, c := range chunks {
+		switch c.fourCC {
+		case "VP8X":
+			if len(c.data) < 10 {
+				return Animation{}, fmt.Errorf("fail to decode webp: short VP8X")
+			}
+			w = int(uint24(c.data[4:])) + 1
+			h = int(uint24(c.data[7:])) + 1
+		case "ANMF":
+			anmfs = append(anmfs, c.data)
+		}
+	}
+
+	if len(anmfs) == 0 {
+		img, err := webp.Decode(bytes.NewReader(b))
+		if err != nil {
+			return Animation{}, errors.Wrap(err, "fail to decode webp")
+		}
+		return Animation{Imgs: []image.Image{img}, SpeedMSecs: []int{0}}, nil
+	}
+
+	if w == 0 || h == 0 {
+		return Animation{}, fmt.Errorf("fail to decode webp: no VP8X for canvas size of animation")
+	}
+	canvas := image.NewRGBA(image.Rect(0, 0, w, h))
+	var anim Animation
+	for i, d := range anmfs {
+		if len(d) < 16 {
+			return Animation{}, fmt.Errorf("fail to decode webp: short ANMF %d", i)
+		}
+		x := int(uint24(d[0:])) * 2
+		y := int(uint24(d[3:])) * 2
+		fw := int(uint24(d[6:])) + 1
+		fh := int(uint24(d[9:])) + 1
+		dur := int(uint24(d[12:]))
+		flags := d[15]
+
+		img, err := decodeWebPFrame(d[16:], fw, fh)
+		if err != nil {
+			return Animation{}, errors.Wrapf(err, "fail to decode webp frame %d", i)
+		}
+
+		r := image.Rect(x, y, x+fw, y+fh)
+		op := draw.Over
+		if flags&0x02 != 0 { // do not blend
+			op = draw.Src
+		}
+		draw.Draw(canvas, r, img, img.Bounds().Min, op)
+
+		out := image.NewRGBA(canvas.Rect)
+		copy(out.Pix, canvas.Pix)
+		anim.Imgs = append(anim.Imgs, out)
+		anim.SpeedMSecs = append(anim.SpeedMSecs, dur)
+
+		if flags&0x01 != 0 { // dispose to background
+			draw.Draw(canvas, r, image.Transparent, image.Point{}, draw.Src)
+		}
+	}
+	return anim, nil
+}
+
+// decodeWebPFrame decodes frame data of ANMF as standalone WebP.
+func decodeWebPFrame(data []byte, w, h int) (image.Image, error) {
+	chunks, err := readRIFFChunks(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var alph, bitstream *riffChunk
+	for i := range chunks {
+		switch chunks[i].fourCC {
+		case "ALPH":
+			alph = &chunks[i]
+		case "VP8 ", "VP8L":
+			bitstream = &chunks[i]
+		}
+	}
+	if bitstream == nil {
+		return nil, fmt.Errorf("no image data")
+	}
+
+	var body bytes.Buffer
+	body.WriteString("WEBP")
+	if alph != nil && bitstream.fourCC == "VP8 " {
+		vp8x := make([]byte, 10)
+		vp8x[0] = 0x10 // alpha
+		putUint24(vp8x[4:], uint32(w-1))
+		putUint24(vp8x[7:], uint32(h-1))
+		writeRIFFChunk(&body, "VP8X", vp8x)
+		writeRIFFChunk(&body, "ALPH", alph.data)
+	}
+	writeRIFFChunk(&body, bitstream.fourCC, bitstream.data)
+
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(body.Len()))
+	buf.Write(body.Bytes())
+	return webp.Decode(&buf)
+}
+
+func readRIFFChunks(b []byte) ([]riffChunk, error) {
+	var chunks []riffChunk
+	for len(b) >= 8 {
+		n := binary.LittleEndian.Uint32(b[4:])
+		if uint64(n)+8 > uint64(len(b)) {
+			return nil, fmt.Errorf("truncated chunk")
+		}
+		chunks = append(chunks, riffChunk{fourCC: string(b[0:4]), data: b[8 : 8+n]})
+		// chunks are padded to even size
+		n += n & 1
+		if uint64(n)+8 > uint64(len(b)) {
+			break
+		}
+		b = b[8+n:]
+	}
+	return chunks, nil
+}
+
+func writeRIFFChunk(w io.Writer, fourCC string, data []byte) {
+	io.WriteString(w, fourCC)
+	binary.Write(w, binary.LittleEndian, uint32(len(data)))
+	w.Write(data)
+	if len(data)&1 == 1 {
+		w.Write([]byte{0})
+	}
+}
+
+func uint24(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+}
+
+func putUint24(b []byte, v uint32) {
+	b[0], b[1], b[2] = byte(v), byte(v>>8), byte(v>>16)
+}
@@ -0,0 +1,75 @@
+package divoom
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDecodeWebP(t *testing.T) {
+	tcs := []animTest{
+		{
+			// no blend, blend over and dispose to background,
+			// with odd sized chunks padded
+			file:       "testdata/anim.webp",
+			wantDelays: []int{100, 200, 300},
+			wantFrames: [][]string{
+				{"RRRR", "RRRR", "RRRR", "RRRR"},
+				{"RRRR", "RRRR", "RRRB", "RRBB"},
+				{"GGRR", "GGRR", "RR..", "RR.."},
+			},
+		},
+		{
+			file:       "testdata/still.webp",
+			wantDelays: []int{0},
+			wantFrames: [][]string{
+				{"BBBB", "BBBB", "BBBB", "BBBB"},
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.file, func(t *testing.T) {
+			b, err := os.ReadFile(tc.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !IsWebP(b) {
+				t.Errorf("IsWebP(%s) = false", tc.file)
+			}
+			anim, err := DecodeWebP(bytes.NewReader(b))
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkAnimation(t, anim, tc)
+		})
+	}
+}
+
+func TestDecodeWebPError(t *testing.T) {
+	noVP8X, err := os.ReadFile("testdata/anim-no-vp8x.webp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	anim, err := os.ReadFile("testdata/anim.webp")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tcs := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{"not webp", []byte("\x89PNG\r\n\x1a\n"), "not webp"},
+		{"no VP8X", noVP8X, "no VP8X"},
+		{"truncated", anim[:len(anim)-10], "truncated"},
+	}
+	for _, tc := range tcs {
+		_, err := DecodeWebP(bytes.NewReader(tc.data))
+		if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("%s: error = %v, want %q", tc.name, err, tc.wantErr)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+// Package widget composes dashboards of widgets on Divoom panels.
+//
+//	d := widget.NewDashboard(c, 64)
+//	d.Add(
+//		widget.NewClock(image.Rect(0, 0, 64, 16), "15:04", color.White, 2),
+//		widget.NewLabel(image.Rect(0, 20, 64, 26), "CPU", color.White, 1),
+//		cpu, // *widget.Sparkline updated by Push
+//	)
+//	err := d.Run(ctx)
+package widget
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+	"time"
+
+	divoom "github.com/suapapa/go_divoom"
+)
+
+// Widget is a part of Dashboard.
+type Widget interface {
+	// Bounds is where the widget is drawn in the panel.
+	Bounds() image.Rectangle
+	// Render draws the widget to dst, clipped to Bounds.
+	Render(dst draw.Image, now time.Time)
+	// RefreshInterval is how often the widget is rendered.
+	// Widget of 0 is rendered on every update of Dashboard.
+	RefreshInterval() time.Duration
+}
+
+// maxPicID is the PicID where Dashboard resets sending animation id of the device.
+const maxPicID = 1000
+
+// Dashboard renders widgets to frames and sends a frame to the device
+// only when it differs from the last sent one.
+type Dashboard struct {
+	c    *divoom.Client
+	size int
+
+	// Background fills the panel under widgets; black by default.
+	Background color.Color
+	// Tick is how often widgets are checked. By default, it's the shortest
+	// RefreshInterval of the widgets, or a second.
+	Tick time.Duration
+	// OnError is called with errors while running. Errors are ignored if nil.
+	OnError func(error)
+
+	mu      sync.Mutex
+	widgets []Widget
+	due     []time.Time
+	canvas  *image.RGBA
+	painted bool
+	last    []byte
+	picID   int
+	refresh chan struct{}
+}
+
+// NewDashboard returns Dashboard for the panel of c with size of 16, 32 or 64.
+func NewDashboard(c *divoom.Client, size int) *Dashboard {
+	return &Dashboard{
+		c:          c,
+		size:       size,
+		Background: color.Black,
+		canvas:     image.NewRGBA(image.Rect(0, 0, size, size)),
+		refresh:    make(chan struct{}, 1),
+	}
+}
+
+// Add adds widgets to the dashboard. Later one is drawn over earlier ones.
+func (d *Dashboard) Add(ws ...Widget) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.widgets = append(d.widgets, ws...)
+	d.due = append(d.due, make([]time.Time, len(ws))...)
+}
+
+// Refresh makes running dashboard render all widgets now.
+func (d *Dashboard) Refresh() {
+	d.mu.Lock()
+	for i := range d.due {
+		d.due[i] = time.Time{}
+	}
+	d.mu.Unlock()
+
+	select {
+	case d.refresh <- struct{}{}:
+	default:
+	}
+}
+
+// Frame renders the widgets which are due at now and returns copy of the frame.
+func (d *Dashboard) Frame(now time.Time) *image.RGBA {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.render(now)
+	frame := image.NewRGBA(d.canvas.Rect)
+	copy(frame.Pix, d.canvas.Pix)
+	return frame
+}
+
+func (d *Dashboard) render(now time.Time) {
+	if !d.painted {
+		draw.Draw(d.canvas, d.canvas.Rect, image.NewUniform(d.Background), image.Point{}, draw.Src)
+		d.painted = true
+	}
+
+	redraw := make([]bool, len(d.widgets))
+	for i, w := range d.widgets {
+		redraw[i] = w.RefreshInterval() == 0 || !now.Before(d.due[i])
+	}
+
+	// clearing a widget erases the ones overlapping it, under or over it,
+	// so redraw those too until no more widget is erased
+	for more := true; more; {
+		more = false
+		for i, w := range d.widgets {
+			if !redraw[i] {
+				continue
+			}
+			for j, o := range d.widgets {
+				if !redraw[j] && o.Bounds().Overlaps(w.Bounds()) {
+					redraw[j], more = true, true
+				}
+			}
+		}
+	}
+
+	// clear all first, not to erase the ones drawn under
+	for i, w := range d.widgets {
+		if redraw[i] {
+			draw.Draw(d.canvas, w.Bounds(), image.NewUniform(d.Background), image.Point{}, draw.Src)
+		}
+	}
+	for i, w := range d.widgets {
+		if !redraw[i] {
+			continue
+		}
+		b := w.Bounds()
+		w.Render(d.canvas.SubImage(b).(draw.Image), now)
+		if iv := w.RefreshInterval(); iv > 0 {
+			d.due[i] = now.Add(iv)
+		}
+	}
+}
+
+// Update renders the widgets and sends the frame if it's changed.
+// It returns true if the frame is sent.
+func (d *Dashboard) Update(now time.Time) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.render(now)
+	if d.last != nil && bytes.Equal(d.last, d.canvas.Pix) {
+		return false, nil
+	}
+
+	if d.picID == 0 || d.picID >= maxPicID {
+		if err := d.c.ResetSendingAnimationPicID(); err != nil {
+			return false, err
+		}
+		d.picID = 0
+	}
+	d.picID++
+
+	frame := image.NewRGBA(d.canvas.Rect)
+	copy(frame.Pix, d.canvas.Pix)
+	if err := d.c.SendAnimationImgs(d.picID, []int{1000}, []image.Image{frame}); err != nil {
+		return false, err
+	}
+	d.last = frame.Pix
+	return true, nil
+}
+
+// Run updates the dashboard every Tick until ctx is done.
+func (d *Dashboard) Run(ctx context.Context) error {
+	tk := time.NewTicker(d.tick())
+	defer tk.Stop()
+
+	for {
+		if _, err := d.Update(time.Now()); err != nil && d.OnError != nil {
+			d.OnError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tk.C:
+		case <-d.refresh:
+		}
+	}
+}
+
+func (d *Dashboard) tick() time.Duration {
+	if d.Tick > 0 {
+		return d.Tick
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	tick := time.Second
+	for _, w := range d.widgets {
+		if iv := w.RefreshInterval(); iv > 0 && iv < tick {
+			tick = iv
+		}
+	}
+	return tick
+}
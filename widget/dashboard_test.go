@@ -0,0 +1,76 @@
+package widget
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+	"time"
+)
+
+// fill is a widget filling the right part of its bounds from x of from.
+type fill struct {
+	rect     image.Rectangle
+	from     int
+	c        color.Color
+	interval time.Duration
+	renders  int
+}
+
+func (w *fill) Bounds() image.Rectangle { return w.rect }
+
+func (w *fill) RefreshInterval() time.Duration { return w.interval }
+
+func (w *fill) Render(dst draw.Image, now time.Time) {
+	r := w.rect
+	r.Min.X = w.from
+	draw.Draw(dst, r, image.NewUniform(w.c), image.Point{}, draw.Src)
+	w.renders++
+}
+
+func TestDashboardOverlap(t *testing.T) {
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	blue := color.RGBA{0, 0, 0xff, 0xff}
+
+	// top shows the bottom through its left half
+	bottom := &fill{rect: image.Rect(0, 0, 8, 16), from: 0, c: red, interval: time.Hour}
+	top := &fill{rect: image.Rect(4, 0, 12, 16), from: 8, c: blue, interval: time.Second}
+	far := &fill{rect: image.Rect(12, 0, 16, 16), from: 12, c: blue, interval: time.Hour}
+
+	for _, tc := range []struct {
+		name    string
+		widgets []*fill
+	}{
+		{"due over", []*fill{bottom, top, far}},
+		{"due under", []*fill{top, bottom, far}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d := NewDashboard(nil, 16)
+			for _, w := range tc.widgets {
+				w.renders = 0
+				d.Add(w)
+			}
+
+			now := time.Now()
+			d.Frame(now)
+			frame := d.Frame(now.Add(time.Second))
+
+			want := map[image.Point]color.RGBA{
+				{2, 0}:  red,
+				{5, 0}:  red, // under or over the top, bottom is seen
+				{10, 0}: blue,
+			}
+			for p, c := range want {
+				if got := frame.RGBAAt(p.X, p.Y); got != c {
+					t.Errorf("pixel at %v = %v, want %v", p, got, c)
+				}
+			}
+			if bottom.renders != 2 {
+				t.Errorf("bottom rendered %d times, want 2", bottom.renders)
+			}
+			if far.renders != 1 {
+				t.Errorf("widget not overlapping rendered %d times, want 1", far.renders)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package widget
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+	"time"
+
+	"github.com/nfnt/resize"
+	"github.com/suapapa/go_divoom/chart"
+	"github.com/suapapa/go_divoom/internal/pixfont"
+)
+
+// Align is horizontal alignment of text in its bounds.
+type Align int
+
+const (
+	AlignLeft Align = iota
+	AlignCenter
+	AlignRight
+)
+
+// drawText draws s in r vertically centered.
+func drawText(dst draw.Image, r image.Rectangle, s string, c color.Color, scale int, align Align) {
+	size := pixfont.Measure(s, scale)
+	p := image.Pt(r.Min.X, r.Min.Y+(r.Dy()-size.Y)/2)
+	switch align {
+	case AlignCenter:
+		p.X += (r.Dx() - size.X) / 2
+	case AlignRight:
+		p.X = r.Max.X - size.X
+	}
+	pixfont.Draw(dst, p, s, c, scale)
+}
+
+// Clock shows current time in Layout of time.Format.
+type Clock struct {
+	Rect     image.Rectangle
+	Layout   string
+	Color    color.Color
+	Scale    int
+	Align    Align
+	Location *time.Location // local time if nil
+}
+
+// NewClock returns centered Clock.
+func NewClock(r image.Rectangle, layout string, c color.Color, scale int) *Clock {
+	return &Clock{
+		Rect:   r,
+		Layout: layout,
+		Color:  c,
+		Scale:  scale,
+		Align:  AlignCenter,
+	}
+}
+
+func (w *Clock) Bounds() image.Rectangle { return w.Rect }
+
+func (w *Clock) RefreshInterval() time.Duration { return time.Second }
+
+func (w *Clock) Render(dst draw.Image, now time.Time) {
+	if w.Location != nil {
+		now = now.In(w.Location)
+	}
+	drawText(dst, w.Rect, now.Format(w.Layout), w.Color, w.Scale, w.Align)
+}
+
+// Label shows a line of text.
+type Label struct {
+	Rect  image.Rectangle
+	Color color.Color
+	Scale int
+	Align Align
+
+	mu   sync.Mutex
+	text string
+}
+
+// NewLabel returns left aligned Label of text.
+func NewLabel(r image.Rectangle, text string, c color.Color, scale int) *Label {
+	return &Label{
+		Rect:  r,
+		Color: c,
+		Scale: scale,
+		text:  text,
+	}
+}
+
+// SetText changes text of the label.
+func (w *Label) SetText(text string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.text = text
+}
+
+func (w *Label) Bounds() image.Rectangle { return w.Rect }
+
+func (w *Label) RefreshInterval() time.Duration { return 0 }
+
+func (w *Label) Render(dst draw.Image, now time.Time) {
+	w.mu.Lock()
+	text := w.text
+	w.mu.Unlock()
+
+	drawText(dst, w.Rect, text, w.Color, w.Scale, w.Align)
+}
+
+// Icon shows an image fitted in its bounds, keeping aspect ratio.
+type Icon struct {
+	rect image.Rectangle
+	img  image.Image
+}
+
+// NewIcon returns Icon of img fitted to r.
+func NewIcon(r image.Rectangle, img image.Image) *Icon {
+	return &Icon{
+		rect: r,
+		img:  resize.Thumbnail(uint(r.Dx()), uint(r.Dy()), img, resize.Lanczos3),
+	}
+}
+
+func (w *Icon) Bounds() image.Rectangle { return w.rect }
+
+func (w *Icon) RefreshInterval() time.Duration { return 0 }
+
+func (w *Icon) Render(dst draw.Image, now time.Time) {
+	ib := w.img.Bounds()
+	p := w.rect.Min.Add(image.Pt((w.rect.Dx()-ib.Dx())/2, (w.rect.Dy()-ib.Dy())/2))
+	draw.Draw(dst, image.Rectangle{p, p.Add(ib.Size())}, w.img, ib.Min, draw.Over)
+}
+
+// ProgressBar shows value of 0~1 as horizontal bar.
+type ProgressBar struct {
+	Rect       image.Rectangle
+	Color      color.Color
+	Background color.Color // optional
+
+	mu    sync.Mutex
+	value float64
+}
+
+// NewProgressBar returns ProgressBar of value 0.
+func NewProgressBar(r image.Rectangle, c color.Color) *ProgressBar {
+	return &ProgressBar{
+		Rect:  r,
+		Color: c,
+	}
+}
+
+// Set sets value, clamped to 0~1.
+func (w *ProgressBar) Set(v float64) {
+	if v < 0 {
+		v = 0
+	} else if v > 1 {
+		v = 1
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.value = v
+}
+
+func (w *ProgressBar) Bounds() image.Rectangle { return w.Rect }
+
+func (w *ProgressBar) RefreshInterval() time.Duration { return 0 }
+
+func (w *ProgressBar) Render(dst draw.Image, now time.Time) {
+	w.mu.Lock()
+	v := w.value
+	w.mu.Unlock()
+
+	if w.Background != nil {
+		draw.Draw(dst, w.Rect, image.NewUniform(w.Background), image.Point{}, draw.Src)
+	}
+	fill := w.Rect
+	fill.Max.X = fill.Min.X + int(float64(w.Rect.Dx())*v+0.5)
+	draw.Draw(dst, fill, image.NewUniform(w.Color), image.Point{}, draw.Src)
+}
+
+// Sparkline shows recent values as a line scaled to min and max of them.
+// It keeps as many values as its width.
+type Sparkline struct {
+	Rect  image.Rectangle
+	Color color.Color
+
+	mu     sync.Mutex
+	values []float64
+}
+
+// NewSparkline returns empty Sparkline.
+func NewSparkline(r image.Rectangle, c color.Color) *Sparkline {
+	return &Sparkline{
+		Rect:  r,
+		Color: c,
+	}
+}
+
+// Push appends v and drops the oldest one if it's full.
+func (w *Sparkline) Push(v float64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.values = append(w.values, v)
+	if n := w.Rect.Dx(); len(w.values) > n {
+		w.values = w.values[len(w.values)-n:]
+	}
+}
+
+func (w *Sparkline) Bounds() image.Rectangle { return w.Rect }
+
+func (w *Sparkline) RefreshInterval() time.Duration { return 0 }
+
+func (w *Sparkline) Render(dst draw.Image, now time.Time) {
+	w.mu.Lock()
+	values := append([]float64(nil), w.values...)
+	w.mu.Unlock()
+
+	chart.DrawSparkline(dst, w.Rect, values, chart.Options{Color: w.Color})
+}